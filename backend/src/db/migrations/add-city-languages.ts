@@ -0,0 +1,67 @@
+import Database from 'better-sqlite3';
+import path from 'path';
+import { fileURLToPath } from 'url';
+import { dirname } from 'path';
+
+const __filename = fileURLToPath(import.meta.url);
+const __dirname = dirname(__filename);
+
+const dbPath = path.join(__dirname, '../../../data/metro.db');
+const db = new Database(dbPath);
+
+console.log('🚀 Adding city_languages table for required station name languages...\n');
+
+// Languages of each network's signage, besides English
+const defaultLanguages: Record<string, string[]> = {
+  delhi: ['hi'],
+  noida: ['hi'],
+  bangalore: ['kn'],
+  mumbai: ['mr'],
+  kolkata: ['bn'],
+  chennai: ['ta'],
+  hyderabad: ['te'],
+};
+
+try {
+  db.exec('BEGIN TRANSACTION');
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS city_languages (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      city_id TEXT NOT NULL REFERENCES cities(id),
+      lang TEXT NOT NULL
+    )
+  `);
+
+  db.exec(`
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_city_languages_city_lang ON city_languages(city_id, lang);
+  `);
+
+  // Only seed cities that exist in this database
+  const insert = db.prepare(`
+    INSERT OR IGNORE INTO city_languages (city_id, lang)
+    SELECT id, ? FROM cities WHERE id = ?
+  `);
+  let seeded = 0;
+  for (const [cityId, langs] of Object.entries(defaultLanguages)) {
+    for (const lang of langs) {
+      seeded += insert.run(lang, cityId).changes;
+    }
+  }
+
+  db.exec('COMMIT');
+
+  console.log('✅ Successfully created city_languages table');
+  console.log(`   - Seeded ${seeded} city languages`);
+  console.log('\n📊 Table Structure:');
+  console.log('   - city_languages: a language every station of a city must be named in, besides English');
+
+} catch (error) {
+  db.exec('ROLLBACK');
+  console.error('❌ Error creating city_languages table:', error);
+  process.exit(1);
+}
+
+db.close();
+console.log('\n✅ Database connection closed');
+console.log('🎉 City languages ready!');
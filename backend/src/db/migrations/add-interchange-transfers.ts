@@ -0,0 +1,62 @@
+import Database from 'better-sqlite3';
+import path from 'path';
+import { fileURLToPath } from 'url';
+import { dirname } from 'path';
+
+const __filename = fileURLToPath(import.meta.url);
+const __dirname = dirname(__filename);
+
+const dbPath = path.join(__dirname, '../../../data/metro.db');
+const db = new Database(dbPath);
+
+// Routing used a flat 120s per transfer before this table existed
+const DEFAULT_WALK_SECONDS = 120;
+
+console.log('🚀 Adding interchange_transfers table for line change times...\n');
+
+try {
+  db.exec('BEGIN TRANSACTION');
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS interchange_transfers (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      station_id TEXT NOT NULL REFERENCES metro_stations(id),
+      from_line_id TEXT NOT NULL REFERENCES metro_lines(id),
+      to_line_id TEXT NOT NULL REFERENCES metro_lines(id),
+      walk_seconds INTEGER NOT NULL CHECK(walk_seconds > 0)
+    )
+  `);
+
+  db.exec(`
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_interchange_transfers_station_lines
+      ON interchange_transfers(station_id, from_line_id, to_line_id);
+  `);
+
+  // Seed every line pair at every interchange with the old flat penalty,
+  // so routing is unchanged until real walking times are measured
+  const seeded = db.prepare(`
+    INSERT OR IGNORE INTO interchange_transfers (station_id, from_line_id, to_line_id, walk_seconds)
+    SELECT DISTINCT a.station_id, a.line_id, b.line_id, ?
+    FROM line_stations a
+    JOIN line_stations b ON a.station_id = b.station_id AND a.line_id <> b.line_id
+  `).run(DEFAULT_WALK_SECONDS);
+
+  db.exec('COMMIT');
+
+  console.log('✅ Successfully created interchange_transfers table');
+  console.log(`✅ Seeded ${seeded.changes} line pairs with ${DEFAULT_WALK_SECONDS}s`);
+  console.log('\n📊 Table Structure:');
+  console.log('   - station_id: Interchange station');
+  console.log('   - from_line_id: Line the passenger arrives on');
+  console.log('   - to_line_id: Line the passenger departs on');
+  console.log('   - walk_seconds: Platform to platform walking time');
+
+} catch (error) {
+  db.exec('ROLLBACK');
+  console.error('❌ Error creating interchange_transfers table:', error);
+  process.exit(1);
+}
+
+db.close();
+console.log('\n✅ Database connection closed');
+console.log('🎉 Interchange transfer times ready!');
@@ -0,0 +1,57 @@
+import Database from 'better-sqlite3';
+import path from 'path';
+import { fileURLToPath } from 'url';
+import { dirname } from 'path';
+
+const __filename = fileURLToPath(import.meta.url);
+const __dirname = dirname(__filename);
+
+const dbPath = path.join(__dirname, '../../../data/metro.db');
+const db = new Database(dbPath);
+
+console.log('🚀 Adding station_facilities and station_exits tables for accessibility data...\n');
+
+try {
+  db.exec('BEGIN TRANSACTION');
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS station_facilities (
+      station_id TEXT PRIMARY KEY REFERENCES metro_stations(id),
+      step_free INTEGER NOT NULL DEFAULT 0 CHECK(step_free IN (0, 1)),
+      lifts INTEGER NOT NULL DEFAULT 0 CHECK(lifts >= 0),
+      escalators INTEGER NOT NULL DEFAULT 0 CHECK(escalators >= 0),
+      parking INTEGER NOT NULL DEFAULT 0 CHECK(parking IN (0, 1)),
+      toilets INTEGER NOT NULL DEFAULT 0 CHECK(toilets IN (0, 1))
+    )
+  `);
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS station_exits (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      station_id TEXT NOT NULL REFERENCES metro_stations(id),
+      name TEXT NOT NULL,
+      latitude REAL NOT NULL,
+      longitude REAL NOT NULL
+    )
+  `);
+
+  db.exec(`
+    CREATE INDEX IF NOT EXISTS idx_station_exits_station_id ON station_exits(station_id);
+  `);
+
+  db.exec('COMMIT');
+
+  console.log('✅ Successfully created station_facilities and station_exits tables');
+  console.log('\n📊 Table Structure:');
+  console.log('   - station_facilities: step_free, lifts, escalators, parking, toilets per station');
+  console.log('   - station_exits: name and coordinates of each exit');
+
+} catch (error) {
+  db.exec('ROLLBACK');
+  console.error('❌ Error creating station facilities tables:', error);
+  process.exit(1);
+}
+
+db.close();
+console.log('\n✅ Database connection closed');
+console.log('🎉 Station accessibility data ready!');
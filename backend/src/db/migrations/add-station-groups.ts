@@ -0,0 +1,56 @@
+import Database from 'better-sqlite3';
+import path from 'path';
+import { fileURLToPath } from 'url';
+import { dirname } from 'path';
+
+const __filename = fileURLToPath(import.meta.url);
+const __dirname = dirname(__filename);
+
+const dbPath = path.join(__dirname, '../../../data/metro.db');
+const db = new Database(dbPath);
+
+console.log('🚀 Adding station_groups tables for station complexes...\n');
+
+try {
+  db.exec('BEGIN TRANSACTION');
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS station_groups (
+      id TEXT PRIMARY KEY,
+      city_id TEXT NOT NULL REFERENCES cities(id),
+      name TEXT NOT NULL,
+      walk_seconds INTEGER NOT NULL CHECK(walk_seconds > 0)
+    )
+  `);
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS station_group_members (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      group_id TEXT NOT NULL REFERENCES station_groups(id),
+      station_id TEXT NOT NULL REFERENCES metro_stations(id)
+    )
+  `);
+
+  // A station belongs to at most one complex
+  db.exec(`
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_station_group_members_station_id
+      ON station_group_members(station_id);
+  `);
+
+  db.exec('COMMIT');
+
+  console.log('✅ Successfully created station_groups and station_group_members tables');
+  console.log('\n📊 Table Structure:');
+  console.log('   - station_groups.id: Complex ID, e.g. delhi-karkarduma-complex');
+  console.log('   - station_groups.walk_seconds: Walkway time between any two members');
+  console.log('   - station_group_members: One row per station in a complex');
+
+} catch (error) {
+  db.exec('ROLLBACK');
+  console.error('❌ Error creating station_groups tables:', error);
+  process.exit(1);
+}
+
+db.close();
+console.log('\n✅ Database connection closed');
+console.log('🎉 Station complexes ready!');
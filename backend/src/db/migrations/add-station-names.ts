@@ -0,0 +1,46 @@
+import Database from 'better-sqlite3';
+import path from 'path';
+import { fileURLToPath } from 'url';
+import { dirname } from 'path';
+
+const __filename = fileURLToPath(import.meta.url);
+const __dirname = dirname(__filename);
+
+const dbPath = path.join(__dirname, '../../../data/metro.db');
+const db = new Database(dbPath);
+
+console.log('🚀 Adding station_names table for multilingual station names...\n');
+
+try {
+  db.exec('BEGIN TRANSACTION');
+
+  db.exec(`
+    CREATE TABLE IF NOT EXISTS station_names (
+      id INTEGER PRIMARY KEY AUTOINCREMENT,
+      station_id TEXT NOT NULL REFERENCES metro_stations(id),
+      lang TEXT NOT NULL,
+      name TEXT NOT NULL,
+      transliteration TEXT
+    )
+  `);
+
+  db.exec(`
+    CREATE UNIQUE INDEX IF NOT EXISTS idx_station_names_station_lang ON station_names(station_id, lang);
+  `);
+
+  db.exec('COMMIT');
+
+  console.log('✅ Successfully created station_names table');
+  console.log('\n📊 Table Structure:');
+  console.log('   - station_names: name of a station in one language (hi, kn, ...), in its own script');
+  console.log('   - transliteration: Latin spelling of the name, used for search');
+
+} catch (error) {
+  db.exec('ROLLBACK');
+  console.error('❌ Error creating station_names table:', error);
+  process.exit(1);
+}
+
+db.close();
+console.log('\n✅ Database connection closed');
+console.log('🎉 Station names ready!');
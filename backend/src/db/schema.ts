@@ -85,6 +85,63 @@ export const trainSightings = sqliteTable('train_sightings', {
   createdAt: integer('created_at', { mode: 'timestamp' }).notNull(),
 });
 
+// Walking time between platforms when changing lines at an interchange
+export const interchangeTransfers = sqliteTable('interchange_transfers', {
+  id: integer('id').primaryKey({ autoIncrement: true }),
+  stationId: text('station_id').notNull().references(() => metroStations.id),
+  fromLineId: text('from_line_id').notNull().references(() => metroLines.id),
+  toLineId: text('to_line_id').notNull().references(() => metroLines.id),
+  walkSeconds: integer('walk_seconds').notNull(), // Platform to platform, incl. stairs and lifts
+});
+
+// Station complexes: separate stations joined by a walkway (e.g. two halves of one interchange)
+export const stationGroups = sqliteTable('station_groups', {
+  id: text('id').primaryKey(), // e.g., 'delhi-karkarduma-complex'
+  cityId: text('city_id').notNull().references(() => cities.id),
+  name: text('name').notNull(),
+  walkSeconds: integer('walk_seconds').notNull(), // Walkway time between any two members
+});
+
+export const stationGroupMembers = sqliteTable('station_group_members', {
+  id: integer('id').primaryKey({ autoIncrement: true }),
+  groupId: text('group_id').notNull().references(() => stationGroups.id),
+  stationId: text('station_id').notNull().references(() => metroStations.id),
+});
+
+// Accessibility and amenities, one row per station
+export const stationFacilities = sqliteTable('station_facilities', {
+  stationId: text('station_id').primaryKey().references(() => metroStations.id),
+  stepFree: integer('step_free', { mode: 'boolean' }).notNull().default(false), // Street to platform without stairs
+  lifts: integer('lifts').notNull().default(0),
+  escalators: integer('escalators').notNull().default(0),
+  parking: integer('parking', { mode: 'boolean' }).notNull().default(false),
+  toilets: integer('toilets', { mode: 'boolean' }).notNull().default(false),
+});
+
+export const stationExits = sqliteTable('station_exits', {
+  id: integer('id').primaryKey({ autoIncrement: true }),
+  stationId: text('station_id').notNull().references(() => metroStations.id),
+  name: text('name').notNull(), // e.g., 'Gate 3'
+  latitude: real('latitude').notNull(),
+  longitude: real('longitude').notNull(),
+});
+
+// Station names in local languages; metro_stations.name is the English name
+export const stationNames = sqliteTable('station_names', {
+  id: integer('id').primaryKey({ autoIncrement: true }),
+  stationId: text('station_id').notNull().references(() => metroStations.id),
+  lang: text('lang').notNull(), // ISO 639-1: 'hi', 'kn', ...
+  name: text('name').notNull(), // In the language's own script
+  transliteration: text('transliteration'), // Latin spelling, for search
+});
+
+// Languages every station of a city must be named in, besides English
+export const cityLanguages = sqliteTable('city_languages', {
+  id: integer('id').primaryKey({ autoIncrement: true }),
+  cityId: text('city_id').notNull().references(() => cities.id),
+  lang: text('lang').notNull(), // ISO 639-1: 'hi', 'kn', ...
+});
+
 // TypeScript types for the schema
 export type City = typeof cities.$inferSelect;
 export type MetroLine = typeof metroLines.$inferSelect;
@@ -103,3 +160,17 @@ export type InsertTrainSchedule = typeof trainSchedules.$inferInsert;
 export type InsertPeakHour = typeof peakHours.$inferInsert;
 export type TrainSighting = typeof trainSightings.$inferSelect;
 export type InsertTrainSighting = typeof trainSightings.$inferInsert;
+export type InterchangeTransfer = typeof interchangeTransfers.$inferSelect;
+export type InsertInterchangeTransfer = typeof interchangeTransfers.$inferInsert;
+export type StationGroup = typeof stationGroups.$inferSelect;
+export type InsertStationGroup = typeof stationGroups.$inferInsert;
+export type StationGroupMember = typeof stationGroupMembers.$inferSelect;
+export type InsertStationGroupMember = typeof stationGroupMembers.$inferInsert;
+export type StationFacilities = typeof stationFacilities.$inferSelect;
+export type InsertStationFacilities = typeof stationFacilities.$inferInsert;
+export type StationExit = typeof stationExits.$inferSelect;
+export type InsertStationExit = typeof stationExits.$inferInsert;
+export type StationName = typeof stationNames.$inferSelect;
+export type InsertStationName = typeof stationNames.$inferInsert;
+export type CityLanguage = typeof cityLanguages.$inferSelect;
+export type InsertCityLanguage = typeof cityLanguages.$inferInsert;
@@ -37,8 +37,9 @@ interface ValidationResponse {
   stats: ValidationStats;
   results: Record<string, ValidationCategoryResult>;
   issues: ValidationIssue[];
-  status: 'pass' | 'pass_with_warnings' | 'fail' | 'error';
+  status: 'pass' | 'pass_with_warnings' | 'fail' | 'partial' | 'schema_error' | 'error';
   error?: string;
+  loadErrors?: Record<string, string>;
 }
 
 interface UseDataValidatorReturn {
@@ -103,12 +104,18 @@ export function useDataValidator(): UseDataValidatorReturn {
 
       const response = await fetch(`${VALIDATOR_API_URL}/api/validate`);
 
-      if (!response.ok) {
+      // Schema and load failures still return a JSON body with details
+      const data: ValidationResponse | null = await response.json().catch(() => null);
+      if (!data) {
         throw new Error(`Validation request failed: ${response.statusText}`);
       }
-
-      const data: ValidationResponse = await response.json();
-      setResults(data);
+      // Older servers omit these fields on failure responses
+      setResults({
+        ...data,
+        stats: data.stats ?? { Cities: 0, Lines: 0, Stations: 0, Connections: 0 },
+        results: data.results ?? {},
+        issues: data.issues ?? [],
+      });
 
       if (data.error) {
         setError(data.error);
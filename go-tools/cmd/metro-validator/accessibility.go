@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metro-tools/internal/database"
+)
+
+// hasLifts reports whether a station can be used without stairs
+func (n *networkSnapshot) hasLifts(station string) bool {
+	return n.facilities[station].Lifts > 0
+}
+
+// stepFreeFilter reads the step_free query parameter. For step-free requests it
+// returns the station filter routing should apply, after checking that the
+// journey can start and end without stairs. It writes the error response and
+// returns false when the request cannot be served.
+func stepFreeFilter(w http.ResponseWriter, r *http.Request, snap *networkSnapshot, from, to database.MetroStation) (func(string) bool, bool) {
+	value := r.URL.Query().Get("step_free")
+	if value == "" {
+		return nil, true
+	}
+	stepFree, err := strconv.ParseBool(value)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "step_free must be true or false")
+		return nil, false
+	}
+	if !stepFree {
+		return nil, true
+	}
+
+	for _, st := range []database.MetroStation{from, to} {
+		if !snap.hasLifts(st.ID) {
+			writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Station '%s' has no lifts", st.ID))
+			return nil, false
+		}
+	}
+	return snap.hasLifts, true
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/sightings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	analyzeSince     time.Duration
+	analyzeLimit     int
+	calibrateSQL     string
+	calibrateSamples int
+	calibrateLine    string
+	calibrateShowAll bool
+)
+
+// newAnalyzeCmd creates the analyze command and its subcommands
+func newAnalyzeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyse crowdsourced and derived data",
+	}
+
+	sightingsCmd := &cobra.Command{
+		Use:   "sightings",
+		Short: "Report train sighting volume, quality and likely spam",
+		Long: "Loads train_sightings and reports volume per line, direction and hour, the share of reports with GPS,\n" +
+			"the confidence_score distribution, and reporters whose sightings look like spam: impossible jumps\n" +
+			"between stations given station_connections travel times, or bursts of reports from far away.",
+		Run: runAnalyzeSightings,
+	}
+	sightingsCmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	sightingsCmd.Flags().DurationVar(&analyzeSince, "since", 0, "Only analyse sightings newer than this (e.g. 168h); 0 for all")
+	sightingsCmd.Flags().IntVar(&analyzeLimit, "limit", 20, "Maximum number of suspects to print")
+	cmd.AddCommand(sightingsCmd)
+
+	travelCmd := &cobra.Command{
+		Use:   "travel-times",
+		Short: "Calibrate station_connections travel times from train sightings",
+		Long: "Pairs consecutive train_sightings of the same train at adjacent stations and compares the observed\n" +
+			"station-to-station intervals (median, IQR, sample size) with the seeded travel_time_seconds, per\n" +
+			"segment and time of day. Optionally writes an SQL patch for segments with enough evidence.",
+		Run: runAnalyzeTravelTimes,
+	}
+	travelCmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	travelCmd.Flags().DurationVar(&analyzeSince, "since", 0, "Only use sightings newer than this (e.g. 720h); 0 for all")
+	travelCmd.Flags().StringVar(&calibrateSQL, "sql", "", "Write UPDATE statements for recommended segments to this file")
+	travelCmd.Flags().IntVar(&calibrateSamples, "min-samples", sightings.DefaultCalibrationOptions.MinSamples, "Samples a segment needs before a change is recommended")
+	travelCmd.Flags().StringVar(&calibrateLine, "line", "", "Only report segments on this line")
+	travelCmd.Flags().BoolVar(&calibrateShowAll, "all", false, "Show per-band statistics for every observed segment")
+	cmd.AddCommand(travelCmd)
+
+	return cmd
+}
+
+func runAnalyzeSightings(cmd *cobra.Command, args []string) {
+	if !jsonOut {
+		printHeader()
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+	defer db.Close()
+
+	cities, err := db.GetAllCities()
+	if err != nil {
+		exitWithError("Failed to load cities", err)
+	}
+	lines, err := db.GetAllLines()
+	if err != nil {
+		exitWithError("Failed to load lines", err)
+	}
+	stations, err := db.GetAllStations()
+	if err != nil {
+		exitWithError("Failed to load stations", err)
+	}
+	connections, err := db.GetAllConnections()
+	if err != nil {
+		exitWithError("Failed to load connections", err)
+	}
+	all, err := db.GetAllTrainSightings()
+	if err != nil {
+		exitWithError("Failed to load sightings", err)
+	}
+
+	all = filterSince(all, analyzeSince)
+
+	report := sightings.Analyze(all, cities, lines, stations, connections, sightings.DefaultOptions)
+
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+	printSightingsReport(report)
+}
+
+func runAnalyzeTravelTimes(cmd *cobra.Command, args []string) {
+	if !jsonOut {
+		printHeader()
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+	defer db.Close()
+
+	cities, err := db.GetAllCities()
+	if err != nil {
+		exitWithError("Failed to load cities", err)
+	}
+	lines, err := db.GetAllLines()
+	if err != nil {
+		exitWithError("Failed to load lines", err)
+	}
+	lineStations, err := db.GetAllLineStations()
+	if err != nil {
+		exitWithError("Failed to load line stations", err)
+	}
+	connections, err := db.GetAllConnections()
+	if err != nil {
+		exitWithError("Failed to load connections", err)
+	}
+	all, err := db.GetAllTrainSightings()
+	if err != nil {
+		exitWithError("Failed to load sightings", err)
+	}
+	all = filterSince(all, analyzeSince)
+
+	opts := sightings.DefaultCalibrationOptions
+	opts.MinSamples = calibrateSamples
+	cal := sightings.Calibrate(all, cities, lines, lineStations, connections, opts)
+
+	if calibrateLine != "" {
+		filtered := cal.Segments[:0]
+		cal.Recommended = 0
+		for _, s := range cal.Segments {
+			if s.LineID == calibrateLine {
+				filtered = append(filtered, s)
+				if s.Recommended {
+					cal.Recommended++
+				}
+			}
+		}
+		cal.Segments = filtered
+	}
+
+	if calibrateSQL != "" {
+		if err := os.WriteFile(calibrateSQL, []byte(cal.SQLPatch()), 0644); err != nil {
+			exitWithError("Failed to write SQL patch", err)
+		}
+	}
+
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(cal)
+		return
+	}
+	printCalibration(cal)
+}
+
+// filterSince keeps sightings newer than the given age; 0 keeps all
+func filterSince(all []database.TrainSighting, since time.Duration) []database.TrainSighting {
+	if since <= 0 {
+		return all
+	}
+	cutoff := time.Now().Add(-since)
+	recent := all[:0]
+	for _, s := range all {
+		if s.Timestamp.After(cutoff) {
+			recent = append(recent, s)
+		}
+	}
+	return recent
+}
+
+// exitWithError prints an error in the selected output format and exits
+func exitWithError(what string, err error) {
+	if jsonOut {
+		outputError(fmt.Errorf("%s: %w", strings.ToLower(what[:1])+what[1:], err))
+	} else {
+		fmt.Printf("%s %s: %v\n", red("ERROR:"), what, err)
+	}
+	os.Exit(1)
+}
+
+func printSightingsReport(r *sightings.Report) {
+	fmt.Printf("  %s %s\n", cyan("Database:"), dbPath)
+	if r.Total == 0 {
+		fmt.Printf("  %s No train sightings to analyse\n\n", cyan("Sightings:"))
+		return
+	}
+	fmt.Printf("  %s %d (%s to %s)\n", cyan("Sightings:"), r.Total, r.From, r.To)
+	fmt.Printf("  %s %.1f%% with GPS | %d anonymous | %d verified | mean confidence %.2f\n",
+		cyan("Quality:"), r.GPSShare*100, r.Anonymous, r.Verified, r.MeanConfidence)
+	fmt.Println()
+
+	// Confidence histogram
+	fmt.Println(bold("  Confidence score"))
+	maxCount := 0
+	for _, b := range r.Confidence {
+		if b.Count > maxCount {
+			maxCount = b.Count
+		}
+	}
+	for _, b := range r.Confidence {
+		bar := ""
+		if maxCount > 0 {
+			bar = strings.Repeat("█", b.Count*30/maxCount)
+		}
+		fmt.Printf("    %.1f-%.1f %s %s\n", b.Min, b.Max, cyan(fmt.Sprintf("%-30s", bar)), dimmed(fmt.Sprint(b.Count)))
+	}
+	fmt.Println()
+
+	// Hourly volume per line and direction as a 24-hour sparkline
+	fmt.Println(bold("  Volume by line, direction and hour (00-23)"))
+	type series struct {
+		key   string
+		hours [24]int
+		total int
+	}
+	var order []string
+	bySeries := make(map[string]*series)
+	for _, v := range r.Volumes {
+		key := fmt.Sprintf("%s %s", v.LineID, v.Direction)
+		s, ok := bySeries[key]
+		if !ok {
+			s = &series{key: key}
+			bySeries[key] = s
+			order = append(order, key)
+		}
+		s.hours[v.Hour] += v.Count
+		s.total += v.Count
+	}
+	for _, key := range order {
+		s := bySeries[key]
+		peak := 0
+		for h := range s.hours {
+			if s.hours[h] > s.hours[peak] {
+				peak = h
+			}
+		}
+		fmt.Printf("    %-32s %s %s\n", key, sparkline(s.hours[:]),
+			dimmed(fmt.Sprintf("total %d, peak %02d:00 (%d)", s.total, peak, s.hours[peak])))
+	}
+	fmt.Println()
+
+	// Likely spam
+	fmt.Printf("  %s %d\n", bold("Likely spam:"), len(r.Suspects))
+	for i, s := range r.Suspects {
+		if i >= analyzeLimit {
+			fmt.Printf("    %s\n", dimmed(fmt.Sprintf("... %d more (use --limit or --json)", len(r.Suspects)-analyzeLimit)))
+			break
+		}
+		fmt.Printf("    %s %s %s: %s %s\n", yellow("⚠"), s.Kind, s.Reporter, s.Detail, dimmed(fmt.Sprintf("%v", s.SightingIDs)))
+	}
+	fmt.Println()
+}
+
+// sparkline renders counts as a row of block characters
+func sparkline(values []int) string {
+	blocks := []rune(" ▁▂▃▄▅▆▇█")
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if maxValue > 0 {
+			idx = v * (len(blocks) - 1) / maxValue
+		}
+		b.WriteRune(blocks[idx])
+	}
+	return b.String()
+}
+
+func printCalibration(cal *sightings.Calibration) {
+	fmt.Printf("  %s %s\n", cyan("Database:"), dbPath)
+	fmt.Printf("  %s %d sightings -> %d paired samples over %d segments (%d segments unobserved)\n",
+		cyan("Samples:"), cal.Sightings, cal.Samples, len(cal.Segments), cal.Unobserved)
+	fmt.Println()
+	if len(cal.Segments) == 0 {
+		fmt.Printf("  %s\n\n", dimmed("Not enough consecutive sightings to calibrate any segment"))
+		return
+	}
+
+	fmt.Println(bold("  Observed station-to-station times (travel + dwell)"))
+	currentLine := ""
+	for _, s := range cal.Segments {
+		if s.LineID+s.Direction != currentLine {
+			currentLine = s.LineID + s.Direction
+			fmt.Printf("\n  %s\n", bold(fmt.Sprintf("%s (%s)", s.LineID, s.Direction)))
+		}
+
+		status := dimmed("  ")
+		delta := dimmed(fmt.Sprintf("%+4ds", s.DeltaSeconds))
+		if s.Recommended {
+			status = yellow("⚠ ")
+			delta = yellow(fmt.Sprintf("%+4ds", s.DeltaSeconds))
+		}
+		fmt.Printf("    %s%-26s -> %-26s seeded %4ds | median %5.0fs IQR %4.0fs n=%-4d | est. travel %4ds %s\n",
+			status, s.FromStationID, s.ToStationID, s.TravelTimeSeconds+s.StopTimeSeconds,
+			s.Observed.Median, s.Observed.IQR, s.Observed.N, s.EstimatedTravelSeconds, delta)
+
+		if calibrateShowAll || s.Recommended {
+			for _, b := range s.Bands {
+				fmt.Printf("      %s\n", dimmed(fmt.Sprintf("%-13s median %5.0fs IQR %4.0fs n=%d", b.Band, b.Median, b.IQR, b.N)))
+			}
+		}
+	}
+	fmt.Println()
+
+	fmt.Printf("  %s %d segment(s) differ from the seeded travel time\n", bold("Recommended changes:"), cal.Recommended)
+	if calibrateSQL != "" {
+		fmt.Printf("  %s %s\n", cyan("SQL patch:"), calibrateSQL)
+	} else if cal.Recommended > 0 {
+		fmt.Printf("  %s\n", dimmed("Use --sql <file> to write an UPDATE patch"))
+	}
+	fmt.Println()
+}
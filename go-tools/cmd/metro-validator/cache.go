@@ -0,0 +1,179 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"metro-tools/internal/rules"
+)
+
+// cachedValidation is an encoded validation response ready to serve
+type cachedValidation struct {
+	body   []byte
+	status int
+	etag   string
+	files  map[string]fileState
+}
+
+// validationCache serves the last validation result until the database changes
+type validationCache struct {
+	dbPath  string
+	ruleSet *rules.RuleSet
+
+	mu      sync.RWMutex
+	current *cachedValidation
+	// refreshing is set by the caller that starts a background refresh and
+	// cleared by that refresh when it finishes
+	refreshing bool
+
+	// first serialises the synchronous validation before anything is cached
+	first sync.Mutex
+}
+
+func newValidationCache(dbPath string, ruleSet *rules.RuleSet) *validationCache {
+	return &validationCache{dbPath: dbPath, ruleSet: ruleSet}
+}
+
+// refresh re-runs validation and replaces the cached response. When the
+// response cannot be encoded, the previous entry is kept but marked as
+// current for this database, so the watcher waits for the next change
+// instead of retrying.
+func (c *validationCache) refresh() (*cachedValidation, error) {
+	// Snapshot before validating so a write during the run triggers another refresh
+	files := snapshotFiles(c.dbPath, nil)
+	response, status := validate(c.dbPath, c.ruleSet)
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		c.mu.Lock()
+		if c.current != nil {
+			kept := *c.current
+			kept.files = files
+			c.current = &kept
+		}
+		c.mu.Unlock()
+		return nil, fmt.Errorf("failed to encode validation response: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	entry := &cachedValidation{
+		body:   append(body, '\n'),
+		status: status,
+		etag:   `"` + hex.EncodeToString(sum[:8]) + `"`,
+		files:  files,
+	}
+
+	c.mu.Lock()
+	c.current = entry
+	c.mu.Unlock()
+	return entry, nil
+}
+
+// stale reports whether the database changed since the cached run
+func (c *validationCache) stale(entry *cachedValidation) bool {
+	return !reflect.DeepEqual(entry.files, snapshotFiles(c.dbPath, nil))
+}
+
+// refreshInBackground starts a refresh unless one is already running
+func (c *validationCache) refreshInBackground() {
+	c.mu.Lock()
+	if c.refreshing {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing = true
+	c.mu.Unlock()
+
+	go func() {
+		if _, err := c.refresh(); err != nil {
+			log.Printf("Failed to refresh validation: %v", err)
+		}
+		c.mu.Lock()
+		c.refreshing = false
+		c.mu.Unlock()
+	}()
+}
+
+// initial validates synchronously when nothing is cached yet; concurrent
+// first requests wait for a single run
+func (c *validationCache) initial() (*cachedValidation, error) {
+	c.first.Lock()
+	defer c.first.Unlock()
+
+	c.mu.RLock()
+	entry := c.current
+	c.mu.RUnlock()
+	if entry != nil {
+		return entry, nil
+	}
+	return c.refresh()
+}
+
+// watch polls the database file and re-validates in the background when it changes
+func (c *validationCache) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		c.mu.RLock()
+		entry := c.current
+		c.mu.RUnlock()
+		if entry != nil && c.stale(entry) {
+			c.refreshInBackground()
+		}
+	}
+}
+
+// ServeHTTP serves the cached validation, honouring If-None-Match for successful results
+func (c *validationCache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	entry := c.current
+	c.mu.RUnlock()
+
+	if entry == nil {
+		// First request after startup: validate synchronously
+		var err error
+		if entry, err = c.initial(); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	} else if c.stale(entry) {
+		// Serve the previous result while the new one is computed
+		c.refreshInBackground()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// Only successful results are revalidated; errors are always resent
+	if entry.status == http.StatusOK {
+		w.Header().Set("ETag", entry.etag)
+		if etagMatches(r.Header.Get("If-None-Match"), entry.etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// etagMatches checks an If-None-Match header against an ETag
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
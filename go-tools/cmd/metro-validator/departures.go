@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"metro-tools/internal/timetable"
+)
+
+const (
+	defaultDeparturesLimit = 3
+	maxDeparturesLimit     = 20
+	departuresHorizon      = 24 * time.Hour
+)
+
+// DepartureEntry is one train on a departure board
+type DepartureEntry struct {
+	TripID             string `json:"tripId"`
+	DepartureTime      string `json:"departureTime"` // local HH:MM:SS
+	DepartureAt        string `json:"departureAt"`   // RFC 3339 with the city's offset
+	MinutesToDeparture int    `json:"minutesToDeparture"`
+	DestinationID      string `json:"destinationId"`
+	DestinationName    string `json:"destinationName"`
+	Assumed            bool   `json:"assumedSchedule"` // line has no published schedule
+}
+
+// DepartureBoard lists the next departures for one line and direction
+type DepartureBoard struct {
+	LineID     string           `json:"lineId"`
+	LineName   string           `json:"lineName"`
+	LineColor  string           `json:"lineColor"`
+	Direction  string           `json:"direction"`
+	Departures []DepartureEntry `json:"departures"`
+}
+
+// DeparturesResponse is the response of GET /api/stations/{id}/departures
+type DeparturesResponse struct {
+	Success     bool             `json:"success"`
+	StationID   string           `json:"stationId"`
+	StationName string           `json:"stationName"`
+	Timezone    string           `json:"timezone"`
+	At          string           `json:"at"`
+	Boards      []DepartureBoard `json:"boards"`
+}
+
+// stationsHandler routes /api/stations/search, /api/stations/nearby and /api/stations/{id}/... requests
+func stationsHandler(store *networkStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/stations/"), "/"), "/")
+		if len(parts) == 1 && parts[0] == "search" {
+			handleSearch(store, w, r)
+			return
+		}
+		if len(parts) == 1 && parts[0] == "nearby" {
+			handleNearby(store, w, r)
+			return
+		}
+		if len(parts) == 2 && parts[0] != "" && parts[1] == "departures" {
+			handleDepartures(store, parts[0], w, r)
+			return
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown endpoint %s", r.URL.Path))
+	}
+}
+
+// handleDepartures serves the departure board of a station
+func handleDepartures(store *networkStore, stationID string, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+		return
+	}
+
+	snap, err := store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+		return
+	}
+
+	station, ok := snap.stations[stationID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", stationID))
+		return
+	}
+	loc := snap.location(station.CityID)
+	stationNames := snap.namer(w, r, station.CityID)
+
+	at := time.Now()
+	if value := r.URL.Query().Get("at"); value != "" {
+		if at, err = parseLocalTime(value, loc); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	limit := defaultDeparturesLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDeparturesLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxDeparturesLimit))
+			return
+		}
+	}
+
+	response := DeparturesResponse{
+		Success:     true,
+		StationID:   station.ID,
+		StationName: stationNames.name(station.ID),
+		Timezone:    loc.String(),
+		At:          at.In(loc).Format(time.RFC3339),
+		Boards:      []DepartureBoard{},
+	}
+
+	// One board per line and direction, in order of the first departure.
+	// Trip directions follow the line's station order, not the schedule's
+	// label, so every train on a board heads the same way.
+	boards := make(map[string]int)
+	for _, o := range timetable.Upcoming(snap.departures[station.ID], at, loc, departuresHorizon) {
+		key := o.LineID + "|" + o.Direction
+		i, ok := boards[key]
+		if !ok {
+			line := snap.lines[o.LineID]
+			response.Boards = append(response.Boards, DepartureBoard{
+				LineID:    o.LineID,
+				LineName:  line.Name,
+				LineColor: line.Color,
+				Direction: o.Direction,
+			})
+			i = len(response.Boards) - 1
+			boards[key] = i
+		}
+		board := &response.Boards[i]
+		if len(board.Departures) >= limit {
+			continue
+		}
+		board.Departures = append(board.Departures, DepartureEntry{
+			TripID:             o.TripID,
+			DepartureTime:      o.At.Format("15:04:05"),
+			DepartureAt:        o.At.Format(time.RFC3339),
+			MinutesToDeparture: int(o.At.Sub(at) / time.Minute),
+			DestinationID:      o.Destination,
+			DestinationName:    stationNames.name(o.Destination),
+			Assumed:            o.Assumed,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"metro-tools/internal/topology"
+)
+
+func TestDepartureBoards(t *testing.T) {
+	store := newNetworkStore(newTestDB(t), nil)
+	handler := stationsHandler(store)
+
+	tests := []struct {
+		name    string
+		target  string
+		status  int
+		boards  map[string]string // direction -> destination of every departure
+		entries int
+	}{
+		{
+			name:    "one board each way",
+			target:  "/api/stations/b/departures?at=2026-10-18T09:00:00",
+			status:  http.StatusOK,
+			boards:  map[string]string{topology.Forward: "c", topology.Backward: "a"},
+			entries: defaultDeparturesLimit,
+		},
+		{
+			name:    "terminus has only departures away from it",
+			target:  "/api/stations/a/departures?at=2026-10-18T09:00:00&limit=2",
+			status:  http.StatusOK,
+			boards:  map[string]string{topology.Forward: "c"},
+			entries: 2,
+		},
+		{name: "unknown station", target: "/api/stations/z/departures", status: http.StatusNotFound},
+		{name: "bad limit", target: "/api/stations/b/departures?limit=0", status: http.StatusBadRequest},
+		{name: "bad time", target: "/api/stations/b/departures?at=9am", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response DeparturesResponse
+			rec := serve(t, handler, http.MethodGet, tt.target, "", &response)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if len(response.Boards) != len(tt.boards) {
+				t.Fatalf("got %d boards, want %d: %+v", len(response.Boards), len(tt.boards), response.Boards)
+			}
+			for _, board := range response.Boards {
+				destination, ok := tt.boards[board.Direction]
+				if !ok || board.LineID != "delhi-yellow" {
+					t.Errorf("unexpected board %s %s", board.LineID, board.Direction)
+					continue
+				}
+				if len(board.Departures) != tt.entries {
+					t.Errorf("%s board has %d departures, want %d", board.Direction, len(board.Departures), tt.entries)
+				}
+				for _, d := range board.Departures {
+					if d.DestinationID != destination {
+						t.Errorf("%s board lists trip %s to %s, want only trains to %s", board.Direction, d.TripID, d.DestinationID, destination)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/fares"
+	"metro-tools/internal/graph"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	faresPath  string
+	fareFrom   string
+	fareTo     string
+	fareCity   string
+	fareMatrix string
+)
+
+// newFaresCmd creates the fares command
+func newFaresCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fares",
+		Short: "Quote fares and export fare matrices",
+		Long: "Prices journeys with each city's fare model: flat, distance slabs or zone rings. Fares are\n" +
+			"charged on the fastest route between two stations. Its distance is the sum of straight-line\n" +
+			"distances between consecutive stations, which can be shorter than the track on curves.\n" +
+			"Without --fares the built-in Delhi and Bangalore distance slabs are used.",
+		Example: "  metro-validator fares --from delhi-rajiv-chowk --to delhi-huda-city-centre\n" +
+			"  metro-validator fares --city delhi --matrix delhi-fares.csv",
+		Run: runFares,
+	}
+	cmd.Flags().StringVar(&faresPath, "fares", "", "Path to a JSON file of fare models by city")
+	cmd.Flags().StringVar(&fareFrom, "from", "", "Origin station ID")
+	cmd.Flags().StringVar(&fareTo, "to", "", "Destination station ID")
+	cmd.Flags().StringVar(&fareCity, "city", "", "City to export the fare matrix for")
+	cmd.Flags().StringVar(&fareMatrix, "matrix", "", "Write the station-to-station fare matrix of --city to this CSV file")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	return cmd
+}
+
+// loadFareConfig reads the fares file, or returns the built-in models
+func loadFareConfig(path string) (*fares.Config, error) {
+	if path == "" {
+		return fares.Default, nil
+	}
+	return fares.Load(path)
+}
+
+func runFares(cmd *cobra.Command, args []string) {
+	quoting := fareFrom != "" || fareTo != ""
+	if quoting && (fareFrom == "" || fareTo == "") {
+		exitWithError("Invalid flags", fmt.Errorf("--from and --to must be used together"))
+	}
+	if fareMatrix != "" && fareCity == "" {
+		exitWithError("Invalid flags", fmt.Errorf("--matrix needs --city"))
+	}
+	if !quoting && fareMatrix == "" {
+		exitWithError("Invalid flags", fmt.Errorf("use --from and --to, or --city with --matrix"))
+	}
+
+	cfg, err := loadFareConfig(faresPath)
+	if err != nil {
+		exitWithError("Failed to load fares", err)
+	}
+
+	if !jsonOut {
+		printHeader()
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+	defer db.Close()
+
+	cities, err := db.GetAllCities()
+	if err != nil {
+		exitWithError("Failed to load cities", err)
+	}
+	stations, err := db.GetAllStations()
+	if err != nil {
+		exitWithError("Failed to load stations", err)
+	}
+	connections, err := db.GetAllConnections()
+	if err != nil {
+		exitWithError("Failed to load connections", err)
+	}
+
+	g := graph.New(connections)
+	calc := fares.NewCalculator(cfg, cities, stations)
+
+	if quoting {
+		path, ok := g.ShortestPath(fareFrom, fareTo)
+		if !ok {
+			exitWithError("Failed to price journey", fmt.Errorf("no route from %s to %s", fareFrom, fareTo))
+		}
+		quote, err := calc.Quote(path.Stations)
+		if err != nil {
+			exitWithError("Failed to price journey", err)
+		}
+		if jsonOut {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(map[string]interface{}{"fare": quote, "stations": path.Stations})
+			return
+		}
+		printQuote(quote, path)
+	}
+
+	if fareMatrix != "" {
+		var cityStations []string
+		for _, st := range stations {
+			if st.CityID == fareCity && g.HasStation(st.ID) {
+				cityStations = append(cityStations, st.ID)
+			}
+		}
+		if len(cityStations) == 0 {
+			exitWithError("Failed to export fares", fmt.Errorf("no stations with connections in city '%s'", fareCity))
+		}
+		if !calc.Has(fareCity) {
+			exitWithError("Failed to export fares", fmt.Errorf("no fare model for city '%s'", fareCity))
+		}
+		sort.Strings(cityStations)
+
+		if err := writeFareMatrix(fareMatrix, g, calc, cityStations); err != nil {
+			exitWithError("Failed to export fares", err)
+		}
+		if !jsonOut {
+			fmt.Printf("  %s %s (%d stations)\n\n", green("✓ Wrote"), fareMatrix, len(cityStations))
+		}
+	}
+}
+
+// writeFareMatrix writes a square CSV of fares, one row and column per station
+func writeFareMatrix(path string, g *graph.Graph, calc *fares.Calculator, stations []string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(append([]string{"from_station_id"}, stations...))
+	for _, from := range stations {
+		paths := g.PathsFrom(from)
+		row := []string{from}
+		for _, to := range stations {
+			cell := ""
+			if to == from {
+				cell = "0"
+			} else if path, ok := paths[to]; ok {
+				quote, err := calc.Quote(path.Stations)
+				if err != nil {
+					return err
+				}
+				cell = strconv.FormatFloat(quote.Fare, 'f', -1, 64)
+			}
+			row = append(row, cell)
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printQuote(q *fares.Quote, path *graph.Path) {
+	fmt.Printf("  %s %s → %s\n", cyan("Journey:"), fareFrom, fareTo)
+	fmt.Printf("  %s %s %s\n", cyan("Fare:"), bold(strconv.FormatFloat(q.Fare, 'f', -1, 64)), q.Currency)
+	switch q.Model {
+	case fares.ModelDistance:
+		fmt.Printf("  %s %.2f km, slab %d\n", cyan("Basis:"), q.DistanceKm, q.Slab)
+	case fares.ModelZone:
+		fmt.Printf("  %s %d zones (%.2f km), slab %d\n", cyan("Basis:"), q.Zones, q.DistanceKm, q.Slab)
+	default:
+		fmt.Printf("  %s flat fare (%.2f km)\n", cyan("Basis:"), q.DistanceKm)
+	}
+	fmt.Printf("  %s %d stations, %s\n", cyan("Route:"), len(path.Stations), formatDuration(path.Seconds))
+	fmt.Println()
+}
+
+// FareResponse is the response of GET /api/fare
+type FareResponse struct {
+	Success bool         `json:"success"`
+	From    StationRef   `json:"from"`
+	To      StationRef   `json:"to"`
+	Fare    *fares.Quote `json:"fare"`
+	// Stations is the fastest route by train, which the fare is charged on
+	Stations []string `json:"stations"`
+}
+
+// fareHandler prices the fastest route between two stations
+func fareHandler(store *networkStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+			return
+		}
+
+		snap, err := store.get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+			return
+		}
+
+		query := r.URL.Query()
+		fromID, toID := query.Get("from"), query.Get("to")
+		if fromID == "" || toID == "" {
+			writeError(w, http.StatusBadRequest, "from and to are required")
+			return
+		}
+		from, ok := snap.stations[fromID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", fromID))
+			return
+		}
+		to, ok := snap.stations[toID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", toID))
+			return
+		}
+		if from.CityID != to.CityID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be in the same city")
+			return
+		}
+		if from.ID == to.ID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be different")
+			return
+		}
+		if !snap.fares.Has(from.CityID) {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No fare model for city '%s'", from.CityID))
+			return
+		}
+
+		// Walkways between stations of a complex are not ridden, so not charged
+		path, ok := snap.rides.ShortestPath(from.ID, to.ID)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("No route by train from '%s' to '%s'", from.ID, to.ID))
+			return
+		}
+		quote, err := snap.fares.Quote(path.Stations)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		stationNames := snap.namer(w, r, from.CityID)
+		writeJSON(w, http.StatusOK, FareResponse{
+			Success:  true,
+			From:     stationNames.ref(from),
+			To:       stationNames.ref(to),
+			Fare:     quote,
+			Stations: path.Stations,
+		})
+	}
+}
+
+// fareBetween prices a journey the way /api/fare does, so every alternative
+// between two stations shows the fare actually charged. It is nil when the
+// city has no fare model or the stations are not joined by train.
+func (n *networkSnapshot) fareBetween(from, to string) *fares.Quote {
+	path, ok := n.rides.ShortestPath(from, to)
+	if !ok {
+		return nil
+	}
+	quote, err := n.fares.Quote(path.Stations)
+	if err != nil {
+		return nil
+	}
+	return quote
+}
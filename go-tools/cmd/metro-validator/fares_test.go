@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"metro-tools/internal/fares"
+)
+
+func TestFareHandler(t *testing.T) {
+	store := newNetworkStore(newTestDB(t, testComplex), fares.Default)
+	handler := fareHandler(store)
+
+	tests := []struct {
+		name     string
+		target   string
+		status   int
+		stations []string
+	}{
+		{"by train", "/api/fare?from=a&to=c", http.StatusOK, []string{"a", "b", "c"}},
+		// The walkway from c is quicker but is not charged as a ride
+		{"not over a walkway", "/api/fare?from=a&to=d", http.StatusOK, []string{"a", "f", "d"}},
+		{"same station", "/api/fare?from=a&to=a", http.StatusBadRequest, nil},
+		{"unknown station", "/api/fare?from=a&to=z", http.StatusNotFound, nil},
+		{"missing parameter", "/api/fare?from=a", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response FareResponse
+			rec := serve(t, handler, http.MethodGet, tt.target, "", &response)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if !reflect.DeepEqual(response.Stations, tt.stations) {
+				t.Errorf("priced %v, want %v", response.Stations, tt.stations)
+			}
+			if response.Fare == nil || response.Fare.Fare <= 0 {
+				t.Errorf("fare = %+v, want a positive fare", response.Fare)
+			}
+		})
+	}
+}
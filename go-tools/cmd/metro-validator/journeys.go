@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"metro-tools/internal/fares"
+	"metro-tools/internal/journey"
+)
+
+const (
+	defaultJourneysLimit = 3
+	maxJourneysLimit     = 5
+)
+
+// StationRef names a station in API responses
+type StationRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// JourneyLeg is a journey.Leg with display names
+type JourneyLeg struct {
+	journey.Leg
+	LineName        string `json:"lineName"`
+	LineColor       string `json:"lineColor"`
+	FromStationName string `json:"fromStationName"`
+	ToStationName   string `json:"toStationName"`
+}
+
+// JourneyItinerary is a journey.Itinerary with display names
+type JourneyItinerary struct {
+	journey.Itinerary
+	Legs []JourneyLeg `json:"legs"`
+	Fare *fares.Quote `json:"fare"` // null when the city has no fare model
+}
+
+// JourneysResponse is the response of GET /api/journeys
+type JourneysResponse struct {
+	Success  bool       `json:"success"`
+	From     StationRef `json:"from"`
+	To       StationRef `json:"to"`
+	Timezone string     `json:"timezone"`
+	DepartAt string     `json:"departAt"`
+	// StaticDurationSeconds is what routeFinder.service.ts would estimate:
+	// shortest path plus 120s per transfer, with no waiting for trains
+	StaticDurationSeconds *int               `json:"staticDurationSeconds"`
+	Itineraries           []JourneyItinerary `json:"itineraries"`
+}
+
+// journeysHandler serves earliest-arrival itineraries over the simulated timetable
+func journeysHandler(store *networkStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+			return
+		}
+
+		snap, err := store.get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+			return
+		}
+
+		query := r.URL.Query()
+		fromID, toID := query.Get("from"), query.Get("to")
+		if fromID == "" || toID == "" {
+			writeError(w, http.StatusBadRequest, "from and to are required")
+			return
+		}
+		from, ok := snap.stations[fromID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", fromID))
+			return
+		}
+		to, ok := snap.stations[toID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", toID))
+			return
+		}
+		if from.CityID != to.CityID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be in the same city")
+			return
+		}
+		if from.ID == to.ID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be different")
+			return
+		}
+		loc := snap.location(from.CityID)
+
+		departAt := time.Now()
+		if value := query.Get("depart_at"); value != "" {
+			if departAt, err = parseLocalTime(value, loc); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		limit := defaultJourneysLimit
+		if value := query.Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxJourneysLimit {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxJourneysLimit))
+				return
+			}
+		}
+
+		accessible, ok := stepFreeFilter(w, r, snap, from, to)
+		if !ok {
+			return
+		}
+		planner := snap.planner
+		if accessible != nil {
+			// The shared planner is read-only; constrain a copy
+			constrained := *planner
+			constrained.Accessible = accessible
+			planner = &constrained
+		}
+
+		stationNames := snap.namer(w, r, from.CityID)
+		response := JourneysResponse{
+			Success:               true,
+			From:                  stationNames.ref(from),
+			To:                    stationNames.ref(to),
+			Timezone:              loc.String(),
+			DepartAt:              departAt.In(loc).Format(time.RFC3339),
+			StaticDurationSeconds: staticDuration(snap, from.ID, to.ID),
+			Itineraries:           []JourneyItinerary{},
+		}
+		for _, it := range planner.Plan(from.ID, to.ID, departAt, loc, limit) {
+			response.Itineraries = append(response.Itineraries, snap.describeItinerary(it, stationNames))
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// describeItinerary adds line and station names to an itinerary
+func (n *networkSnapshot) describeItinerary(it journey.Itinerary, stationNames stationNamer) JourneyItinerary {
+	out := JourneyItinerary{Itinerary: it}
+	for _, leg := range it.Legs {
+		line := n.lines[leg.LineID]
+		out.Legs = append(out.Legs, JourneyLeg{
+			Leg:             leg,
+			LineName:        line.Name,
+			LineColor:       line.Color,
+			FromStationName: stationNames.name(leg.FromStationID),
+			ToStationName:   stationNames.name(leg.ToStationID),
+		})
+	}
+	out.Fare = n.fareBetween(it.Legs[0].FromStationID, it.Legs[len(it.Legs)-1].ToStationID)
+	return out
+}
+
+// staticDuration reproduces the estimate of routeFinder.service.ts
+func staticDuration(snap *networkSnapshot, from, to string) *int {
+	path, ok := snap.graph.ShortestPath(from, to)
+	if !ok {
+		return nil
+	}
+	seconds := path.Seconds
+	line := ""
+	for _, e := range path.Edges {
+		if e.Walk {
+			// Walkways between stations of a complex already carry their time
+			line = ""
+			continue
+		}
+		if line != "" && e.LineID != line {
+			seconds += journey.DefaultTransferSeconds
+		}
+		line = e.LineID
+	}
+	return &seconds
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"metro-tools/internal/live"
+	"metro-tools/internal/topology"
+)
+
+// maxReportBytes bounds the body of a train report
+const maxReportBytes = 64 << 10
+
+// LiveTrainsResponse is the response of GET /api/live/trains
+type LiveTrainsResponse struct {
+	Success   bool         `json:"success"`
+	LineID    string       `json:"lineId,omitempty"`
+	Direction string       `json:"direction,omitempty"`
+	Trains    []live.Train `json:"trains"`
+	Count     int          `json:"count"`
+	Timestamp string       `json:"timestamp"`
+}
+
+// LiveTrainDetail is a live train with the estimated arrival at its next station
+type LiveTrainDetail struct {
+	live.Train
+	NextStation live.NextStation `json:"nextStation"`
+}
+
+// reportRequest is the body of POST /api/live/trains/report
+type reportRequest struct {
+	TrainID   string   `json:"trainId"`
+	LineID    string   `json:"lineId"`
+	CityID    string   `json:"cityId"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Direction string   `json:"direction"`
+	Source    string   `json:"source"`
+	UserID    string   `json:"userId"`
+	Speed     *float64 `json:"speed"`
+	Accuracy  *float64 `json:"accuracy"`
+}
+
+// attachRequest is the body of POST /api/live/trains/attach and /detach
+type attachRequest struct {
+	UserID  string `json:"userId"`
+	TrainID string `json:"trainId"`
+	LineID  string `json:"lineId"`
+	CityID  string `json:"cityId"`
+}
+
+// liveTimestamp formats the time like JavaScript's toISOString
+func liveTimestamp() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// liveHandler serves /api/live/trains and the routes under it, in the shape
+// of the Node liveTracking controller
+func liveHandler(store *networkStore, tracker *live.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/live/trains"), "/")
+		parts := strings.Split(path, "/")
+
+		switch {
+		case path == "":
+			if !allowMethod(w, r, http.MethodGet) {
+				return
+			}
+			query := r.URL.Query()
+			trains := tracker.Trains(query.Get("lineId"), query.Get("cityId"))
+			writeJSON(w, http.StatusOK, LiveTrainsResponse{Success: true, Trains: trains, Count: len(trains), Timestamp: liveTimestamp()})
+		case path == "report":
+			if allowMethod(w, r, http.MethodPost) {
+				handleTrainReport(store, tracker, w, r)
+			}
+		case path == "attach" || path == "detach":
+			if allowMethod(w, r, http.MethodPost) {
+				handleAttachment(tracker, path == "attach", w, r)
+			}
+		case len(parts) == 1:
+			if allowMethod(w, r, http.MethodGet) {
+				handleTrainDetails(store, tracker, parts[0], w)
+			}
+		case len(parts) == 3 && parts[1] == "direction":
+			if !allowMethod(w, r, http.MethodGet) {
+				return
+			}
+			lineID, direction := parts[0], parts[2]
+			if direction != topology.Forward && direction != topology.Backward {
+				writeError(w, http.StatusBadRequest, "Invalid direction")
+				return
+			}
+			var trains []live.Train
+			for _, t := range tracker.Trains(lineID, r.URL.Query().Get("cityId")) {
+				if t.Direction == direction {
+					trains = append(trains, t)
+				}
+			}
+			if trains == nil {
+				trains = []live.Train{}
+			}
+			writeJSON(w, http.StatusOK, LiveTrainsResponse{
+				Success: true, LineID: lineID, Direction: direction,
+				Trains: trains, Count: len(trains), Timestamp: liveTimestamp(),
+			})
+		default:
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown endpoint %s", r.URL.Path))
+		}
+	}
+}
+
+// allowMethod rejects requests with any other method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("Only %s is supported", method))
+		return false
+	}
+	return true
+}
+
+// handleTrainReport validates a crowd-sourced position and adds it to the tracker
+func handleTrainReport(store *networkStore, tracker *live.Tracker, w http.ResponseWriter, r *http.Request) {
+	var req reportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if req.TrainID == "" || req.LineID == "" || req.CityID == "" || req.Latitude == nil || req.Longitude == nil {
+		writeError(w, http.StatusBadRequest, "Missing required fields")
+		return
+	}
+	if req.Direction != topology.Forward && req.Direction != topology.Backward {
+		writeError(w, http.StatusBadRequest, "Invalid direction")
+		return
+	}
+	if !live.ValidSource(req.Source) {
+		writeError(w, http.StatusBadRequest, "Invalid source")
+		return
+	}
+	if req.Speed != nil && (*req.Speed < live.MinSpeed || *req.Speed > live.MaxSpeed) {
+		writeError(w, http.StatusBadRequest, "Invalid speed")
+		return
+	}
+	if req.Accuracy != nil && (*req.Accuracy < 0 || *req.Accuracy > live.MaxAccuracyMeters) {
+		writeError(w, http.StatusBadRequest, "Invalid accuracy")
+		return
+	}
+	lat, lng := *req.Latitude, *req.Longitude
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		writeError(w, http.StatusBadRequest, "Invalid coordinates")
+		return
+	}
+
+	snap, err := store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+		return
+	}
+	line, ok := snap.lines[req.LineID]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("Line '%s' not found", req.LineID))
+		return
+	}
+	if line.CityID != req.CityID {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Line '%s' is not in city '%s'", req.LineID, req.CityID))
+		return
+	}
+
+	_, err = tracker.ProcessReport(live.Report{
+		TrainID:   req.TrainID,
+		LineID:    req.LineID,
+		CityID:    req.CityID,
+		Latitude:  lat,
+		Longitude: lng,
+		Direction: req.Direction,
+		Source:    req.Source,
+		UserID:    req.UserID,
+		Speed:     req.Speed,
+		Accuracy:  req.Accuracy,
+	}, snap.tracks[req.LineID])
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Report rejected: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Train report accepted",
+		"trainId": req.TrainID,
+	})
+}
+
+// handleTrainDetails serves one train with its next station ETA
+func handleTrainDetails(store *networkStore, tracker *live.Tracker, trainID string, w http.ResponseWriter) {
+	train, ok := tracker.Train(trainID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Train not found")
+		return
+	}
+
+	snap, err := store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success":   true,
+		"train":     LiveTrainDetail{Train: train, NextStation: tracker.NextStation(train, snap.tracks[train.LineID])},
+		"timestamp": liveTimestamp(),
+	})
+}
+
+// handleAttachment records a user starting or stopping location sharing
+func handleAttachment(tracker *live.Tracker, attach bool, w http.ResponseWriter, r *http.Request) {
+	var req attachRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+
+	if attach {
+		if req.UserID == "" || req.TrainID == "" || req.LineID == "" || req.CityID == "" {
+			writeError(w, http.StatusBadRequest, "Missing required fields")
+			return
+		}
+		tracker.Attach(req.UserID, req.TrainID, req.LineID)
+	} else {
+		if req.UserID == "" || req.TrainID == "" {
+			writeError(w, http.StatusBadRequest, "Missing required fields")
+			return
+		}
+		tracker.Detach(req.UserID, req.TrainID)
+	}
+
+	message := "Detached from train"
+	if attach {
+		message = "Attached to train"
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": message,
+		"trainId": req.TrainID,
+		"userId":  req.UserID,
+	})
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"unicode"
+	"unicode/utf8"
 
 	"metro-tools/internal/database"
+	"metro-tools/internal/rules"
 	"metro-tools/internal/validators"
 
 	"github.com/fatih/color"
@@ -17,9 +20,11 @@ const version = "1.0.0"
 
 var (
 	dbPath     string
+	rulesPath  string
 	verbose    bool
 	jsonOut    bool
 	serverPort string
+	statePath  string
 )
 
 // Output helpers
@@ -42,6 +47,11 @@ type JSONOutput struct {
 	Status    string                        `json:"status"`
 }
 
+// addRulesFlag adds --rules to the commands that run the validators
+func addRulesFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&rulesPath, "rules", "", "Path to a JSON file of custom validation rules")
+}
+
 func main() {
 	// Find default database path relative to executable
 	defaultDB := findDefaultDB()
@@ -60,6 +70,7 @@ func main() {
 	// Validate command flags
 	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed output")
 	rootCmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	addRulesFlag(rootCmd)
 
 	// Serve command - starts HTTP server for frontend integration
 	serveCmd := &cobra.Command{
@@ -73,15 +84,42 @@ func main() {
 				port = serverPort
 			}
 			config := ServerConfig{
-				Port:   port,
-				DBPath: dbPath,
+				Port:      port,
+				DBPath:    dbPath,
+				RulesPath: rulesPath,
+				FaresPath: faresPath,
+				StatePath: statePath,
+			}
+			if config.StatePath == "" {
+				config.StatePath = os.Getenv("LIVE_STATE_PATH")
 			}
 			runServer(config)
 		},
 	}
 	serveCmd.Flags().StringVarP(&serverPort, "port", "p", "5001", "Server port")
+	serveCmd.Flags().StringVar(&faresPath, "fares", "", "Path to a JSON file of fare models by city (default: built-in)")
+	serveCmd.Flags().StringVar(&statePath, "state", "", "SQLite file to keep live train state in across restarts (default: $LIVE_STATE_PATH, else memory only)")
+	addRulesFlag(serveCmd)
 	rootCmd.AddCommand(serveCmd)
 
+	// Watch command - re-validates on every database or seed change
+	rootCmd.AddCommand(newWatchCmd())
+
+	// Schema check command - compares the database with schema.ts
+	rootCmd.AddCommand(newSchemaCheckCmd())
+
+	// Analyze command - reports on crowdsourced data quality
+	rootCmd.AddCommand(newAnalyzeCmd())
+
+	// Positions command - replays sightings through the train position estimator
+	rootCmd.AddCommand(newPositionsCmd())
+
+	// Simulate command - generates the synthetic timetable from schedules
+	rootCmd.AddCommand(newSimulateCmd())
+
+	// Fares command - quotes fares and exports fare matrices
+	rootCmd.AddCommand(newFaresCmd())
+
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
 	}
@@ -111,6 +149,21 @@ func runValidator(cmd *cobra.Command, args []string) {
 		printHeader()
 	}
 
+	// Load custom rules before touching the database so typos fail fast
+	var ruleSet *rules.RuleSet
+	if rulesPath != "" {
+		var err error
+		ruleSet, err = rules.Load(rulesPath)
+		if err != nil {
+			if jsonOut {
+				outputError(err)
+			} else {
+				fmt.Printf("%s Failed to load rules: %v\n", red("ERROR:"), err)
+			}
+			os.Exit(1)
+		}
+	}
+
 	// Open database
 	db, err := database.Open(dbPath)
 	if err != nil {
@@ -139,74 +192,15 @@ func runValidator(cmd *cobra.Command, args []string) {
 	}
 
 	// Load all data
-	cities, err := db.GetAllCities()
-	if err != nil {
-		handleLoadError("cities", err)
-	}
-
-	lines, err := db.GetAllLines()
+	data, err := loadNetworkData(db)
 	if err != nil {
-		handleLoadError("lines", err)
-	}
-
-	stations, err := db.GetAllStations()
-	if err != nil {
-		handleLoadError("stations", err)
-	}
-
-	lineStations, err := db.GetAllLineStations()
-	if err != nil {
-		handleLoadError("line_stations", err)
-	}
-
-	connections, err := db.GetAllConnections()
-	if err != nil {
-		handleLoadError("connections", err)
-	}
-
-	stationCounts, err := db.GetStationCountByLine()
-	if err != nil {
-		handleLoadError("station counts", err)
-	}
-
-	linesPerStation, err := db.GetLinesPerStation()
-	if err != nil {
-		handleLoadError("lines per station", err)
+		handleLoadError(err)
 	}
 
 	// Run validations
-	results := make(map[string]*validators.Result)
-
-	results["city"] = validators.ValidateCities(cities)
-	results["line"] = validators.ValidateLines(lines, cities, stationCounts)
-	results["station"] = validators.ValidateStations(stations, cities)
-	results["connection"] = validators.ValidateConnections(connections, stations, lines, lineStations)
-	results["interchange"] = validators.ValidateInterchanges(stations, linesPerStation)
-
-	// Collect all issues
-	var allIssues []validators.Issue
-	for _, r := range results {
-		allIssues = append(allIssues, r.Issues...)
-	}
-
-	// Determine overall status
-	hasErrors := false
-	hasWarnings := false
-	for _, r := range results {
-		if r.Failed > 0 {
-			hasErrors = true
-		}
-		if r.Warnings > 0 {
-			hasWarnings = true
-		}
-	}
-
-	status := "pass"
-	if hasErrors {
-		status = "fail"
-	} else if hasWarnings {
-		status = "pass_with_warnings"
-	}
+	results := runValidations(data, ruleSet)
+	allIssues := collectIssues(results)
+	status := overallStatus(results)
 
 	// Output results
 	if jsonOut {
@@ -217,16 +211,16 @@ func runValidator(cmd *cobra.Command, args []string) {
 	}
 
 	// Exit with appropriate code
-	if hasErrors {
+	if status == "fail" {
 		os.Exit(1)
 	}
 }
 
-func handleLoadError(what string, err error) {
+func handleLoadError(err error) {
 	if jsonOut {
-		outputError(fmt.Errorf("failed to load %s: %w", what, err))
+		outputError(err)
 	} else {
-		fmt.Printf("%s Failed to load %s: %v\n", red("ERROR:"), what, err)
+		fmt.Printf("%s %v\n", red("ERROR:"), capitalize(err.Error()))
 	}
 	os.Exit(1)
 }
@@ -253,9 +247,7 @@ func printStats(stats *database.Stats) {
 }
 
 func printResults(results map[string]*validators.Result) {
-	order := []string{"city", "line", "station", "connection", "interchange"}
-
-	for _, category := range order {
+	for _, category := range categoryOrder() {
 		r := results[category]
 		if r == nil {
 			continue
@@ -340,9 +332,11 @@ func outputError(err error) {
 	encoder.Encode(output)
 }
 
+// capitalize upper-cases the first letter of s if it is lower case
 func capitalize(s string) string {
-	if len(s) == 0 {
+	r, size := utf8.DecodeRuneInString(s)
+	if !unicode.IsLower(r) {
 		return s
 	}
-	return string(s[0]-32) + s[1:]
+	return string(unicode.ToUpper(r)) + s[size:]
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"metro-tools/internal/mapmatch"
+)
+
+// maxMatchPoints bounds the length of a trace
+const maxMatchPoints = 500
+
+// matchRequest is the body of POST /api/live/match
+type matchRequest struct {
+	CityID string `json:"cityId"`
+	Points []struct {
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Timestamp int64   `json:"timestamp"` // Unix milliseconds, 0 if unknown
+		Accuracy  float64 `json:"accuracy"`  // metres, clamped to mapmatch.MaxAccuracy
+	} `json:"points"`
+}
+
+// matchHandler infers the line, direction and segment a rider is on from
+// their recent GPS fixes, oldest first. Known timestamps must increase.
+func matchHandler(store *networkStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		var req matchRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReportBytes)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
+		if len(req.Points) == 0 || len(req.Points) > maxMatchPoints {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("points must have between 1 and %d entries", maxMatchPoints))
+			return
+		}
+
+		snap, err := store.get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+			return
+		}
+		if _, ok := snap.cities[req.CityID]; req.CityID != "" && !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("City '%s' not found", req.CityID))
+			return
+		}
+
+		points := make([]mapmatch.Point, 0, len(req.Points))
+		timed := -1 // the last point with a timestamp
+		for i, p := range req.Points {
+			if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid coordinates in point %d", i))
+				return
+			}
+			if p.Accuracy < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid accuracy in point %d", i))
+				return
+			}
+			point := mapmatch.Point{Lat: p.Latitude, Lng: p.Longitude, Accuracy: p.Accuracy}
+			if p.Timestamp > 0 {
+				if timed >= 0 && p.Timestamp <= req.Points[timed].Timestamp {
+					writeError(w, http.StatusBadRequest, fmt.Sprintf("Timestamp of point %d is not after point %d", i, timed))
+					return
+				}
+				point.Time = time.Unix(0, p.Timestamp*int64(time.Millisecond))
+				timed = i
+			}
+			points = append(points, point)
+		}
+
+		var keep func(string) bool
+		if req.CityID != "" {
+			keep = func(lineID string) bool { return snap.lines[lineID].CityID == req.CityID }
+		}
+		match, err := snap.matcher.Match(points, keep)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"match":   match,
+		})
+	}
+}
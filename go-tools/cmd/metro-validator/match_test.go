@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"metro-tools/internal/fares"
+	"metro-tools/internal/mapmatch"
+)
+
+func TestMatchHandler(t *testing.T) {
+	store := newNetworkStore(newTestDB(t), fares.Default)
+	handler := matchHandler(store)
+
+	// Three fixes heading north along the yellow line, at the given Unix milliseconds
+	body := func(times ...int64) string {
+		return fmt.Sprintf(`{"cityId":"delhi","points":[
+			{"latitude":28.601,"longitude":77.2,"timestamp":%d},
+			{"latitude":28.605,"longitude":77.2,"timestamp":%d},
+			{"latitude":28.609,"longitude":77.2,"timestamp":%d}]}`, times[0], times[1], times[2])
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		error  string
+	}{
+		{"increasing", body(1000, 31000, 61000), http.StatusOK, ""},
+		{"unknown times are skipped", body(1000, 0, 61000), http.StatusOK, ""},
+		{"repeated time", body(1000, 1000, 61000), http.StatusBadRequest, "Timestamp of point 1 is not after point 0"},
+		{"back in time", body(1000, 31000, 21000), http.StatusBadRequest, "Timestamp of point 2 is not after point 1"},
+		{"back in time across an unknown time", body(31000, 0, 1000), http.StatusBadRequest, "Timestamp of point 2 is not after point 0"},
+		{"unknown city", `{"cityId":"mumbai","points":[{"latitude":28.601,"longitude":77.2}]}`, http.StatusNotFound, "City 'mumbai' not found"},
+		{"no points", `{"points":[]}`, http.StatusBadRequest, "points must have between 1 and 500 entries"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response struct {
+				Error string          `json:"error"`
+				Match *mapmatch.Match `json:"match"`
+			}
+			rec := serve(t, handler, http.MethodPost, "/api/live/match", tt.body, &response)
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if response.Error != tt.error {
+				t.Errorf("error = %q, want %q", response.Error, tt.error)
+			}
+			if tt.status == http.StatusOK && (response.Match == nil || response.Match.LineID != "delhi-yellow") {
+				t.Errorf("match = %+v, want delhi-yellow", response.Match)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/names"
+)
+
+// stationNamer shows station names in the language negotiated for a response
+type stationNamer struct {
+	snap *networkSnapshot
+	lang string
+}
+
+// namer picks the response language for a city from Accept-Language, among
+// those it has names in, and labels the response with it
+func (n *networkSnapshot) namer(w http.ResponseWriter, r *http.Request, cityID string) stationNamer {
+	lang := names.Negotiate(r.Header.Get("Accept-Language"), n.names.Available(cityID))
+	w.Header().Add("Vary", "Accept-Language")
+	w.Header().Set("Content-Language", lang)
+	return stationNamer{snap: n, lang: lang}
+}
+
+// name returns a station's name, falling back to English where it has none
+// in the negotiated language
+func (s stationNamer) name(stationID string) string {
+	if s.lang != names.Default {
+		if n, ok := s.snap.names.Lookup(stationID, s.lang); ok {
+			return n.Name
+		}
+	}
+	return s.snap.stations[stationID].Name
+}
+
+// ref returns a station reference with its name in the negotiated language
+func (s stationNamer) ref(st database.MetroStation) StationRef {
+	return StationRef{st.ID, s.name(st.ID)}
+}
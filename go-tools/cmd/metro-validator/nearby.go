@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"metro-tools/internal/spatial"
+	"metro-tools/internal/validators"
+)
+
+const (
+	defaultNearbyRadius = 2000 // metres, as in stations.controller.ts
+	maxNearbyRadius     = 20000
+	defaultNearbyLimit  = 10
+	maxNearbyLimit      = 50
+)
+
+// NearbyStation is a station near the requested location
+type NearbyStation struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	CityID    string  `json:"cityId"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	// DistanceMeters is the straight-line distance to the station's coordinates
+	DistanceMeters int     `json:"distanceMeters"`
+	Bearing        float64 `json:"bearing"` // degrees clockwise from north
+	Compass        string  `json:"compass"`
+	// WalkSeconds estimates walking to the nearest exit, or to the station
+	// when it has no exits recorded
+	WalkSeconds int    `json:"walkSeconds"`
+	Exit        string `json:"exit,omitempty"`
+}
+
+// NearbyResponse is the response of GET /api/stations/nearby
+type NearbyResponse struct {
+	Success  bool            `json:"success"`
+	Radius   int             `json:"radius"`
+	Stations []NearbyStation `json:"stations"`
+}
+
+// handleNearby finds the stations closest to a location
+func handleNearby(store *networkStore, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+		return
+	}
+
+	snap, err := store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("lat") == "" || query.Get("lng") == "" {
+		writeError(w, http.StatusBadRequest, "lat and lng are required")
+		return
+	}
+	at, err := parsePoint(query.Get("lat"), query.Get("lng"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	cityID := query.Get("city")
+	if _, ok := snap.cities[cityID]; cityID != "" && !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("City '%s' not found", cityID))
+		return
+	}
+
+	radius := defaultNearbyRadius
+	if value := query.Get("radius"); value != "" {
+		radius, err = strconv.Atoi(value)
+		if err != nil || radius < 1 || radius > maxNearbyRadius {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("radius must be between 1 and %d metres", maxNearbyRadius))
+			return
+		}
+	}
+	limit := defaultNearbyLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxNearbyLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxNearbyLimit))
+			return
+		}
+	}
+
+	var keep func(spatial.Point) bool
+	if cityID != "" {
+		keep = func(p spatial.Point) bool { return snap.stations[p.ID].CityID == cityID }
+	}
+	found := snap.nearby.Within(at.Lat, at.Lng, float64(radius), limit, keep)
+
+	// Without a city, names are shown in the languages of the nearest station's city
+	if cityID == "" && len(found) > 0 {
+		cityID = snap.stations[found[0].ID].CityID
+	}
+	stationNames := snap.namer(w, r, cityID)
+
+	response := NearbyResponse{Success: true, Radius: radius, Stations: []NearbyStation{}}
+	for _, n := range found {
+		st := snap.stations[n.ID]
+		walkMeters, exit := n.Meters, ""
+		for _, e := range snap.facilities[st.ID].Exits {
+			if meters := validators.HaversineDistance(at.Lat, at.Lng, e.Latitude, e.Longitude) * 1000; exit == "" || meters < walkMeters {
+				walkMeters, exit = meters, e.Name
+			}
+		}
+		response.Stations = append(response.Stations, NearbyStation{
+			ID:             st.ID,
+			Name:           stationNames.name(st.ID),
+			CityID:         st.CityID,
+			Latitude:       st.Latitude,
+			Longitude:      st.Longitude,
+			DistanceMeters: int(math.Round(n.Meters)),
+			Bearing:        math.Round(n.Bearing*10) / 10,
+			Compass:        spatial.Compass(n.Bearing),
+			WalkSeconds:    spatial.WalkSeconds(walkMeters),
+			Exit:           exit,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
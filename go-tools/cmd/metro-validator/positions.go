@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/tracking"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	positionsLine     string
+	positionsAt       string
+	positionsMaxAge   time.Duration
+	positionsMode     string
+	positionsBacktest bool
+)
+
+// newPositionsCmd creates the positions command
+func newPositionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "positions",
+		Short: "Estimate train positions on a line by replaying sightings",
+		Long: "Replays train_sightings through the same model as trainPositionEstimator.service.ts and prints the\n" +
+			"estimated position of the latest train in each direction at the given time. With --backtest, every\n" +
+			"sighting on the line is predicted from the ones before it and the hit rate is reported per\n" +
+			"confidence level and sighting age for each next-station strategy.",
+		Example: "  metro-validator positions --line delhi-yellow --at 2025-01-15T08:30:00\n" +
+			"  metro-validator positions --line delhi-yellow --backtest",
+		Run: runPositions,
+	}
+	cmd.Flags().StringVar(&positionsLine, "line", "", "Line ID to estimate (required)")
+	cmd.Flags().StringVar(&positionsAt, "at", "", "Time to estimate at, RFC 3339 or local time in the line's city (default: newest sighting)")
+	cmd.Flags().DurationVar(&positionsMaxAge, "max-age", tracking.DefaultMaxAge, "Ignore sightings older than this")
+	cmd.Flags().StringVar(&positionsMode, "mode", string(tracking.NextStationFirst), "Next-station lookup: first (as the backend) or directional")
+	cmd.Flags().BoolVar(&positionsBacktest, "backtest", false, "Score the estimator against every sighting on the line")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	cmd.MarkFlagRequired("line")
+	return cmd
+}
+
+func runPositions(cmd *cobra.Command, args []string) {
+	mode := tracking.NextStationMode(positionsMode)
+	if mode != tracking.NextStationFirst && mode != tracking.NextStationDirectional {
+		exitWithError("Invalid --mode", fmt.Errorf("expected first or directional, got %q", positionsMode))
+	}
+
+	if !jsonOut {
+		printHeader()
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+	defer db.Close()
+
+	cities, err := db.GetAllCities()
+	if err != nil {
+		exitWithError("Failed to load cities", err)
+	}
+	lines, err := db.GetAllLines()
+	if err != nil {
+		exitWithError("Failed to load lines", err)
+	}
+	stations, err := db.GetAllStations()
+	if err != nil {
+		exitWithError("Failed to load stations", err)
+	}
+	lineStations, err := db.GetAllLineStations()
+	if err != nil {
+		exitWithError("Failed to load line stations", err)
+	}
+	connections, err := db.GetAllConnections()
+	if err != nil {
+		exitWithError("Failed to load connections", err)
+	}
+	all, err := db.GetAllTrainSightings()
+	if err != nil {
+		exitWithError("Failed to load sightings", err)
+	}
+
+	loc, ok := lineLocation(positionsLine, cities, lines)
+	if !ok {
+		exitWithError("Unknown line", fmt.Errorf("no line with ID %q", positionsLine))
+	}
+
+	var onLine []database.TrainSighting
+	for _, s := range all {
+		if s.LineID == positionsLine {
+			onLine = append(onLine, s)
+		}
+	}
+
+	estimator := tracking.NewEstimator(stations, lineStations, connections, onLine)
+	estimator.MaxAge = positionsMaxAge
+	estimator.Mode = mode
+
+	if positionsBacktest {
+		var results []*tracking.Backtest
+		for _, m := range []tracking.NextStationMode{tracking.NextStationFirst, tracking.NextStationDirectional} {
+			estimator.Mode = m
+			results = append(results, estimator.RunBacktest(positionsLine, time.Time{}, time.Time{}))
+		}
+		if jsonOut {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(results)
+			return
+		}
+		printBacktest(results)
+		return
+	}
+
+	var at time.Time
+	if positionsAt == "" {
+		if len(onLine) == 0 {
+			exitWithError("No sightings to replay", fmt.Errorf("line %s has no train sightings; pass --at", positionsLine))
+		}
+		at = onLine[len(onLine)-1].Timestamp
+	} else {
+		at, err = parseLocalTime(positionsAt, loc)
+		if err != nil {
+			exitWithError("Invalid --at", err)
+		}
+	}
+
+	positions := estimator.EstimateAll(positionsLine, at)
+
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(map[string]interface{}{
+			"lineId":    positionsLine,
+			"at":        at.In(loc).Format(time.RFC3339),
+			"positions": positions,
+		})
+		return
+	}
+	printPositions(at.In(loc), positions)
+}
+
+// lineLocation returns the timezone of the city a line belongs to
+func lineLocation(lineID string, cities []database.City, lines []database.MetroLine) (*time.Location, bool) {
+	for _, l := range lines {
+		if l.ID != lineID {
+			continue
+		}
+		for _, c := range cities {
+			if c.ID == l.CityID {
+				if loc, err := time.LoadLocation(c.Timezone); err == nil {
+					return loc, true
+				}
+			}
+		}
+		return time.UTC, true
+	}
+	return nil, false
+}
+
+// parseLocalTime accepts RFC 3339, or a date and time without offset in loc
+func parseLocalTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a timestamp", value)
+}
+
+func printPositions(at time.Time, positions []tracking.Position) {
+	fmt.Printf("  %s %s\n", cyan("Database:"), dbPath)
+	fmt.Printf("  %s %s at %s (mode %s, max age %s)\n", cyan("Line:"), positionsLine, at.Format("2006-01-02 15:04:05 MST"), positionsMode, positionsMaxAge)
+	fmt.Println()
+
+	if len(positions) == 0 {
+		fmt.Printf("  %s\n\n", dimmed("No sightings within max age; no position can be estimated"))
+		return
+	}
+
+	for _, p := range positions {
+		levelColor := green
+		switch p.Confidence {
+		case tracking.ConfidenceMedium:
+			levelColor = yellow
+		case tracking.ConfidenceLow:
+			levelColor = red
+		}
+		fmt.Printf("  %s %s\n", bold(capitalize(p.Direction)), levelColor(fmt.Sprintf("[%s %.2f]", p.Confidence, p.ConfidenceScore)))
+
+		switch p.Status {
+		case tracking.StatusInTransit:
+			fmt.Printf("    In transit %s -> %s, %.0f%% of the way, arriving in %ds\n",
+				p.CurrentStationName, *p.NextStationName, p.ProgressPercent, *p.EstimatedArrivalNextStation)
+		case tracking.StatusAtStation:
+			fmt.Printf("    At %s", p.CurrentStationName)
+			if p.NextStationName != nil {
+				fmt.Printf(", next %s in %ds", *p.NextStationName, *p.EstimatedArrivalNextStation)
+			}
+			fmt.Println()
+		default:
+			fmt.Printf("    Unknown, last seen at %s\n", p.CurrentStationName)
+		}
+		fmt.Printf("    %s\n", dimmed(fmt.Sprintf("from sighting #%d, %ds old", p.SightingID, p.LastSightingAge)))
+	}
+	fmt.Println()
+}
+
+func printBacktest(results []*tracking.Backtest) {
+	fmt.Printf("  %s %s\n", cyan("Database:"), dbPath)
+	fmt.Printf("  %s %s (max age %s)\n", cyan("Line:"), positionsLine, positionsMaxAge)
+	fmt.Println()
+
+	for _, bt := range results {
+		fmt.Printf("  %s\n", bold(fmt.Sprintf("Next station: %s", bt.Mode)))
+		if bt.Predictions == 0 {
+			fmt.Printf("    %s\n\n", dimmed(fmt.Sprintf("%d sightings, none with an earlier sighting within max age", bt.Sightings)))
+			continue
+		}
+		fmt.Printf("    %d sightings, %d predicted (%d without a recent sighting)\n", bt.Sightings, bt.Predictions, bt.NoEstimate)
+		fmt.Printf("    Hit rate %.1f%% | Brier score %.3f\n", bt.HitRate*100, bt.Brier)
+
+		fmt.Println("    By confidence:")
+		for _, l := range bt.Levels {
+			if l.Predictions == 0 {
+				continue
+			}
+			flag := ""
+			if l.HitRate+0.1 < l.MeanScore {
+				flag = yellow(" overconfident")
+			}
+			fmt.Printf("      %-7s n=%-5d hit %5.1f%%  claimed %.2f%s\n", l.Level, l.Predictions, l.HitRate*100, l.MeanScore, flag)
+		}
+		fmt.Println("    By sighting age:")
+		for _, a := range bt.Ages {
+			if a.Predictions == 0 {
+				continue
+			}
+			fmt.Printf("      <%4ds  n=%-5d hit %5.1f%%\n", a.MaxAgeSeconds, a.Predictions, a.HitRate*100)
+		}
+		fmt.Println()
+	}
+}
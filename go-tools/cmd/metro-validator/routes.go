@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metro-tools/internal/fares"
+	"metro-tools/internal/graph"
+)
+
+const (
+	defaultRoutesLimit = 3
+	maxRoutesLimit     = 5
+)
+
+// RouteLeg is a ride along one line, or a walk between stations of a complex
+type RouteLeg struct {
+	Walk            bool   `json:"walk,omitempty"`
+	LineID          string `json:"lineId"`
+	LineName        string `json:"lineName"`
+	LineColor       string `json:"lineColor"`
+	FromStationID   string `json:"fromStationId"`
+	FromStationName string `json:"fromStationName"`
+	ToStationID     string `json:"toStationId"`
+	ToStationName   string `json:"toStationName"`
+	Stops           int    `json:"stops"`
+	Seconds         int    `json:"seconds"`
+}
+
+// RouteTransfer is a change of line with display names
+type RouteTransfer struct {
+	graph.Transfer
+	StationName   string `json:"stationName"`
+	ToStationName string `json:"toStationName,omitempty"`
+}
+
+// RouteOption is one Pareto-optimal route with its cost breakdown
+type RouteOption struct {
+	Best []string `json:"best"`
+	graph.Criteria
+	RideSeconds int             `json:"rideSeconds"`
+	Legs        []RouteLeg      `json:"legs"`
+	Transfers   []RouteTransfer `json:"transfers"`
+	Stations    []string        `json:"stations"`
+}
+
+// RoutesResponse is the response of GET /api/routes
+type RoutesResponse struct {
+	Success bool          `json:"success"`
+	From    StationRef    `json:"from"`
+	To      StationRef    `json:"to"`
+	Routes  []RouteOption `json:"routes"`
+	// Fare is charged on the shortest ride between the stations, whichever
+	// route is taken, so it is the same for every alternative
+	Fare *fares.Quote `json:"fare"`
+}
+
+// routesHandler serves the Pareto-optimal alternatives between two stations
+func routesHandler(store *networkStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+			return
+		}
+
+		snap, err := store.get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+			return
+		}
+
+		query := r.URL.Query()
+		fromID, toID := query.Get("from"), query.Get("to")
+		if fromID == "" || toID == "" {
+			writeError(w, http.StatusBadRequest, "from and to are required")
+			return
+		}
+		from, ok := snap.stations[fromID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", fromID))
+			return
+		}
+		to, ok := snap.stations[toID]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Station '%s' not found", toID))
+			return
+		}
+		if from.CityID != to.CityID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be in the same city")
+			return
+		}
+		if from.ID == to.ID {
+			writeError(w, http.StatusBadRequest, "Origin and destination must be different")
+			return
+		}
+
+		limit := defaultRoutesLimit
+		if value := query.Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxRoutesLimit {
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxRoutesLimit))
+				return
+			}
+		}
+
+		opts := graph.DefaultParetoOptions
+		if opts.Accessible, ok = stepFreeFilter(w, r, snap, from, to); !ok {
+			return
+		}
+
+		routes := snap.graph.ParetoRoutes(from.ID, to.ID, snap.transfers.Walk, opts)
+		stationNames := snap.namer(w, r, from.CityID)
+		response := RoutesResponse{
+			Success: true,
+			From:    stationNames.ref(from),
+			To:      stationNames.ref(to),
+			Routes:  []RouteOption{},
+		}
+		if len(routes) > 0 {
+			response.Fare = snap.fareBetween(from.ID, to.ID)
+		}
+		for _, route := range selectRoutes(routes, limit) {
+			response.Routes = append(response.Routes, snap.describeRoute(route, stationNames))
+		}
+
+		writeJSON(w, http.StatusOK, response)
+	}
+}
+
+// selectRoutes keeps the routes that are best at something, then fills up
+// to limit with the remaining Pareto-optimal routes, fastest first
+func selectRoutes(routes []graph.Route, limit int) []graph.Route {
+	var selected, rest []graph.Route
+	for _, r := range routes {
+		if len(r.Best) > 0 {
+			selected = append(selected, r)
+		} else {
+			rest = append(rest, r)
+		}
+	}
+	selected = append(selected, rest...)
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	return selected
+}
+
+// describeRoute groups a route's edges into legs and adds display names
+func (n *networkSnapshot) describeRoute(r graph.Route, stationNames stationNamer) RouteOption {
+	out := RouteOption{
+		Best:        r.Best,
+		Criteria:    r.Criteria,
+		RideSeconds: r.Seconds - r.WalkSeconds,
+		Legs:        []RouteLeg{},
+		Transfers:   []RouteTransfer{},
+		Stations:    r.Stations,
+	}
+	if out.Best == nil {
+		out.Best = []string{}
+	}
+
+	for _, e := range r.Edges {
+		if len(out.Legs) == 0 || out.Legs[len(out.Legs)-1].LineID != e.LineID || e.Walk {
+			line := n.lines[e.LineID]
+			out.Legs = append(out.Legs, RouteLeg{
+				Walk:            e.Walk,
+				LineID:          e.LineID,
+				LineName:        line.Name,
+				LineColor:       line.Color,
+				FromStationID:   e.From,
+				FromStationName: stationNames.name(e.From),
+			})
+		}
+		leg := &out.Legs[len(out.Legs)-1]
+		leg.ToStationID = e.To
+		leg.ToStationName = stationNames.name(e.To)
+		if !e.Walk {
+			leg.Stops++
+		}
+		leg.Seconds += e.Weight
+	}
+	for _, t := range r.Transfers {
+		out.Transfers = append(out.Transfers, RouteTransfer{
+			Transfer:      t,
+			StationName:   stationNames.name(t.Station),
+			ToStationName: stationNames.name(t.ToStation),
+		})
+	}
+	return out
+}
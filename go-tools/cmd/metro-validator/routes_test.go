@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"metro-tools/internal/fares"
+)
+
+func TestRoutesFare(t *testing.T) {
+	store := newNetworkStore(newTestDB(t, testComplex), fares.Default)
+
+	var fare FareResponse
+	serve(t, fareHandler(store), http.MethodGet, "/api/fare?from=a&to=d", "", &fare)
+
+	var response RoutesResponse
+	rec := serve(t, routesHandler(store), http.MethodGet, "/api/routes?from=a&to=d", "", &response)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(response.Routes) < 2 {
+		t.Fatalf("got %d routes, want the yellow line and walkway and the blue line", len(response.Routes))
+	}
+	// One fare for the journey, as /api/fare charges it, not one per route
+	if response.Fare == nil || *response.Fare != *fare.Fare {
+		t.Errorf("fare = %+v, want %+v", response.Fare, fare.Fare)
+	}
+	var raw struct {
+		Routes []map[string]json.RawMessage `json:"routes"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range raw.Routes {
+		if _, ok := r["fare"]; ok {
+			t.Errorf("route %d has its own fare", i)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/schema"
+	"metro-tools/internal/validators"
+
+	"github.com/spf13/cobra"
+)
+
+// newSchemaCheckCmd creates the schema-check command
+func newSchemaCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema-check",
+		Short: "Detect drift between the database schema and schema.ts",
+		Long: "Reads sqlite_master and the table_info, foreign_key_list and index_list pragmas, and compares them\n" +
+			"against the schema defined in backend/src/db/schema.ts: missing tables and columns, type mismatches,\n" +
+			"missing foreign keys and missing indexes.",
+		Run: runSchemaCheck,
+	}
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output results as JSON")
+	return cmd
+}
+
+func runSchemaCheck(cmd *cobra.Command, args []string) {
+	if !jsonOut {
+		printHeader()
+		fmt.Printf("  %s %s\n\n", cyan("Database:"), dbPath)
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		if jsonOut {
+			outputError(err)
+		} else {
+			fmt.Printf("%s Failed to open database: %v\n", red("ERROR:"), err)
+		}
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	result, err := schema.Check(db)
+	if err != nil {
+		if jsonOut {
+			outputError(err)
+		} else {
+			fmt.Printf("%s Failed to inspect schema: %v\n", red("ERROR:"), err)
+		}
+		os.Exit(1)
+	}
+
+	results := map[string]*validators.Result{"schema": result}
+	status := overallStatus(results)
+
+	if jsonOut {
+		outputJSON(nil, results, result.Issues, status)
+	} else {
+		// Drift is always worth seeing, so show warnings too
+		verbose = true
+		printResults(results)
+		printSummary(results, status)
+	}
+
+	if status == "fail" {
+		os.Exit(1)
+	}
+}
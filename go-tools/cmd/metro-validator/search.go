@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"metro-tools/internal/search"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// SearchResponse is the response of GET /api/stations/search
+type SearchResponse struct {
+	Success bool           `json:"success"`
+	Query   string         `json:"query"`
+	Results []search.Match `json:"results"`
+}
+
+// handleSearch finds stations by fuzzy name, in any of their languages
+func handleSearch(store *networkStore, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+		return
+	}
+
+	snap, err := store.get()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+		return
+	}
+
+	query := r.URL.Query()
+	q := search.Query{Text: query.Get("q"), CityID: query.Get("city"), Limit: defaultSearchLimit}
+	if q.Text == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if _, ok := snap.cities[q.CityID]; q.CityID != "" && !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("City '%s' not found", q.CityID))
+		return
+	}
+
+	lat, lng := query.Get("lat"), query.Get("lng")
+	if lat != "" || lng != "" {
+		near, err := parsePoint(lat, lng)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		q.Near = near
+	}
+
+	if value := query.Get("limit"); value != "" {
+		q.Limit, err = strconv.Atoi(value)
+		if err != nil || q.Limit < 1 || q.Limit > maxSearchLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit))
+			return
+		}
+	}
+
+	// Names are only localised within one city, as languages differ between cities
+	stationNames := snap.namer(w, r, q.CityID)
+	results := snap.search.Search(q)
+	for i := range results {
+		results[i].Name = stationNames.name(results[i].StationID)
+	}
+
+	writeJSON(w, http.StatusOK, SearchResponse{Success: true, Query: q.Text, Results: results})
+}
+
+// parsePoint reads a lat/lng pair of query parameters
+func parsePoint(lat, lng string) (*search.Point, error) {
+	if lat == "" || lng == "" {
+		return nil, fmt.Errorf("lat and lng must be used together")
+	}
+	la, err := strconv.ParseFloat(lat, 64)
+	if err != nil || la < -90 || la > 90 {
+		return nil, fmt.Errorf("lat must be a number between -90 and 90")
+	}
+	ln, err := strconv.ParseFloat(lng, 64)
+	if err != nil || ln < -180 || ln > 180 {
+		return nil, fmt.Errorf("lng must be a number between -180 and 180")
+	}
+	return &search.Point{Lat: la, Lng: ln}, nil
+}
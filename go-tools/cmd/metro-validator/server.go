@@ -6,32 +6,56 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"metro-tools/internal/database"
+	"metro-tools/internal/live"
+	"metro-tools/internal/rules"
 	"metro-tools/internal/validators"
 )
 
 // ServerConfig holds the server configuration
 type ServerConfig struct {
-	Port   string
-	DBPath string
+	Port      string
+	DBPath    string
+	RulesPath string
+	FaresPath string
+	// StatePath is the SQLite file live train state is kept in; empty keeps it in memory only
+	StatePath string
 }
 
 // ValidationResponse is the API response format
 type ValidationResponse struct {
-	Success   bool                          `json:"success"`
-	Database  string                        `json:"database"`
-	Timestamp string                        `json:"timestamp"`
-	Stats     *database.Stats               `json:"stats,omitempty"`
-	Results   map[string]*validators.Result `json:"results,omitempty"`
-	Issues    []validators.Issue            `json:"issues,omitempty"`
-	Status    string                        `json:"status"`
-	Error     string                        `json:"error,omitempty"`
+	Success    bool                          `json:"success"`
+	Database   string                        `json:"database"`
+	Timestamp  string                        `json:"timestamp"`
+	Stats      *database.Stats               `json:"stats"`
+	Results    map[string]*validators.Result `json:"results"`
+	Issues     []validators.Issue            `json:"issues"`
+	Status     string                        `json:"status"`
+	Error      string                        `json:"error,omitempty"`
+	LoadErrors map[string]string             `json:"loadErrors,omitempty"` // skipped categories and why
 }
 
 // runServer starts the HTTP server
 func runServer(config ServerConfig) {
+	// Custom rules are loaded once; restart the server to pick up edits
+	var ruleSet *rules.RuleSet
+	if config.RulesPath != "" {
+		var err error
+		ruleSet, err = rules.Load(config.RulesPath)
+		if err != nil {
+			log.Fatalf("Failed to load rules: %v", err)
+		}
+	}
+
+	fareConfig, err := loadFareConfig(config.FaresPath)
+	if err != nil {
+		log.Fatalf("Failed to load fares: %v", err)
+	}
+
 	mux := http.NewServeMux()
 
 	// Health check endpoint
@@ -44,10 +68,42 @@ func runServer(config ServerConfig) {
 		})
 	})
 
-	// Validation endpoint
-	mux.HandleFunc("/api/validate", func(w http.ResponseWriter, r *http.Request) {
-		handleValidation(w, r, config.DBPath)
-	})
+	// Validation endpoint, cached until the database file changes
+	cache := newValidationCache(config.DBPath, ruleSet)
+	go cache.watch(2 * time.Second)
+	mux.Handle("/api/validate", cache)
+
+	// Passenger endpoints share one in-memory copy of the network
+	store := newNetworkStore(config.DBPath, fareConfig)
+	go store.watch(2 * time.Second)
+	mux.HandleFunc("/api/stations/", stationsHandler(store))
+	mux.HandleFunc("/api/journeys", journeysHandler(store))
+	mux.HandleFunc("/api/routes", routesHandler(store))
+	mux.HandleFunc("/api/fare", fareHandler(store))
+
+	// Live train positions are kept in memory and expire without reports
+	tracker := live.NewTracker()
+	tracker.Events = live.NewHub()
+	restored := 0
+	if config.StatePath != "" {
+		stateStore, err := live.OpenStateStore(config.StatePath)
+		if err != nil {
+			log.Fatalf("Failed to open live state: %v", err)
+		}
+		state, err := stateStore.Load()
+		if err != nil {
+			log.Fatalf("Failed to load live state: %v", err)
+		}
+		tracker.Restore(state)
+		restored = len(tracker.Trains("", ""))
+		go tracker.Persist(stateStore, live.SnapshotInterval)
+		go saveOnShutdown(tracker, stateStore)
+	}
+	go tracker.Janitor(live.CleanupInterval)
+	mux.HandleFunc("/api/live/trains", liveHandler(store, tracker))
+	mux.HandleFunc("/api/live/trains/", liveHandler(store, tracker))
+	mux.HandleFunc("/api/live/stream", streamHandler(store, tracker, tracker.Events))
+	mux.HandleFunc("/api/live/match", matchHandler(store))
 
 	// CORS middleware wrapper
 	handler := corsMiddleware(mux)
@@ -55,15 +111,48 @@ func runServer(config ServerConfig) {
 	fmt.Printf("\n  🚀 Metro Validator Server v%s\n", version)
 	fmt.Printf("  ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	fmt.Printf("  Database: %s\n", config.DBPath)
+	if config.RulesPath != "" {
+		fmt.Printf("  Rules:    %s\n", config.RulesPath)
+	}
+	if config.StatePath != "" {
+		fmt.Printf("  State:    %s (%d live trains restored)\n", config.StatePath, restored)
+	}
 	fmt.Printf("  Server:   http://localhost:%s\n", config.Port)
 	fmt.Printf("  ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
 	fmt.Printf("  Endpoints:\n")
 	fmt.Printf("    GET  /health        - Health check\n")
-	fmt.Printf("    GET  /api/validate  - Run validation\n\n")
+	fmt.Printf("    GET  /api/validate  - Run validation\n")
+	fmt.Printf("    GET  /api/stations/search?q=&city=&lat=&lng=&limit=  - Station search\n")
+	fmt.Printf("    GET  /api/stations/nearby?lat=&lng=&radius=&limit=   - Nearest stations\n")
+	fmt.Printf("    GET  /api/stations/{id}/departures?at=&limit=        - Departure board\n")
+	fmt.Printf("    GET  /api/journeys?from=&to=&depart_at=&step_free=   - Timetable journey planner\n")
+	fmt.Printf("    GET  /api/routes?from=&to=&limit=&step_free=         - Route alternatives\n")
+	fmt.Printf("    GET  /api/fare?from=&to=                             - Fare quote\n")
+	fmt.Printf("    GET  /api/live/trains?lineId=&cityId=                - Live train positions\n")
+	fmt.Printf("    GET  /api/live/trains/{trainId}                      - Live train with next station ETA\n")
+	fmt.Printf("    POST /api/live/trains/report                         - Report a train position\n")
+	fmt.Printf("    GET  /api/live/stream?line=&city=                    - Live updates (Server-Sent Events)\n")
+	fmt.Printf("    POST /api/live/match                                 - Match a GPS trace to a line\n\n")
 
 	log.Fatal(http.ListenAndServe(":"+config.Port, handler))
 }
 
+// saveOnShutdown writes the live state one last time when the server is
+// asked to stop, so a redeploy picks up where it left off
+func saveOnShutdown(tracker *live.Tracker, store *live.StateStore) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	state, _ := tracker.Snapshot()
+	if err := store.Save(state); err != nil {
+		log.Printf("Failed to save live state: %v", err)
+		os.Exit(1)
+	}
+	store.Close()
+	os.Exit(0)
+}
+
 // corsMiddleware adds CORS headers for frontend access
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -80,8 +169,9 @@ func corsMiddleware(next http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -92,13 +182,15 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// handleValidation runs the validation and returns JSON response
-func handleValidation(w http.ResponseWriter, r *http.Request, dbPath string) {
-	w.Header().Set("Content-Type", "application/json")
-
+// validate runs the validation and returns the response with its HTTP status
+func validate(dbPath string, ruleSet *rules.RuleSet) (ValidationResponse, int) {
+	// Failures still send every field so clients can render them unguarded
 	response := ValidationResponse{
 		Database:  dbPath,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Stats:     &database.Stats{},
+		Results:   map[string]*validators.Result{},
+		Issues:    []validators.Issue{},
 	}
 
 	// Open database
@@ -107,72 +199,75 @@ func handleValidation(w http.ResponseWriter, r *http.Request, dbPath string) {
 		response.Success = false
 		response.Status = "error"
 		response.Error = fmt.Sprintf("Failed to open database: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		return response, http.StatusInternalServerError
 	}
 	defer db.Close()
 
+	// Confirm the tables and columns the loaders read exist
+	problems, err := db.MissingSchema()
+	if err != nil {
+		response.Success = false
+		response.Status = "error"
+		response.Error = fmt.Sprintf("Failed to inspect schema: %v", err)
+		return response, http.StatusInternalServerError
+	}
+	schema := validators.ValidateSchema(database.RequiredTables(), problems)
+	if schema.HasErrors() {
+		response.Success = false
+		response.Status = "schema_error"
+		response.Error = fmt.Sprintf("Database schema is incomplete (%d problems)", schema.Failed)
+		response.Results = map[string]*validators.Result{"schema": schema}
+		response.Issues = schema.Issues
+		return response, http.StatusServiceUnavailable
+	}
+
 	// Get statistics
 	stats, err := db.GetStats()
 	if err != nil {
 		response.Success = false
 		response.Status = "error"
 		response.Error = fmt.Sprintf("Failed to get stats: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(response)
-		return
+		return response, http.StatusInternalServerError
 	}
 	response.Stats = stats
 
-	// Load all data
-	cities, _ := db.GetAllCities()
-	lines, _ := db.GetAllLines()
-	stations, _ := db.GetAllStations()
-	lineStations, _ := db.GetAllLineStations()
-	connections, _ := db.GetAllConnections()
-	stationCounts, _ := db.GetStationCountByLine()
-	linesPerStation, _ := db.GetLinesPerStation()
+	// Load all data, keeping whatever loads
+	data, failed := loadNetworkDataPartial(db)
 
 	// Run validations
-	results := make(map[string]*validators.Result)
-	results["city"] = validators.ValidateCities(cities)
-	results["line"] = validators.ValidateLines(lines, cities, stationCounts)
-	results["station"] = validators.ValidateStations(stations, cities)
-	results["connection"] = validators.ValidateConnections(connections, stations, lines, lineStations)
-	results["interchange"] = validators.ValidateInterchanges(stations, linesPerStation)
-
+	results, skipped := runAvailableValidations(data, failed, ruleSet)
+	results["schema"] = schema
 	response.Results = results
+	response.Issues = append(response.Issues, collectIssues(results)...)
 
-	// Collect all issues
-	var allIssues []validators.Issue
-	for _, res := range results {
-		allIssues = append(allIssues, res.Issues...)
-	}
-	response.Issues = allIssues
-
-	// Determine status
-	hasErrors := false
-	hasWarnings := false
-	for _, res := range results {
-		if res.Failed > 0 {
-			hasErrors = true
-		}
-		if res.Warnings > 0 {
-			hasWarnings = true
-		}
+	if len(skipped) > 0 {
+		response.Success = false
+		response.Status = "partial"
+		response.Error = fmt.Sprintf("%d validation categories were skipped because their data failed to load", len(skipped))
+		response.LoadErrors = skipped
+		// The categories that loaded were validated, so this is still a result
+		return response, http.StatusOK
 	}
 
-	response.Success = !hasErrors
-	if hasErrors {
-		response.Status = "fail"
-	} else if hasWarnings {
-		response.Status = "pass_with_warnings"
-	} else {
-		response.Status = "pass"
-	}
+	response.Status = overallStatus(results)
+	response.Success = response.Status != "fail"
+
+	return response, http.StatusOK
+}
 
-	json.NewEncoder(w).Encode(response)
+// writeJSON writes a JSON response with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"success": false,
+		"error":   message,
+	})
 }
 
 // getEnv gets an environment variable with a default fallback
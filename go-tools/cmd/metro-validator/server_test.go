@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testSchema is the part of the backend schema the service datasets need
+const testSchema = `
+CREATE TABLE cities (
+	id TEXT PRIMARY KEY, name TEXT NOT NULL, display_name TEXT NOT NULL, country TEXT NOT NULL,
+	timezone TEXT NOT NULL, map_center TEXT NOT NULL, is_active INTEGER NOT NULL DEFAULT 1
+);
+CREATE TABLE metro_lines (
+	id TEXT PRIMARY KEY, city_id TEXT NOT NULL REFERENCES cities(id), name TEXT NOT NULL,
+	color TEXT NOT NULL, display_order INTEGER NOT NULL
+);
+CREATE TABLE metro_stations (
+	id TEXT PRIMARY KEY, city_id TEXT NOT NULL REFERENCES cities(id), name TEXT NOT NULL,
+	latitude REAL NOT NULL, longitude REAL NOT NULL, is_interchange INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE line_stations (
+	id INTEGER PRIMARY KEY AUTOINCREMENT, line_id TEXT NOT NULL REFERENCES metro_lines(id),
+	station_id TEXT NOT NULL REFERENCES metro_stations(id), sequence_number INTEGER NOT NULL, direction TEXT NOT NULL
+);
+CREATE TABLE station_connections (
+	id INTEGER PRIMARY KEY AUTOINCREMENT, from_station_id TEXT NOT NULL REFERENCES metro_stations(id),
+	to_station_id TEXT NOT NULL REFERENCES metro_stations(id), line_id TEXT NOT NULL REFERENCES metro_lines(id),
+	travel_time_seconds INTEGER NOT NULL, stop_time_seconds INTEGER NOT NULL DEFAULT 25
+);
+CREATE TABLE train_schedules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT, line_id TEXT NOT NULL REFERENCES metro_lines(id), direction TEXT NOT NULL,
+	start_station_id TEXT NOT NULL REFERENCES metro_stations(id), end_station_id TEXT NOT NULL REFERENCES metro_stations(id),
+	first_train_time TEXT NOT NULL, last_train_time TEXT NOT NULL,
+	peak_frequency_minutes INTEGER NOT NULL, off_peak_frequency_minutes INTEGER NOT NULL
+);
+CREATE TABLE peak_hours (
+	id INTEGER PRIMARY KEY AUTOINCREMENT, schedule_id INTEGER NOT NULL REFERENCES train_schedules(id),
+	start_time TEXT NOT NULL, end_time TEXT NOT NULL
+);
+`
+
+// testNetwork is one line through a, b and c, about 1.1km apart. Its only
+// published schedule runs c -> a but, like the shipped data, is labelled forward.
+const testNetwork = `
+INSERT INTO cities VALUES ('delhi', 'Delhi', 'Delhi Metro', 'India', 'Asia/Kolkata', '{"lat":28.61,"lng":77.2}', 1);
+INSERT INTO metro_lines VALUES ('delhi-yellow', 'delhi', 'Yellow Line', '#FFCC00', 1);
+INSERT INTO metro_stations VALUES
+	('a', 'delhi', 'Alpha', 28.60, 77.20, 0),
+	('b', 'delhi', 'Bravo', 28.61, 77.20, 0),
+	('c', 'delhi', 'Charlie', 28.62, 77.20, 0);
+INSERT INTO line_stations (line_id, station_id, sequence_number, direction) VALUES
+	('delhi-yellow', 'a', 1, 'forward'), ('delhi-yellow', 'b', 2, 'forward'), ('delhi-yellow', 'c', 3, 'forward');
+INSERT INTO station_connections (from_station_id, to_station_id, line_id, travel_time_seconds, stop_time_seconds) VALUES
+	('a', 'b', 'delhi-yellow', 120, 30), ('b', 'c', 'delhi-yellow', 120, 30),
+	('c', 'b', 'delhi-yellow', 120, 30), ('b', 'a', 'delhi-yellow', 120, 30);
+INSERT INTO train_schedules VALUES (1, 'delhi-yellow', 'forward', 'c', 'a', '06:00:00', '23:00:00', 5, 10);
+`
+
+// testComplex adds the blue line from a round to d, which stands next to c.
+// c and d form a complex, so a -> d is quickest by yellow line and walkway
+// but only the blue line joins them by train.
+const testComplex = `
+INSERT INTO metro_lines VALUES ('delhi-blue', 'delhi', 'Blue Line', '#0066B3', 2);
+INSERT INTO metro_stations VALUES ('f', 'delhi', 'Foxtrot', 28.61, 77.23, 0), ('d', 'delhi', 'Delta', 28.62, 77.2005, 0);
+INSERT INTO line_stations (line_id, station_id, sequence_number, direction) VALUES
+	('delhi-blue', 'a', 1, 'forward'), ('delhi-blue', 'f', 2, 'forward'), ('delhi-blue', 'd', 3, 'forward');
+INSERT INTO station_connections (from_station_id, to_station_id, line_id, travel_time_seconds, stop_time_seconds) VALUES
+	('a', 'f', 'delhi-blue', 600, 30), ('f', 'd', 'delhi-blue', 600, 30),
+	('d', 'f', 'delhi-blue', 600, 30), ('f', 'a', 'delhi-blue', 600, 30);
+CREATE TABLE station_groups (
+	id TEXT PRIMARY KEY, city_id TEXT NOT NULL REFERENCES cities(id), name TEXT NOT NULL,
+	walk_seconds INTEGER NOT NULL CHECK(walk_seconds > 0)
+);
+CREATE TABLE station_group_members (
+	id INTEGER PRIMARY KEY AUTOINCREMENT, group_id TEXT NOT NULL REFERENCES station_groups(id),
+	station_id TEXT NOT NULL REFERENCES metro_stations(id)
+);
+INSERT INTO station_groups VALUES ('cd', 'delhi', 'Charlie-Delta', 60);
+INSERT INTO station_group_members (group_id, station_id) VALUES ('cd', 'c'), ('cd', 'd');
+`
+
+// newTestDB writes the test network, then any further statements, to a new database
+func newTestDB(t *testing.T, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "metro.db")
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer conn.Close()
+	for _, stmt := range append([]string{testSchema, testNetwork}, statements...) {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("failed to write test database: %v", err)
+		}
+	}
+	return path
+}
+
+// serve sends a request to a handler and decodes the JSON response into v
+func serve(t *testing.T, h http.HandlerFunc, method, target, body string, v interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusNotModified && ct != "application/json" {
+		t.Errorf("%s %s: Content-Type = %q, want application/json", method, target, ct)
+	}
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: failed to decode %q: %v", method, target, rec.Body.String(), err)
+		}
+	}
+	return rec
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/timetable"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	simulateCSV        string
+	simulateDepartures string
+	simulateStation    string
+	simulateLine       string
+	simulateLimit      int
+	simulateAssumed    bool
+)
+
+// newSimulateCmd creates the simulate command
+func newSimulateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Generate the synthetic timetable for a service day",
+		Long: "Builds every trip of a service day from train_schedules, peak_hours and the cumulative\n" +
+			"station_connections travel and stop times, with each trip's arrival and departure at every\n" +
+			"station. Writes the timetable and per-station departures as CSV and prints a departure board.",
+		Example: "  metro-validator simulate --csv timetable.csv --departures departures.csv\n" +
+			"  metro-validator simulate --station delhi-rajiv-chowk",
+		Run: runSimulate,
+	}
+	cmd.Flags().StringVar(&simulateCSV, "csv", "", "Write the timetable (one row per trip and station) to this CSV file")
+	cmd.Flags().StringVar(&simulateDepartures, "departures", "", "Write departures from every station to this CSV file")
+	cmd.Flags().StringVar(&simulateStation, "station", "", "Print the departure board for this station")
+	cmd.Flags().StringVar(&simulateLine, "line", "", "Only simulate schedules on this line")
+	cmd.Flags().IntVar(&simulateLimit, "limit", 20, "Maximum number of departures to print for --station")
+	cmd.Flags().BoolVar(&simulateAssumed, "assumed", false, "Also run the default service on lines without a schedule, as the server does")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Output the timetable as JSON")
+	return cmd
+}
+
+func runSimulate(cmd *cobra.Command, args []string) {
+	if !jsonOut {
+		printHeader()
+	}
+
+	db, err := database.Open(dbPath)
+	if err != nil {
+		exitWithError("Failed to open database", err)
+	}
+	defer db.Close()
+
+	schedules, err := db.GetAllTrainSchedules()
+	if err != nil {
+		exitWithError("Failed to load train schedules", err)
+	}
+	peakHours, err := db.GetAllPeakHours()
+	if err != nil {
+		exitWithError("Failed to load peak hours", err)
+	}
+	lineStations, err := db.GetAllLineStations()
+	if err != nil {
+		exitWithError("Failed to load line stations", err)
+	}
+	connections, err := db.GetAllConnections()
+	if err != nil {
+		exitWithError("Failed to load connections", err)
+	}
+
+	if simulateAssumed {
+		assumed, assumedPeaks := timetable.AssumedSchedules(schedules, lineStations, connections, timetable.DefaultService)
+		schedules = append(schedules, assumed...)
+		peakHours = append(peakHours, assumedPeaks...)
+	}
+
+	if simulateLine != "" {
+		filtered := schedules[:0]
+		for _, s := range schedules {
+			if s.LineID == simulateLine {
+				filtered = append(filtered, s)
+			}
+		}
+		schedules = filtered
+	}
+
+	tt := timetable.Generate(schedules, peakHours, lineStations, connections)
+
+	if simulateCSV != "" {
+		if err := writeTimetableCSV(simulateCSV, tt); err != nil {
+			exitWithError("Failed to write timetable", err)
+		}
+	}
+	if simulateDepartures != "" {
+		if err := writeDeparturesCSV(simulateDepartures, tt.AllDepartures()); err != nil {
+			exitWithError("Failed to write departures", err)
+		}
+	}
+
+	if jsonOut {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(tt)
+		return
+	}
+	printSimulation(tt)
+}
+
+// writeTimetableCSV writes one row per trip and station
+func writeTimetableCSV(path string, tt *timetable.Timetable) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"trip_id", "schedule_id", "line_id", "direction", "peak", "stop_sequence", "station_id", "arrival_time", "departure_time"})
+	for _, trip := range tt.Trips {
+		for i, stop := range trip.Stops {
+			w.Write([]string{
+				trip.ID,
+				strconv.Itoa(trip.ScheduleID),
+				trip.LineID,
+				trip.Direction,
+				strconv.FormatBool(trip.Peak),
+				strconv.Itoa(i + 1),
+				stop.StationID,
+				timetable.FormatClock(stop.Arrival),
+				timetable.FormatClock(stop.Departure),
+			})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeDeparturesCSV writes departures ordered by station and time
+func writeDeparturesCSV(path string, deps []timetable.Departure) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"station_id", "departure_time", "line_id", "direction", "destination_id", "trip_id"})
+	for _, d := range deps {
+		w.Write([]string{d.StationID, timetable.FormatClock(d.Time), d.LineID, d.Direction, d.Destination, d.TripID})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func printSimulation(tt *timetable.Timetable) {
+	fmt.Printf("  %s %s\n", cyan("Database:"), dbPath)
+	fmt.Printf("  %s %d trips from %d schedules\n", cyan("Timetable:"), len(tt.Trips), len(tt.Schedules))
+	fmt.Println()
+
+	if len(tt.Schedules) > 0 {
+		fmt.Println(bold("  Schedules"))
+		for _, s := range tt.Schedules {
+			fmt.Printf("    #%-3d %-22s %-8s %4d trips (%d peak) %s-%s, %d stations in %s\n",
+				s.ScheduleID, s.LineID, s.Direction, s.Trips, s.PeakTrips,
+				s.FirstDeparture, s.LastDeparture, s.Stations, formatDuration(s.DurationSeconds))
+		}
+		fmt.Println()
+	}
+
+	if len(tt.Warnings) > 0 {
+		fmt.Println(bold("  Warnings"))
+		for _, w := range tt.Warnings {
+			fmt.Printf("    %s %s\n", yellow("⚠"), w)
+		}
+		fmt.Println()
+	}
+
+	if simulateStation != "" {
+		deps := tt.Departures(simulateStation)
+		fmt.Printf("  %s %s (%d departures)\n", bold("Departures from"), simulateStation, len(deps))
+		for i, d := range deps {
+			if i >= simulateLimit {
+				fmt.Printf("    %s\n", dimmed(fmt.Sprintf("... %d more (use --limit or --departures)", len(deps)-simulateLimit)))
+				break
+			}
+			fmt.Printf("    %s  %-22s to %-28s %s\n", timetable.FormatClock(d.Time), d.LineID, d.Destination, dimmed(d.TripID))
+		}
+		fmt.Println()
+	}
+
+	for _, path := range []string{simulateCSV, simulateDepartures} {
+		if path != "" {
+			fmt.Printf("  %s %s\n", green("✓ Wrote"), path)
+		}
+	}
+	if simulateCSV != "" || simulateDepartures != "" {
+		fmt.Println()
+	}
+}
+
+// formatDuration formats seconds as e.g. "1h 2m"
+func formatDuration(seconds int) string {
+	if seconds < 3600 {
+		return fmt.Sprintf("%dm %ds", seconds/60, seconds%60)
+	}
+	return fmt.Sprintf("%dh %dm", seconds/3600, seconds/60%60)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/fares"
+	"metro-tools/internal/graph"
+	"metro-tools/internal/journey"
+	"metro-tools/internal/live"
+	"metro-tools/internal/mapmatch"
+	"metro-tools/internal/names"
+	"metro-tools/internal/search"
+	"metro-tools/internal/spatial"
+	"metro-tools/internal/timetable"
+	"metro-tools/internal/topology"
+)
+
+// serviceDatasets are the dataLoaders the passenger-facing endpoints need
+var serviceDatasets = []string{
+	"cities", "lines", "stations", "line_stations", "connections", "train_schedules", "peak_hours",
+	"interchange_transfers", "station_groups", "station_facilities", "station_names", "city_languages",
+}
+
+// networkSnapshot is the network as loaded from one version of the database,
+// with the indexes and derived data the API endpoints share
+type networkSnapshot struct {
+	data      *networkData
+	files     map[string]fileState
+	loadedAt  time.Time
+	cities    map[string]database.City
+	lines     map[string]database.MetroLine
+	stations  map[string]database.MetroStation
+	locations map[string]*time.Location // city ID -> timezone
+	topology  *topology.Topology
+	graph     *graph.Graph
+	rides     *graph.Graph // train hops only, without walkways, for fares
+	transfers *graph.TransferTimes
+	timetable *timetable.Timetable
+	planner   *journey.Planner
+	fares     *fares.Calculator
+	names     *names.Catalog
+	search    *search.Index
+	nearby    *spatial.Grid
+	tracks    map[string][]live.Segment // line ID -> track geometry
+	matcher   *mapmatch.Network
+	// facilities by station ID; stations without data have none
+	facilities map[string]database.StationFacilities
+	// departures from each station in service-day order
+	departures map[string][]timetable.Departure
+}
+
+// networkStore keeps the current snapshot and reloads it in the background
+// when the database changes
+type networkStore struct {
+	dbPath string
+	fares  *fares.Config
+
+	current atomic.Pointer[networkSnapshot]
+
+	// mu serialises loads; failed is the database state that last failed to
+	// load and err why, so a bad write is not reloaded on every poll
+	mu     sync.Mutex
+	failed map[string]fileState
+	err    error
+}
+
+func newNetworkStore(dbPath string, fareConfig *fares.Config) *networkStore {
+	return &networkStore{dbPath: dbPath, fares: fareConfig}
+}
+
+// get returns the current snapshot, loading the first one if needed.
+// Snapshots are immutable, so callers may use them freely.
+func (s *networkStore) get() (*networkSnapshot, error) {
+	if snap := s.current.Load(); snap != nil {
+		return snap, nil
+	}
+	return s.reload()
+}
+
+// reload loads the database unless it is unchanged since the last attempt.
+// A failed load keeps serving the last good network through a bad write.
+func (s *networkStore) reload() (*networkSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := snapshotFiles(s.dbPath, nil)
+	current := s.current.Load()
+	if current != nil && reflect.DeepEqual(current.files, files) {
+		return current, nil
+	}
+	if s.failed != nil && reflect.DeepEqual(s.failed, files) {
+		if current != nil {
+			return current, nil
+		}
+		return nil, s.err
+	}
+
+	snap, err := loadSnapshot(s.dbPath, s.fares)
+	if err != nil {
+		s.failed, s.err = files, err
+		if current != nil {
+			log.Printf("Failed to reload network, serving the previous version: %v", err)
+			return current, nil
+		}
+		return nil, err
+	}
+	s.failed, s.err = nil, nil
+	snap.files = files
+	s.current.Store(snap)
+	return snap, nil
+}
+
+// watch polls the database file and reloads the network when it changes
+func (s *networkStore) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.reload()
+	}
+}
+
+// loadSnapshot reads the service datasets and builds the shared indexes
+func loadSnapshot(dbPath string, fareConfig *fares.Config) (*networkSnapshot, error) {
+	db, err := database.Open(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	var d networkData
+	for _, l := range dataLoaders {
+		if !contains(serviceDatasets, l.name) {
+			continue
+		}
+		if err := l.load(db, &d); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", l.name, err)
+		}
+	}
+
+	snap := &networkSnapshot{
+		data:       &d,
+		loadedAt:   time.Now(),
+		cities:     make(map[string]database.City),
+		lines:      make(map[string]database.MetroLine),
+		stations:   make(map[string]database.MetroStation),
+		locations:  make(map[string]*time.Location),
+		topology:   topology.New(d.LineStations, d.Connections),
+		graph:      graph.New(d.Connections),
+		rides:      graph.New(d.Connections),
+		transfers:  graph.NewTransferTimes(d.Transfers, journey.DefaultTransferSeconds),
+		fares:      fares.NewCalculator(fareConfig, d.Cities, d.Stations),
+		names:      names.NewCatalog(d.Names, d.Stations, names.NewLanguages(d.CityLanguages)),
+		departures: make(map[string][]timetable.Departure),
+		facilities: make(map[string]database.StationFacilities),
+	}
+
+	// Lines without a published schedule run an assumed default service
+	assumed, assumedPeaks := timetable.AssumedSchedules(d.Schedules, d.LineStations, d.Connections, timetable.DefaultService)
+	schedules := append(append([]database.TrainSchedule(nil), d.Schedules...), assumed...)
+	peakHours := append(append([]database.PeakHour(nil), d.PeakHours...), assumedPeaks...)
+	snap.timetable = timetable.Generate(schedules, peakHours, d.LineStations, d.Connections)
+	snap.planner = journey.NewPlanner(snap.timetable)
+	snap.planner.Transfer = snap.transfers.Walk
+	snap.planner.AddWalkways(d.Groups)
+	snap.graph.AddWalkways(d.Groups)
+
+	for _, c := range d.Cities {
+		snap.cities[c.ID] = c
+		loc, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		snap.locations[c.ID] = loc
+	}
+	for _, l := range d.Lines {
+		snap.lines[l.ID] = l
+	}
+	entries := make([]search.Entry, 0, len(d.Stations))
+	points := make([]spatial.Point, 0, len(d.Stations))
+	for _, st := range d.Stations {
+		snap.stations[st.ID] = st
+		points = append(points, spatial.Point{ID: st.ID, Lat: st.Latitude, Lng: st.Longitude})
+
+		entry := search.Entry{StationID: st.ID, CityID: st.CityID, Name: st.Name, Latitude: st.Latitude, Longitude: st.Longitude}
+		for _, n := range snap.names.All(st.ID) {
+			entry.Aliases = append(entry.Aliases, n.Name)
+			if n.Transliteration != "" {
+				entry.Aliases = append(entry.Aliases, n.Transliteration)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	snap.search = search.NewIndex(entries)
+	snap.nearby = spatial.NewGrid(points)
+	snap.tracks = live.BuildTracks(snap.topology, d.Stations)
+	snap.matcher = mapmatch.NewNetwork(snap.tracks)
+	for _, f := range d.Facilities {
+		snap.facilities[f.StationID] = f
+	}
+	for _, dep := range snap.timetable.AllDepartures() {
+		snap.departures[dep.StationID] = append(snap.departures[dep.StationID], dep)
+	}
+
+	return snap, nil
+}
+
+// location returns the timezone of a city, defaulting to UTC
+func (n *networkSnapshot) location(cityID string) *time.Location {
+	if loc, ok := n.locations[cityID]; ok {
+		return loc
+	}
+	return time.UTC
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
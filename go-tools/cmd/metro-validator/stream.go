@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"metro-tools/internal/live"
+)
+
+const (
+	// streamBuffer is how many events a slow client may fall behind before it is dropped
+	streamBuffer = 64
+	// streamHeartbeat keeps idle connections open through proxies
+	streamHeartbeat = 15 * time.Second
+)
+
+// streamHandler pushes live train updates as Server-Sent Events. A client
+// first receives the current position of every train it asked for, then
+// each update as it is reported.
+func streamHandler(store *networkStore, tracker *live.Tracker, hub *live.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "Only GET is supported")
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "Streaming is not supported")
+			return
+		}
+
+		snap, err := store.get()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to load network: %v", err))
+			return
+		}
+		lineID, cityID := r.URL.Query().Get("line"), r.URL.Query().Get("city")
+		if _, ok := snap.lines[lineID]; lineID != "" && !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("Line '%s' not found", lineID))
+			return
+		}
+		if _, ok := snap.cities[cityID]; cityID != "" && !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("City '%s' not found", cityID))
+			return
+		}
+
+		// Subscribe before the snapshot so no update falls in between
+		sub := hub.Subscribe(lineID, cityID, streamBuffer)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // disable nginx buffering
+		w.WriteHeader(http.StatusOK)
+
+		for _, train := range tracker.Trains(lineID, cityID) {
+			next := tracker.NextStation(train, snap.tracks[train.LineID])
+			writeEvent(w, live.Event{Type: live.EventPosition, Train: train, NextStation: &next})
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, open := <-sub.Events():
+				if !open {
+					// Dropped for falling behind; the client reconnects
+					return
+				}
+				writeEvent(w, e)
+				flusher.Flush()
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// writeEvent writes one event in text/event-stream format. Snapshot events
+// have no ID, as they are not part of the published sequence.
+func writeEvent(w http.ResponseWriter, e live.Event) {
+	data, _ := json.Marshal(e)
+	if e.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", e.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/rules"
+	"metro-tools/internal/validators"
+)
+
+// networkData holds everything the validators read from the database
+type networkData struct {
+	Cities          []database.City
+	Lines           []database.MetroLine
+	Stations        []database.MetroStation
+	LineStations    []database.LineStation
+	Connections     []database.StationConnection
+	StationCounts   map[string]int
+	LinesPerStation map[string][]string
+	Schedules       []database.TrainSchedule
+	PeakHours       []database.PeakHour
+	Sightings       []database.TrainSighting
+	Transfers       []database.InterchangeTransfer
+	HasTransfers    bool // interchange_transfers exists
+	Groups          []database.StationGroup
+	Facilities      []database.StationFacilities
+	Names           []database.StationName
+	HasNames        bool // station_names exists
+	CityLanguages   []database.CityLanguage
+	HasLanguages    bool // city_languages exists
+	FKViolations    []database.ForeignKeyViolation
+}
+
+// dataLoader loads one dataset into networkData
+type dataLoader struct {
+	name string
+	load func(db *database.DB, d *networkData) error
+}
+
+// dataLoaders lists every dataset the validators read, in load order
+var dataLoaders = []dataLoader{
+	{"cities", func(db *database.DB, d *networkData) (err error) {
+		d.Cities, err = db.GetAllCities()
+		return err
+	}},
+	{"lines", func(db *database.DB, d *networkData) (err error) {
+		d.Lines, err = db.GetAllLines()
+		return err
+	}},
+	{"stations", func(db *database.DB, d *networkData) (err error) {
+		d.Stations, err = db.GetAllStations()
+		return err
+	}},
+	{"line_stations", func(db *database.DB, d *networkData) (err error) {
+		d.LineStations, err = db.GetAllLineStations()
+		return err
+	}},
+	{"connections", func(db *database.DB, d *networkData) (err error) {
+		d.Connections, err = db.GetAllConnections()
+		return err
+	}},
+	{"station_counts", func(db *database.DB, d *networkData) (err error) {
+		d.StationCounts, err = db.GetStationCountByLine()
+		return err
+	}},
+	{"lines_per_station", func(db *database.DB, d *networkData) (err error) {
+		d.LinesPerStation, err = db.GetLinesPerStation()
+		return err
+	}},
+	{"train_schedules", func(db *database.DB, d *networkData) (err error) {
+		d.Schedules, err = db.GetAllTrainSchedules()
+		return err
+	}},
+	{"peak_hours", func(db *database.DB, d *networkData) (err error) {
+		d.PeakHours, err = db.GetAllPeakHours()
+		return err
+	}},
+	{"train_sightings", func(db *database.DB, d *networkData) error {
+		// Added by a later migration, so older databases may not have it
+		exists, err := db.TableExists("train_sightings")
+		if err != nil || !exists {
+			return err
+		}
+		d.Sightings, err = db.GetAllTrainSightings()
+		return err
+	}},
+	{"interchange_transfers", func(db *database.DB, d *networkData) (err error) {
+		// Optional until add-interchange-transfers.ts has run
+		d.HasTransfers, err = db.TableExists("interchange_transfers")
+		if err != nil || !d.HasTransfers {
+			return err
+		}
+		d.Transfers, err = db.GetAllInterchangeTransfers()
+		return err
+	}},
+	{"station_groups", func(db *database.DB, d *networkData) error {
+		// Optional until add-station-groups.ts has run
+		exists, err := db.TableExists("station_groups")
+		if err != nil || !exists {
+			return err
+		}
+		d.Groups, err = db.GetAllStationGroups()
+		return err
+	}},
+	{"station_facilities", func(db *database.DB, d *networkData) error {
+		// Optional until add-station-facilities.ts has run
+		exists, err := db.TableExists("station_facilities")
+		if err != nil || !exists {
+			return err
+		}
+		d.Facilities, err = db.GetAllStationFacilities()
+		return err
+	}},
+	{"station_names", func(db *database.DB, d *networkData) (err error) {
+		// Optional until add-station-names.ts has run
+		d.HasNames, err = db.TableExists("station_names")
+		if err != nil || !d.HasNames {
+			return err
+		}
+		d.Names, err = db.GetAllStationNames()
+		return err
+	}},
+	{"city_languages", func(db *database.DB, d *networkData) (err error) {
+		// Optional until add-city-languages.ts has run
+		d.HasLanguages, err = db.TableExists("city_languages")
+		if err != nil || !d.HasLanguages {
+			return err
+		}
+		d.CityLanguages, err = db.GetAllCityLanguages()
+		return err
+	}},
+	{"foreign_key_check", func(db *database.DB, d *networkData) (err error) {
+		d.FKViolations, err = db.ForeignKeyCheck()
+		return err
+	}},
+}
+
+// loadNetworkData loads all tables needed for validation, stopping at the first failure
+func loadNetworkData(db *database.DB) (*networkData, error) {
+	var d networkData
+	for _, l := range dataLoaders {
+		if err := l.load(db, &d); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", l.name, err)
+		}
+	}
+	return &d, nil
+}
+
+// loadNetworkDataPartial loads every dataset it can and returns the failures by dataset name
+func loadNetworkDataPartial(db *database.DB) (*networkData, map[string]error) {
+	var d networkData
+	failed := make(map[string]error)
+	for _, l := range dataLoaders {
+		if err := l.load(db, &d); err != nil {
+			failed[l.name] = err
+		}
+	}
+	return &d, failed
+}
+
+// validatorSpec describes one validation category and the data it depends on
+type validatorSpec struct {
+	category string
+	needs    []string // dataset names from dataLoaders
+	inputs   func(d *networkData) []interface{}
+	run      func(d *networkData) *validators.Result
+}
+
+// validatorSpecs lists the built-in validators in display order
+var validatorSpecs = []validatorSpec{
+	{
+		category: "city",
+		needs:    []string{"cities"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Cities} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateCities(d.Cities)
+		},
+	},
+	{
+		category: "line",
+		needs:    []string{"lines", "cities", "station_counts"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Lines, d.Cities, d.StationCounts} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateLines(d.Lines, d.Cities, d.StationCounts)
+		},
+	},
+	{
+		category: "color",
+		needs:    []string{"lines"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Lines} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateLineColors(d.Lines)
+		},
+	},
+	{
+		category: "station",
+		needs:    []string{"stations", "cities"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Stations, d.Cities} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateStations(d.Stations, d.Cities)
+		},
+	},
+	{
+		category: "connection",
+		needs:    []string{"connections", "stations", "lines", "line_stations"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Connections, d.Stations, d.Lines, d.LineStations}
+		},
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateConnections(d.Connections, d.Stations, d.Lines, d.LineStations)
+		},
+	},
+	{
+		category: "interchange",
+		needs:    []string{"stations", "lines_per_station", "station_groups"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Stations, d.LinesPerStation, d.Groups}
+		},
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateInterchanges(d.Stations, d.LinesPerStation, d.Groups)
+		},
+	},
+	{
+		category: "group",
+		needs:    []string{"station_groups", "stations"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Groups, d.Stations} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateStationGroups(d.Groups, d.Stations)
+		},
+	},
+	{
+		category: "facility",
+		needs:    []string{"station_facilities", "stations"},
+		inputs:   func(d *networkData) []interface{} { return []interface{}{d.Facilities, d.Stations} },
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateFacilities(d.Facilities, d.Stations)
+		},
+	},
+	{
+		category: "name",
+		needs:    []string{"station_names", "city_languages", "stations"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Names, d.HasNames, d.CityLanguages, d.HasLanguages, d.Stations}
+		},
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateStationNames(d.Names, d.CityLanguages, d.Stations, d.HasNames, d.HasLanguages)
+		},
+	},
+	{
+		category: "transfer",
+		needs:    []string{"interchange_transfers", "lines_per_station"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Transfers, d.HasTransfers, d.LinesPerStation}
+		},
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateTransfers(d.Transfers, d.LinesPerStation, d.HasTransfers)
+		},
+	},
+	{
+		category: "reference",
+		needs: []string{"cities", "lines", "stations", "line_stations", "connections",
+			"train_schedules", "peak_hours", "train_sightings", "interchange_transfers", "station_groups", "station_facilities", "station_names",
+			"city_languages", "foreign_key_check"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Cities, d.Lines, d.Stations, d.LineStations, d.Connections,
+				d.Schedules, d.PeakHours, d.Sightings, d.Transfers, d.Groups, d.Facilities, d.Names, d.CityLanguages, d.FKViolations}
+		},
+		run: func(d *networkData) *validators.Result {
+			return validators.ValidateReferences(validators.ReferenceData{
+				Cities:       d.Cities,
+				Lines:        d.Lines,
+				Stations:     d.Stations,
+				LineStations: d.LineStations,
+				Connections:  d.Connections,
+				Schedules:    d.Schedules,
+				PeakHours:    d.PeakHours,
+				Sightings:    d.Sightings,
+				Transfers:    d.Transfers,
+				Groups:       d.Groups,
+				Facilities:   d.Facilities,
+				Names:        d.Names,
+				Languages:    d.CityLanguages,
+				Violations:   d.FKViolations,
+			})
+		},
+	},
+}
+
+// rulesSpec runs custom rules, which may look at any part of the network
+func rulesSpec(ruleSet *rules.RuleSet) validatorSpec {
+	return validatorSpec{
+		category: "rules",
+		needs:    []string{"cities", "lines", "stations", "line_stations", "connections"},
+		inputs: func(d *networkData) []interface{} {
+			return []interface{}{d.Cities, d.Lines, d.Stations, d.LineStations, d.Connections}
+		},
+		run: func(d *networkData) *validators.Result {
+			network := rules.NewNetwork(d.Cities, d.Lines, d.Stations, d.LineStations, d.Connections)
+			return ruleSet.Evaluate(network)
+		},
+	}
+}
+
+// activeSpecs returns the validators to run, including custom rules if loaded
+func activeSpecs(ruleSet *rules.RuleSet) []validatorSpec {
+	specs := append([]validatorSpec{}, validatorSpecs...)
+	if ruleSet != nil {
+		specs = append(specs, rulesSpec(ruleSet))
+	}
+	return specs
+}
+
+// runValidations runs every validator over the loaded data
+func runValidations(d *networkData, ruleSet *rules.RuleSet) map[string]*validators.Result {
+	results := make(map[string]*validators.Result)
+	for _, spec := range activeSpecs(ruleSet) {
+		results[spec.category] = spec.run(d)
+	}
+	return results
+}
+
+// runAvailableValidations runs the validators whose datasets loaded and
+// returns the load errors of the ones it had to skip, keyed by category
+func runAvailableValidations(d *networkData, failed map[string]error, ruleSet *rules.RuleSet) (map[string]*validators.Result, map[string]string) {
+	results := make(map[string]*validators.Result)
+	skipped := make(map[string]string)
+
+	for _, spec := range activeSpecs(ruleSet) {
+		var reasons []string
+		for _, name := range spec.needs {
+			if err := failed[name]; err != nil {
+				reasons = append(reasons, fmt.Sprintf("failed to load %s: %v", name, err))
+			}
+		}
+		if len(reasons) > 0 {
+			skipped[spec.category] = strings.Join(reasons, "; ")
+			continue
+		}
+		results[spec.category] = spec.run(d)
+	}
+	return results, skipped
+}
+
+// categoryOrder returns the display order of result categories
+func categoryOrder() []string {
+	order := []string{"schema"}
+	for _, spec := range validatorSpecs {
+		order = append(order, spec.category)
+	}
+	return append(order, "rules")
+}
+
+// collectIssues flattens the issues of all results in display order
+func collectIssues(results map[string]*validators.Result) []validators.Issue {
+	var issues []validators.Issue
+	for _, category := range categoryOrder() {
+		if r := results[category]; r != nil {
+			issues = append(issues, r.Issues...)
+		}
+	}
+	return issues
+}
+
+// overallStatus summarises results as pass, pass_with_warnings or fail
+func overallStatus(results map[string]*validators.Result) string {
+	hasErrors := false
+	hasWarnings := false
+	for _, r := range results {
+		if r.Failed > 0 {
+			hasErrors = true
+		}
+		if r.Warnings > 0 {
+			hasWarnings = true
+		}
+	}
+
+	if hasErrors {
+		return "fail"
+	} else if hasWarnings {
+		return "pass_with_warnings"
+	}
+	return "pass"
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/rules"
+	"metro-tools/internal/validators"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchPaths    []string
+	watchInterval time.Duration
+	watchSettle   time.Duration
+)
+
+// newWatchCmd creates the watch command
+func newWatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Re-run validation whenever the database or seed files change",
+		Long: "Watches the SQLite database (including its WAL) and any extra paths such as seed JSON files,\n" +
+			"re-runs the validators whose inputs changed, and prints only the issues that appeared or were resolved.",
+		Run: runWatch,
+	}
+	cmd.Flags().StringSliceVar(&watchPaths, "path", nil, "Additional files or directories to watch (e.g. ../backend/src/db/seeds)")
+	cmd.Flags().DurationVar(&watchInterval, "interval", 500*time.Millisecond, "Polling interval")
+	cmd.Flags().DurationVar(&watchSettle, "settle", time.Second, "Wait for files to stop changing for this long before validating")
+	addRulesFlag(cmd)
+	return cmd
+}
+
+// fileState is the modification time and size of a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshotFiles records the state of the database, its WAL and all extra watch paths
+func snapshotFiles(db string, paths []string) map[string]fileState {
+	states := make(map[string]fileState)
+	record := func(path string) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			states[path] = fileState{info.ModTime(), info.Size()}
+		}
+	}
+
+	record(db)
+	record(db + "-wal")
+
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			record(p)
+			continue
+		}
+		filepath.Walk(p, func(path string, fi os.FileInfo, err error) error {
+			if err == nil && !fi.IsDir() && strings.HasSuffix(fi.Name(), ".json") {
+				record(path)
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+// incrementalValidator keeps the last inputs and results per category
+type incrementalValidator struct {
+	specs   []validatorSpec
+	inputs  map[string][]interface{}
+	results map[string]*validators.Result
+}
+
+func newIncrementalValidator(ruleSet *rules.RuleSet) *incrementalValidator {
+	return &incrementalValidator{
+		specs:   activeSpecs(ruleSet),
+		inputs:  make(map[string][]interface{}),
+		results: make(map[string]*validators.Result),
+	}
+}
+
+// run re-runs only the validators whose inputs changed and returns the categories it ran
+func (iv *incrementalValidator) run(d *networkData) []string {
+	var ran []string
+	for _, spec := range iv.specs {
+		in := spec.inputs(d)
+		if prev, ok := iv.inputs[spec.category]; ok && reflect.DeepEqual(prev, in) {
+			continue
+		}
+		iv.inputs[spec.category] = in
+		iv.results[spec.category] = spec.run(d)
+		ran = append(ran, spec.category)
+	}
+	return ran
+}
+
+// issueKey identifies an issue across runs
+func issueKey(issue validators.Issue) string {
+	return strings.Join([]string{issue.Category, string(issue.Severity), issue.ID, issue.Message}, "\x00")
+}
+
+// diffIssues returns issues present in next but not prev, and those resolved since prev
+func diffIssues(prev, next []validators.Issue) (added, resolved []validators.Issue) {
+	prevKeys := make(map[string]bool, len(prev))
+	for _, issue := range prev {
+		prevKeys[issueKey(issue)] = true
+	}
+	nextKeys := make(map[string]bool, len(next))
+	for _, issue := range next {
+		nextKeys[issueKey(issue)] = true
+		if !prevKeys[issueKey(issue)] {
+			added = append(added, issue)
+		}
+	}
+	for _, issue := range prev {
+		if !nextKeys[issueKey(issue)] {
+			resolved = append(resolved, issue)
+		}
+	}
+	return added, resolved
+}
+
+func runWatch(cmd *cobra.Command, args []string) {
+	printHeader()
+
+	var ruleSet *rules.RuleSet
+	if rulesPath != "" {
+		var err error
+		ruleSet, err = rules.Load(rulesPath)
+		if err != nil {
+			fmt.Printf("%s Failed to load rules: %v\n", red("ERROR:"), err)
+			os.Exit(1)
+		}
+	}
+
+	fmt.Printf("  %s %s\n", cyan("Watching:"), dbPath)
+	for _, p := range watchPaths {
+		fmt.Printf("            %s\n", p)
+	}
+	fmt.Println(dimmed("  Press Ctrl+C to stop"))
+	fmt.Println()
+
+	iv := newIncrementalValidator(ruleSet)
+	var lastIssues []validators.Issue
+	first := true
+
+	validate := func() {
+		db, err := database.Open(dbPath)
+		if err != nil {
+			fmt.Printf("  %s %s %v\n", dimmed(time.Now().Format("15:04:05")), red("ERROR:"), err)
+			return
+		}
+		data, err := loadNetworkData(db)
+		db.Close()
+		if err != nil {
+			fmt.Printf("  %s %s %v\n", dimmed(time.Now().Format("15:04:05")), red("ERROR:"), err)
+			return
+		}
+
+		ran := iv.run(data)
+		issues := collectIssues(iv.results)
+		added, resolved := diffIssues(lastIssues, issues)
+		lastIssues = issues
+
+		printWatchRun(ran, added, resolved, iv.results, first)
+		first = false
+	}
+
+	validate()
+	last := snapshotFiles(dbPath, watchPaths)
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	var changedAt time.Time
+	for {
+		select {
+		case <-interrupt:
+			fmt.Println()
+			return
+		case <-ticker.C:
+			current := snapshotFiles(dbPath, watchPaths)
+			if !reflect.DeepEqual(current, last) {
+				// Keep waiting while a seed script is still writing
+				last = current
+				changedAt = time.Now()
+				continue
+			}
+			if !changedAt.IsZero() && time.Since(changedAt) >= watchSettle {
+				changedAt = time.Time{}
+				validate()
+			}
+		}
+	}
+}
+
+// printWatchRun prints the issues that changed since the previous run
+func printWatchRun(ran []string, added, resolved []validators.Issue, results map[string]*validators.Result, first bool) {
+	stamp := dimmed(time.Now().Format("15:04:05"))
+
+	if !first && len(added) == 0 && len(resolved) == 0 {
+		fmt.Printf("  %s %s\n", stamp, dimmed(fmt.Sprintf("No changes (re-ran: %s)", joinOrNone(ran))))
+		return
+	}
+
+	if first {
+		fmt.Printf("  %s Initial validation\n", stamp)
+	} else {
+		fmt.Printf("  %s Re-ran: %s\n", stamp, joinOrNone(ran))
+	}
+
+	sortIssues(added)
+	sortIssues(resolved)
+	for _, issue := range added {
+		label := yellow("+ WARNING:")
+		if issue.Severity == validators.SeverityError {
+			label = red("+ ERROR:  ")
+		}
+		fmt.Printf("      %s [%s] %s: %s\n", label, issue.Category, issue.ID, issue.Message)
+	}
+	for _, issue := range resolved {
+		fmt.Printf("      %s [%s] %s: %s\n", green("- FIXED:  "), issue.Category, issue.ID, issue.Message)
+	}
+
+	var totalErrors, totalWarnings int
+	for _, r := range results {
+		totalErrors += r.Failed
+		totalWarnings += r.Warnings
+	}
+	fmt.Printf("      %s %d errors, %d warnings\n\n", bold("Now:"), totalErrors, totalWarnings)
+}
+
+func sortIssues(issues []validators.Issue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Category != issues[j].Category {
+			return issues[i].Category < issues[j].Category
+		}
+		return issues[i].ID < issues[j].ID
+	})
+}
+
+func joinOrNone(categories []string) string {
+	if len(categories) == 0 {
+		return "none"
+	}
+	return strings.Join(categories, ", ")
+}
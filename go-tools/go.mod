@@ -3,6 +3,7 @@ module metro-tools
 go 1.21
 
 require (
+	github.com/expr-lang/expr v1.16.9
 	github.com/fatih/color v1.16.0
 	github.com/spf13/cobra v1.8.0
 	modernc.org/sqlite v1.28.0
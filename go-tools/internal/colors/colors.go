@@ -0,0 +1,161 @@
+package colors
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RGB is an sRGB colour with 8-bit channels
+type RGB struct {
+	R, G, B uint8
+}
+
+// Lab is a colour in CIE L*a*b* space (D65 white point)
+type Lab struct {
+	L, A, B float64
+}
+
+// ParseHex parses a #RRGGBB color code
+func ParseHex(hex string) (RGB, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(s) != 6 {
+		return RGB{}, fmt.Errorf("invalid hex color '%s' (expected format: #RRGGBB)", hex)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid hex color '%s': %w", hex, err)
+	}
+	return RGB{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v)}, nil
+}
+
+// Hex formats the colour as #RRGGBB
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// linearize converts an 8-bit sRGB channel to linear light
+func linearize(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// Lab converts the colour to CIE L*a*b*
+func (c RGB) Lab() Lab {
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+
+	// sRGB -> XYZ (D65), normalised by the reference white
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := (0.2126729*r + 0.7151522*g + 0.0721750*b) / 1.00000
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// CIEDE2000 returns the perceptual colour difference between two Lab colours
+func CIEDE2000(c1, c2 Lab) float64 {
+	const kL, kC, kH = 1.0, 1.0, 1.0
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	deg := func(r float64) float64 { return r * 180 / math.Pi }
+
+	cab1 := math.Hypot(c1.A, c1.B)
+	cab2 := math.Hypot(c2.A, c2.B)
+	cabMean := (cab1 + cab2) / 2
+	g := 0.5 * (1 - math.Sqrt(math.Pow(cabMean, 7)/(math.Pow(cabMean, 7)+math.Pow(25, 7))))
+
+	a1 := (1 + g) * c1.A
+	a2 := (1 + g) * c2.A
+	cp1 := math.Hypot(a1, c1.B)
+	cp2 := math.Hypot(a2, c2.B)
+
+	hue := func(b, a float64) float64 {
+		if a == 0 && b == 0 {
+			return 0
+		}
+		h := deg(math.Atan2(b, a))
+		if h < 0 {
+			h += 360
+		}
+		return h
+	}
+	hp1 := hue(c1.B, a1)
+	hp2 := hue(c2.B, a2)
+
+	dL := c2.L - c1.L
+	dC := cp2 - cp1
+
+	var dh float64
+	switch {
+	case cp1*cp2 == 0:
+		dh = 0
+	case math.Abs(hp2-hp1) <= 180:
+		dh = hp2 - hp1
+	case hp2-hp1 > 180:
+		dh = hp2 - hp1 - 360
+	default:
+		dh = hp2 - hp1 + 360
+	}
+	dH := 2 * math.Sqrt(cp1*cp2) * math.Sin(rad(dh/2))
+
+	lMean := (c1.L + c2.L) / 2
+	cpMean := (cp1 + cp2) / 2
+
+	var hMean float64
+	switch {
+	case cp1*cp2 == 0:
+		hMean = hp1 + hp2
+	case math.Abs(hp1-hp2) <= 180:
+		hMean = (hp1 + hp2) / 2
+	case hp1+hp2 < 360:
+		hMean = (hp1 + hp2 + 360) / 2
+	default:
+		hMean = (hp1 + hp2 - 360) / 2
+	}
+
+	t := 1 - 0.17*math.Cos(rad(hMean-30)) +
+		0.24*math.Cos(rad(2*hMean)) +
+		0.32*math.Cos(rad(3*hMean+6)) -
+		0.20*math.Cos(rad(4*hMean-63))
+
+	dTheta := 30 * math.Exp(-math.Pow((hMean-275)/25, 2))
+	rc := 2 * math.Sqrt(math.Pow(cpMean, 7)/(math.Pow(cpMean, 7)+math.Pow(25, 7)))
+	sl := 1 + (0.015*math.Pow(lMean-50, 2))/math.Sqrt(20+math.Pow(lMean-50, 2))
+	sc := 1 + 0.045*cpMean
+	sh := 1 + 0.015*cpMean*t
+	rt := -math.Sin(rad(2*dTheta)) * rc
+
+	lTerm := dL / (kL * sl)
+	cTerm := dC / (kC * sc)
+	hTerm := dH / (kH * sh)
+
+	return math.Sqrt(lTerm*lTerm + cTerm*cTerm + hTerm*hTerm + rt*cTerm*hTerm)
+}
+
+// DeltaE returns the CIEDE2000 difference between two #RRGGBB colours
+func DeltaE(hex1, hex2 string) (float64, error) {
+	c1, err := ParseHex(hex1)
+	if err != nil {
+		return 0, err
+	}
+	c2, err := ParseHex(hex2)
+	if err != nil {
+		return 0, err
+	}
+	return CIEDE2000(c1.Lab(), c2.Lab()), nil
+}
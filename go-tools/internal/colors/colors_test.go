@@ -0,0 +1,78 @@
+package colors
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCIEDE2000 uses pairs from Sharma, Wu & Dalal (2005), the reference
+// data for the formula
+func TestCIEDE2000(t *testing.T) {
+	tests := []struct {
+		c1, c2 Lab
+		want   float64
+	}{
+		{Lab{50, 2.6772, -79.7751}, Lab{50, 0, -82.7485}, 2.0425},
+		{Lab{50, 3.1571, -77.2803}, Lab{50, 0, -82.7485}, 2.8615},
+		{Lab{50, 2.8361, -74.0200}, Lab{50, 0, -82.7485}, 3.4412},
+		{Lab{50, 0, 0}, Lab{50, -1, 2}, 2.3669},
+		{Lab{50, 2.49, -0.001}, Lab{50, -2.49, 0.0009}, 7.1792},
+		{Lab{50, 2.5, 0}, Lab{73, 25, -18}, 27.1492},
+		{Lab{50, 2.5, 0}, Lab{50, 3.1736, 0.5854}, 1.0000},
+		{Lab{60.2574, -34.0099, 36.2677}, Lab{60.4626, -34.1751, 39.4387}, 1.2644},
+		{Lab{90.8027, -2.0831, 1.4410}, Lab{91.1528, -1.6435, 0.0447}, 1.4441},
+		{Lab{2.0776, 0.0795, -1.1350}, Lab{0.9033, -0.0636, -0.5514}, 0.9082},
+	}
+	for _, tt := range tests {
+		if got := CIEDE2000(tt.c1, tt.c2); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("CIEDE2000(%v, %v) = %.4f, want %.4f", tt.c1, tt.c2, got, tt.want)
+		}
+		if got := CIEDE2000(tt.c2, tt.c1); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("CIEDE2000(%v, %v) = %.4f, want %.4f (swapped)", tt.c2, tt.c1, got, tt.want)
+		}
+	}
+}
+
+func TestDeltaE(t *testing.T) {
+	tests := []struct {
+		hex1, hex2 string
+		min, max   float64
+		wantErr    bool
+	}{
+		{"#FFFFFF", "#ffffff", 0, 0, false},
+		{"#000000", "#FFFFFF", 99, 101, false},
+		{"#E91E8C", "#D90074", 5, 7, false}, // Delhi Pink and Magenta lines
+		{"#FFF", "#FFFFFF", 0, 0, true},
+		{"#GGGGGG", "#FFFFFF", 0, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := DeltaE(tt.hex1, tt.hex2)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("DeltaE(%s, %s) error = %v, want error %v", tt.hex1, tt.hex2, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (got < tt.min || got > tt.max) {
+			t.Errorf("DeltaE(%s, %s) = %.2f, want %.0f-%.0f", tt.hex1, tt.hex2, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestParseHex(t *testing.T) {
+	tests := []struct {
+		hex  string
+		want RGB
+	}{
+		{"#0066B3", RGB{0x00, 0x66, 0xB3}},
+		{"  #ffcc00 ", RGB{0xFF, 0xCC, 0x00}},
+		{"E21B28", RGB{0xE2, 0x1B, 0x28}},
+	}
+	for _, tt := range tests {
+		got, err := ParseHex(tt.hex)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseHex(%q) = %v, %v, want %v", tt.hex, got, err, tt.want)
+		}
+		if again, _ := ParseHex(got.Hex()); again != got {
+			t.Errorf("ParseHex(%q) does not round-trip through Hex: %v", got.Hex(), again)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package colors
+
+import (
+	"math"
+)
+
+// RelativeLuminance returns the WCAG 2.x relative luminance of the colour
+func (c RGB) RelativeLuminance() float64 {
+	return 0.2126*linearize(c.R) + 0.7152*linearize(c.G) + 0.0722*linearize(c.B)
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colours (1 to 21)
+func ContrastRatio(c1, c2 RGB) float64 {
+	l1, l2 := c1.RelativeLuminance(), c2.RelativeLuminance()
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// ContrastRatioHex returns the WCAG contrast ratio between two #RRGGBB colours
+func ContrastRatioHex(hex1, hex2 string) (float64, error) {
+	c1, err := ParseHex(hex1)
+	if err != nil {
+		return 0, err
+	}
+	c2, err := ParseHex(hex2)
+	if err != nil {
+		return 0, err
+	}
+	return ContrastRatio(c1, c2), nil
+}
+
+// Vision is a type of colour-vision deficiency to simulate
+type Vision string
+
+const (
+	Protanopia   Vision = "protanopia"
+	Deuteranopia Vision = "deuteranopia"
+	Tritanopia   Vision = "tritanopia"
+)
+
+// cvdMatrices are linear-RGB simulation matrices for full dichromacy
+// (Machado, Oliveira & Fernandes 2009, severity 1.0)
+var cvdMatrices = map[Vision][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// Simulate returns how the colour appears to someone with the given deficiency
+func (c RGB) Simulate(v Vision) RGB {
+	m, ok := cvdMatrices[v]
+	if !ok {
+		return c
+	}
+	r, g, b := linearize(c.R), linearize(c.G), linearize(c.B)
+	return RGB{
+		R: delinearize(m[0][0]*r + m[0][1]*g + m[0][2]*b),
+		G: delinearize(m[1][0]*r + m[1][1]*g + m[1][2]*b),
+		B: delinearize(m[2][0]*r + m[2][1]*g + m[2][2]*b),
+	}
+}
+
+// delinearize converts linear light back to an 8-bit sRGB channel
+func delinearize(v float64) uint8 {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		v *= 12.92
+	} else {
+		v = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return uint8(math.Round(v * 255))
+}
+
+// RGB converts a Lab colour back to sRGB, clamping out-of-gamut values
+func (l Lab) RGB() RGB {
+	fy := (l.L + 16) / 116
+	fx := fy + l.A/500
+	fz := fy - l.B/200
+
+	finv := func(t float64) float64 {
+		if t*t*t > 216.0/24389.0 {
+			return t * t * t
+		}
+		return (116*t - 16) / (24389.0 / 27.0)
+	}
+	x := finv(fx) * 0.95047
+	y := finv(fy) * 1.00000
+	z := finv(fz) * 1.08883
+
+	return RGB{
+		R: delinearize(3.2404542*x - 1.5371385*y - 0.4985314*z),
+		G: delinearize(-0.9692660*x + 1.8760108*y + 0.0415560*z),
+		B: delinearize(0.0556434*x - 0.2040259*y + 1.0572252*z),
+	}
+}
+
+// NearestCompliant searches for the perceptually closest colour that satisfies
+// ok by walking lightness (and, failing that, chroma) in Lab space.
+// It returns false if no such colour exists.
+func NearestCompliant(c RGB, ok func(RGB) bool) (RGB, bool) {
+	if ok(c) {
+		return c, true
+	}
+
+	origin := c.Lab()
+	var best RGB
+	bestDist := math.Inf(1)
+
+	for chroma := 1.0; chroma >= 0; chroma -= 0.1 {
+		for dl := 0.5; dl <= 100; dl += 0.5 {
+			for _, sign := range []float64{-1, 1} {
+				l := origin.L + sign*dl
+				if l < 0 || l > 100 {
+					continue
+				}
+				candidate := Lab{L: l, A: origin.A * chroma, B: origin.B * chroma}.RGB()
+				if !ok(candidate) {
+					continue
+				}
+				if d := CIEDE2000(origin, candidate.Lab()); d < bestDist {
+					best, bestDist = candidate, d
+				}
+			}
+			// Further lightness steps only move away from the original
+			if !math.IsInf(bestDist, 1) {
+				break
+			}
+		}
+		if !math.IsInf(bestDist, 1) {
+			return best, true
+		}
+	}
+
+	return RGB{}, false
+}
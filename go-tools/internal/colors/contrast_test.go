@@ -0,0 +1,98 @@
+package colors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		hex1, hex2 string
+		want       float64
+	}{
+		{"#000000", "#FFFFFF", 21},
+		{"#FFFFFF", "#000000", 21},
+		{"#0066B3", "#0066B3", 1},
+		{"#767676", "#FFFFFF", 4.54}, // the darkest grey that passes AA text on white
+		{"#777777", "#FFFFFF", 4.48},
+		{"#FFCC00", "#F2EFE9", 1.32},
+	}
+	for _, tt := range tests {
+		got, err := ContrastRatioHex(tt.hex1, tt.hex2)
+		if err != nil {
+			t.Fatalf("ContrastRatioHex(%s, %s): %v", tt.hex1, tt.hex2, err)
+		}
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("ContrastRatioHex(%s, %s) = %.2f, want %.2f", tt.hex1, tt.hex2, got, tt.want)
+		}
+	}
+	if _, err := ContrastRatioHex("#12345", "#FFFFFF"); err == nil {
+		t.Error("ContrastRatioHex accepted a malformed colour")
+	}
+}
+
+func TestLabRoundTrip(t *testing.T) {
+	for _, hex := range []string{"#000000", "#FFFFFF", "#E21B28", "#00A550", "#0066B3", "#FFCC00", "#991484"} {
+		c, _ := ParseHex(hex)
+		if got := c.Lab().RGB(); got != c {
+			t.Errorf("%s converted to Lab and back is %s", hex, got.Hex())
+		}
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	grey := RGB{128, 128, 128}
+	for _, v := range []Vision{Protanopia, Deuteranopia, Tritanopia} {
+		// Greys carry no hue, so every deficiency sees them unchanged
+		if got := grey.Simulate(v); CIEDE2000(got.Lab(), grey.Lab()) > 1 {
+			t.Errorf("%s changes grey to %s", v, got.Hex())
+		}
+	}
+	red, green := RGB{0xE2, 0x1B, 0x28}, RGB{0x00, 0xA5, 0x50}
+	normal := CIEDE2000(red.Lab(), green.Lab())
+	deutan := CIEDE2000(red.Simulate(Deuteranopia).Lab(), green.Simulate(Deuteranopia).Lab())
+	if deutan >= normal/2 {
+		t.Errorf("deuteranopia should make red and green much closer: ΔE %.1f, normally %.1f", deutan, normal)
+	}
+	if got := red.Simulate("none"); got != red {
+		t.Errorf("unknown vision changed the colour to %s", got.Hex())
+	}
+}
+
+func TestNearestCompliant(t *testing.T) {
+	white, _ := ParseHex("#FFFFFF")
+	light, _ := ParseHex("#F2EFE9")
+	dark, _ := ParseHex("#242424")
+	all := func(c RGB) bool {
+		return ContrastRatio(c, white) >= 4.5 && ContrastRatio(c, light) >= 3 && ContrastRatio(c, dark) >= 3
+	}
+
+	tests := []struct {
+		name string
+		hex  string
+		ok   func(RGB) bool
+	}{
+		{"too light for white text", "#FFCC00", func(c RGB) bool { return ContrastRatio(c, white) >= 4.5 }},
+		{"too dark for a dark map", "#0066B3", func(c RGB) bool { return ContrastRatio(c, dark) >= 3 }},
+		{"every check at once", "#00A550", all},
+		{"already compliant", "#0066B3", func(RGB) bool { return true }},
+	}
+	for _, tt := range tests {
+		c, _ := ParseHex(tt.hex)
+		got, found := NearestCompliant(c, tt.ok)
+		if !found {
+			t.Errorf("%s: no compliant colour for %s", tt.name, tt.hex)
+			continue
+		}
+		if !tt.ok(got) {
+			t.Errorf("%s: suggestion %s for %s is not compliant", tt.name, got.Hex(), tt.hex)
+		}
+		if tt.ok(c) && got != c {
+			t.Errorf("%s: compliant %s was changed to %s", tt.name, tt.hex, got.Hex())
+		}
+	}
+
+	if _, found := NearestCompliant(white, func(RGB) bool { return false }); found {
+		t.Error("NearestCompliant found a colour for an impossible requirement")
+	}
+}
@@ -0,0 +1,343 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RequiredColumns lists the tables and columns read by the loaders
+var RequiredColumns = map[string][]string{
+	"cities":              {"id", "name", "display_name", "country", "timezone", "map_center", "is_active"},
+	"metro_lines":         {"id", "city_id", "name", "color", "display_order"},
+	"metro_stations":      {"id", "city_id", "name", "latitude", "longitude", "is_interchange"},
+	"line_stations":       {"id", "line_id", "station_id", "sequence_number", "direction"},
+	"station_connections": {"id", "from_station_id", "to_station_id", "line_id", "travel_time_seconds", "stop_time_seconds"},
+	"train_schedules": {"id", "line_id", "direction", "start_station_id", "end_station_id",
+		"first_train_time", "last_train_time", "peak_frequency_minutes", "off_peak_frequency_minutes"},
+	"peak_hours": {"id", "schedule_id", "start_time", "end_time"},
+}
+
+// requiredTableOrder is the order tables are reported in
+var requiredTableOrder = []string{
+	"cities", "metro_lines", "metro_stations", "line_stations", "station_connections", "train_schedules", "peak_hours",
+}
+
+// TableExists reports whether a table exists in the database
+func (db *DB) TableExists(table string) (bool, error) {
+	var count int
+	err := db.conn.QueryRow(
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to query sqlite_master: %w", err)
+	}
+	return count > 0, nil
+}
+
+// TableColumns returns the column names of a table
+func (db *DB) TableColumns(table string) ([]string, error) {
+	info, err := db.TableInfo(table)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(info))
+	for i, c := range info {
+		columns[i] = c.Name
+	}
+	return columns, nil
+}
+
+// SchemaProblem is a required table or column that does not exist
+type SchemaProblem struct {
+	Table  string
+	Column string // empty when the whole table is missing
+}
+
+func (p SchemaProblem) String() string {
+	if p.Column == "" {
+		return fmt.Sprintf("table '%s' is missing", p.Table)
+	}
+	return fmt.Sprintf("column '%s.%s' is missing", p.Table, p.Column)
+}
+
+// RequiredTables returns the tables read by the loaders in a stable order
+func RequiredTables() []string {
+	return append([]string{}, requiredTableOrder...)
+}
+
+// MissingSchema returns every required table or column that does not exist
+func (db *DB) MissingSchema() ([]SchemaProblem, error) {
+	var missing []SchemaProblem
+	for _, table := range requiredTableOrder {
+		exists, err := db.TableExists(table)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			missing = append(missing, SchemaProblem{Table: table})
+			continue
+		}
+
+		columns, err := db.TableColumns(table)
+		if err != nil {
+			return nil, err
+		}
+		have := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			have[c] = true
+		}
+		for _, c := range RequiredColumns[table] {
+			if !have[c] {
+				missing = append(missing, SchemaProblem{Table: table, Column: c})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// ColumnInfo is a column as reported by PRAGMA table_info
+type ColumnInfo struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	PrimaryKey bool
+}
+
+// ForeignKeyInfo is a constraint as reported by PRAGMA foreign_key_list
+type ForeignKeyInfo struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// IndexInfo is an index as reported by PRAGMA index_list and index_info
+type IndexInfo struct {
+	Name    string
+	Unique  bool
+	Origin  string // "c" for CREATE INDEX, "u" for UNIQUE, "pk" for primary key
+	Columns []string
+}
+
+// ListTables returns the user tables in the database
+func (db *DB) ListTables() ([]string, error) {
+	rows, err := db.conn.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sqlite_master: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// TableInfo returns the full column definitions of a table
+func (db *DB) TableInfo(table string) ([]ColumnInfo, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%q)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var cid, notNull, pk int
+		var c ColumnInfo
+		var defaultValue interface{}
+		if err := rows.Scan(&cid, &c.Name, &c.Type, &notNull, &defaultValue, &pk); err != nil {
+			return nil, fmt.Errorf("failed to scan column of %s: %w", table, err)
+		}
+		c.NotNull = notNull == 1
+		c.PrimaryKey = pk > 0
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// ForeignKeys returns the foreign key constraints declared on a table
+func (db *DB) ForeignKeys(table string) ([]ForeignKeyInfo, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA foreign_key_list(%q)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var fks []ForeignKeyInfo
+	for rows.Next() {
+		var id, seq int
+		var fk ForeignKeyInfo
+		var refColumn sql.NullString
+		var onUpdate, onDelete, match string
+		if err := rows.Scan(&id, &seq, &fk.RefTable, &fk.Column, &refColumn, &onUpdate, &onDelete, &match); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key of %s: %w", table, err)
+		}
+		// A NULL target column means the referenced table's primary key
+		fk.RefColumn = refColumn.String
+		if !refColumn.Valid {
+			fk.RefColumn = "id"
+		}
+		fks = append(fks, fk)
+	}
+	return fks, rows.Err()
+}
+
+// Indexes returns the indexes on a table with their columns in order
+func (db *DB) Indexes(table string) ([]IndexInfo, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA index_list(%q)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes of %s: %w", table, err)
+	}
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var seq, unique, partial int
+		var idx IndexInfo
+		if err := rows.Scan(&seq, &idx.Name, &unique, &idx.Origin, &partial); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan index of %s: %w", table, err)
+		}
+		idx.Unique = unique == 1
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		cols, err := db.conn.Query(fmt.Sprintf("PRAGMA index_info(%q)", indexes[i].Name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index %s: %w", indexes[i].Name, err)
+		}
+		for cols.Next() {
+			var seqno, cid int
+			var name sql.NullString
+			if err := cols.Scan(&seqno, &cid, &name); err != nil {
+				cols.Close()
+				return nil, fmt.Errorf("failed to scan index %s: %w", indexes[i].Name, err)
+			}
+			indexes[i].Columns = append(indexes[i].Columns, name.String)
+		}
+		cols.Close()
+	}
+	return indexes, nil
+}
+
+// ForeignKeyViolation is an orphaned row reported by PRAGMA foreign_key_check
+type ForeignKeyViolation struct {
+	Table    string
+	RowID    int64
+	RowKey   string // the row's id column, when it has one
+	Parent   string
+	Column   string
+	ParentID string
+}
+
+// ForeignKeyCheck runs PRAGMA foreign_key_check over the whole database
+func (db *DB) ForeignKeyCheck() ([]ForeignKeyViolation, error) {
+	rows, err := db.conn.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign_key_check: %w", err)
+	}
+
+	type rawViolation struct {
+		table  string
+		rowID  sql.NullInt64
+		parent string
+		fkID   int
+	}
+	var raw []rawViolation
+	for rows.Next() {
+		var v rawViolation
+		if err := rows.Scan(&v.table, &v.rowID, &v.parent, &v.fkID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan foreign_key_check: %w", err)
+		}
+		raw = append(raw, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Resolve the constrained column of each fkid, and whether the table has
+	// an id column, once per table
+	fkColumns := make(map[string]map[int]string)
+	hasID := make(map[string]bool)
+	violations := make([]ForeignKeyViolation, 0, len(raw))
+	for _, v := range raw {
+		if _, ok := fkColumns[v.table]; !ok {
+			fkColumns[v.table], err = db.foreignKeyColumns(v.table)
+			if err != nil {
+				return nil, err
+			}
+			columns, err := db.TableInfo(v.table)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range columns {
+				if c.Name == "id" {
+					hasID[v.table] = true
+				}
+			}
+		}
+
+		fv := ForeignKeyViolation{
+			Table:  v.table,
+			RowID:  v.rowID.Int64,
+			Parent: v.parent,
+			Column: fkColumns[v.table][v.fkID],
+		}
+		if v.rowID.Valid {
+			var key, parentID sql.NullString
+			if hasID[v.table] {
+				err := db.conn.QueryRow(fmt.Sprintf("SELECT CAST(id AS TEXT) FROM %q WHERE rowid = ?", v.table), v.rowID.Int64).Scan(&key)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read id of orphaned %s row %d: %w", v.table, v.rowID.Int64, err)
+				}
+			}
+			if fv.Column != "" {
+				err := db.conn.QueryRow(fmt.Sprintf("SELECT CAST(%q AS TEXT) FROM %q WHERE rowid = ?", fv.Column, v.table), v.rowID.Int64).Scan(&parentID)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read %s of orphaned %s row %d: %w", fv.Column, v.table, v.rowID.Int64, err)
+				}
+			}
+			fv.RowKey = key.String
+			fv.ParentID = parentID.String
+		}
+		violations = append(violations, fv)
+	}
+	return violations, nil
+}
+
+// foreignKeyColumns maps each foreign key id of a table to its column
+func (db *DB) foreignKeyColumns(table string) (map[int]string, error) {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA foreign_key_list(%q)", table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[int]string)
+	for rows.Next() {
+		var id, seq int
+		var refTable, from string
+		var to sql.NullString
+		var onUpdate, onDelete, match string
+		if err := rows.Scan(&id, &seq, &refTable, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key of %s: %w", table, err)
+		}
+		columns[id] = from
+	}
+	return columns, rows.Err()
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -67,6 +68,98 @@ type StationConnection struct {
 	StopTimeSeconds   int
 }
 
+// InterchangeTransfer is the walk between platforms when changing lines at a station
+type InterchangeTransfer struct {
+	ID          int
+	StationID   string
+	FromLineID  string
+	ToLineID    string
+	WalkSeconds int
+}
+
+// StationGroup is a complex of separate stations joined by walkways
+type StationGroup struct {
+	ID          string
+	CityID      string
+	Name        string
+	WalkSeconds int      // walk between any two members
+	StationIDs  []string // members, ordered by station ID
+}
+
+// StationFacilities describes the accessibility and amenities of a station
+type StationFacilities struct {
+	StationID  string
+	StepFree   bool // street to platform without stairs
+	Lifts      int
+	Escalators int
+	Parking    bool
+	Toilets    bool
+	Exits      []StationExit
+}
+
+// StationExit is a numbered or named way out of a station
+type StationExit struct {
+	ID        int
+	StationID string
+	Name      string // e.g. "Gate 3"
+	Latitude  float64
+	Longitude float64
+}
+
+// StationName is a station's name in one language
+type StationName struct {
+	ID              int
+	StationID       string
+	Lang            string // ISO 639-1, e.g. "hi"
+	Name            string // in the language's own script
+	Transliteration string // Latin spelling, empty if not recorded
+}
+
+// CityLanguage is a language every station of a city must be named in, besides English
+type CityLanguage struct {
+	ID     int
+	CityID string
+	Lang   string // ISO 639-1, e.g. "hi"
+}
+
+type TrainSchedule struct {
+	ID                      int
+	LineID                  string
+	Direction               string
+	StartStationID          string
+	EndStationID            string
+	FirstTrainTime          string // "06:00:00"
+	LastTrainTime           string // "23:00:00"
+	PeakFrequencyMinutes    int
+	OffPeakFrequencyMinutes int
+}
+
+type PeakHour struct {
+	ID         int
+	ScheduleID int
+	StartTime  string // "07:00:00"
+	EndTime    string // "10:00:00"
+}
+
+type TrainSighting struct {
+	ID              int
+	LineID          string
+	StationID       string
+	Direction       string
+	Timestamp       time.Time
+	UserID          string   // empty for anonymous reports
+	UserLatitude    *float64 // nil when no GPS was provided
+	UserLongitude   *float64
+	ConfidenceScore float64
+	IsVerified      bool
+	CreatedAt       time.Time
+}
+
+// HasLocation reports whether the sighting included the reporter's GPS position
+func (s *TrainSighting) HasLocation() bool {
+	return s.UserLatitude != nil && s.UserLongitude != nil
+}
+
 // DB wraps the database connection
 type DB struct {
 	conn *sql.DB
@@ -253,6 +346,267 @@ func (db *DB) GetLinesPerStation() (map[string][]string, error) {
 	return linesMap, rows.Err()
 }
 
+// GetAllTrainSchedules retrieves all train schedules
+func (db *DB) GetAllTrainSchedules() ([]TrainSchedule, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, line_id, direction, start_station_id, end_station_id,
+		       first_train_time, last_train_time, peak_frequency_minutes, off_peak_frequency_minutes
+		FROM train_schedules
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query train_schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []TrainSchedule
+	for rows.Next() {
+		var ts TrainSchedule
+		if err := rows.Scan(&ts.ID, &ts.LineID, &ts.Direction, &ts.StartStationID, &ts.EndStationID,
+			&ts.FirstTrainTime, &ts.LastTrainTime, &ts.PeakFrequencyMinutes, &ts.OffPeakFrequencyMinutes); err != nil {
+			return nil, fmt.Errorf("failed to scan train_schedule: %w", err)
+		}
+		schedules = append(schedules, ts)
+	}
+	return schedules, rows.Err()
+}
+
+// GetAllPeakHours retrieves all peak hour windows
+func (db *DB) GetAllPeakHours() ([]PeakHour, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, schedule_id, start_time, end_time
+		FROM peak_hours
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query peak_hours: %w", err)
+	}
+	defer rows.Close()
+
+	var peakHours []PeakHour
+	for rows.Next() {
+		var ph PeakHour
+		if err := rows.Scan(&ph.ID, &ph.ScheduleID, &ph.StartTime, &ph.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to scan peak_hour: %w", err)
+		}
+		peakHours = append(peakHours, ph)
+	}
+	return peakHours, rows.Err()
+}
+
+// GetAllInterchangeTransfers retrieves the walking times between lines at interchanges
+func (db *DB) GetAllInterchangeTransfers() ([]InterchangeTransfer, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, station_id, from_line_id, to_line_id, walk_seconds
+		FROM interchange_transfers
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query interchange_transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []InterchangeTransfer
+	for rows.Next() {
+		var t InterchangeTransfer
+		if err := rows.Scan(&t.ID, &t.StationID, &t.FromLineID, &t.ToLineID, &t.WalkSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan interchange_transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+// GetAllStationGroups retrieves station complexes with their members
+func (db *DB) GetAllStationGroups() ([]StationGroup, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, city_id, name, walk_seconds
+		FROM station_groups
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station_groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []StationGroup
+	index := make(map[string]int)
+	for rows.Next() {
+		var g StationGroup
+		if err := rows.Scan(&g.ID, &g.CityID, &g.Name, &g.WalkSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan station_group: %w", err)
+		}
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	members, err := db.conn.Query(`
+		SELECT group_id, station_id
+		FROM station_group_members
+		ORDER BY group_id, station_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station_group_members: %w", err)
+	}
+	defer members.Close()
+
+	for members.Next() {
+		var groupID, stationID string
+		if err := members.Scan(&groupID, &stationID); err != nil {
+			return nil, fmt.Errorf("failed to scan station_group_member: %w", err)
+		}
+		if i, ok := index[groupID]; ok {
+			groups[i].StationIDs = append(groups[i].StationIDs, stationID)
+		}
+	}
+	return groups, members.Err()
+}
+
+// GetAllStationFacilities retrieves station facilities with their exits.
+// Stations with exits but no facilities row get an entry with no amenities.
+func (db *DB) GetAllStationFacilities() ([]StationFacilities, error) {
+	rows, err := db.conn.Query(`
+		SELECT station_id, step_free, lifts, escalators, parking, toilets
+		FROM station_facilities
+		ORDER BY station_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station_facilities: %w", err)
+	}
+	defer rows.Close()
+
+	var facilities []StationFacilities
+	index := make(map[string]int)
+	for rows.Next() {
+		var f StationFacilities
+		var stepFree, parking, toilets int
+		if err := rows.Scan(&f.StationID, &stepFree, &f.Lifts, &f.Escalators, &parking, &toilets); err != nil {
+			return nil, fmt.Errorf("failed to scan station_facilities: %w", err)
+		}
+		f.StepFree, f.Parking, f.Toilets = stepFree == 1, parking == 1, toilets == 1
+		index[f.StationID] = len(facilities)
+		facilities = append(facilities, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	exits, err := db.conn.Query(`
+		SELECT id, station_id, name, latitude, longitude
+		FROM station_exits
+		ORDER BY station_id, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station_exits: %w", err)
+	}
+	defer exits.Close()
+
+	for exits.Next() {
+		var e StationExit
+		if err := exits.Scan(&e.ID, &e.StationID, &e.Name, &e.Latitude, &e.Longitude); err != nil {
+			return nil, fmt.Errorf("failed to scan station_exit: %w", err)
+		}
+		i, ok := index[e.StationID]
+		if !ok {
+			i = len(facilities)
+			index[e.StationID] = i
+			facilities = append(facilities, StationFacilities{StationID: e.StationID})
+		}
+		facilities[i].Exits = append(facilities[i].Exits, e)
+	}
+	return facilities, exits.Err()
+}
+
+// GetAllStationNames retrieves the names of stations in other languages
+func (db *DB) GetAllStationNames() ([]StationName, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, station_id, lang, name, transliteration
+		FROM station_names
+		ORDER BY station_id, lang
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query station_names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []StationName
+	for rows.Next() {
+		var n StationName
+		var transliteration sql.NullString
+		if err := rows.Scan(&n.ID, &n.StationID, &n.Lang, &n.Name, &transliteration); err != nil {
+			return nil, fmt.Errorf("failed to scan station_name: %w", err)
+		}
+		n.Transliteration = transliteration.String
+		names = append(names, n)
+	}
+	return names, rows.Err()
+}
+
+// GetAllCityLanguages retrieves the configured languages of every city, in configured order
+func (db *DB) GetAllCityLanguages() ([]CityLanguage, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, city_id, lang
+		FROM city_languages
+		ORDER BY city_id, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query city_languages: %w", err)
+	}
+	defer rows.Close()
+
+	var languages []CityLanguage
+	for rows.Next() {
+		var l CityLanguage
+		if err := rows.Scan(&l.ID, &l.CityID, &l.Lang); err != nil {
+			return nil, fmt.Errorf("failed to scan city_language: %w", err)
+		}
+		languages = append(languages, l)
+	}
+	return languages, rows.Err()
+}
+
+// GetAllTrainSightings retrieves all crowdsourced train sightings, oldest first
+func (db *DB) GetAllTrainSightings() ([]TrainSighting, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, line_id, station_id, direction, timestamp, user_id,
+		       user_latitude, user_longitude, confidence_score, is_verified, created_at
+		FROM train_sightings
+		ORDER BY timestamp, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query train_sightings: %w", err)
+	}
+	defer rows.Close()
+
+	var sightings []TrainSighting
+	for rows.Next() {
+		var s TrainSighting
+		var timestamp, createdAt int64
+		var userID sql.NullString
+		var lat, lng, confidence sql.NullFloat64
+		var isVerified sql.NullInt64
+		if err := rows.Scan(&s.ID, &s.LineID, &s.StationID, &s.Direction, &timestamp, &userID,
+			&lat, &lng, &confidence, &isVerified, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan train_sighting: %w", err)
+		}
+		// Drizzle stores timestamp-mode integers as Unix seconds
+		s.Timestamp = time.Unix(timestamp, 0).UTC()
+		s.CreatedAt = time.Unix(createdAt, 0).UTC()
+		s.UserID = userID.String
+		if lat.Valid && lng.Valid {
+			s.UserLatitude = &lat.Float64
+			s.UserLongitude = &lng.Float64
+		}
+		s.ConfidenceScore = 1.0
+		if confidence.Valid {
+			s.ConfidenceScore = confidence.Float64
+		}
+		s.IsVerified = isVerified.Int64 == 1
+		sightings = append(sightings, s)
+	}
+	return sightings, rows.Err()
+}
+
 // Stats holds database statistics
 type Stats struct {
 	Cities      int
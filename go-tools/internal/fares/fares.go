@@ -0,0 +1,245 @@
+package fares
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/validators"
+)
+
+// Fare models
+const (
+	ModelFlat     = "flat"     // one fare for any journey
+	ModelDistance = "distance" // slabs by kilometres travelled
+	ModelZone     = "zone"     // slabs by concentric zone rings crossed
+)
+
+// Slab is one step of a fare table. UpTo is the upper bound in kilometres
+// (distance model) or zones (zone model); 0 means no upper bound.
+type Slab struct {
+	UpTo float64 `json:"upTo,omitempty"`
+	Fare float64 `json:"fare"`
+}
+
+// CityFares is the fare model of one city
+type CityFares struct {
+	Currency string  `json:"currency"`
+	Model    string  `json:"model"`
+	Flat     float64 `json:"flatFare,omitempty"`
+	Slabs    []Slab  `json:"slabs,omitempty"`
+	// RingKm is the width of each zone ring around the city's map center
+	RingKm float64 `json:"ringKm,omitempty"`
+}
+
+// Config is the contents of a fares file, keyed by city ID
+type Config struct {
+	Cities map[string]*CityFares `json:"cities"`
+}
+
+// Default are the published distance slabs of the two networks with data
+var Default = &Config{Cities: map[string]*CityFares{
+	"delhi": {
+		Currency: "INR",
+		Model:    ModelDistance,
+		Slabs: []Slab{
+			{UpTo: 2, Fare: 11}, {UpTo: 5, Fare: 21}, {UpTo: 12, Fare: 32},
+			{UpTo: 21, Fare: 43}, {UpTo: 32, Fare: 54}, {Fare: 64},
+		},
+	},
+	"bangalore": {
+		Currency: "INR",
+		Model:    ModelDistance,
+		Slabs: []Slab{
+			{UpTo: 2, Fare: 10}, {UpTo: 4, Fare: 20}, {UpTo: 6, Fare: 30},
+			{UpTo: 8, Fare: 40}, {UpTo: 10, Fare: 50}, {UpTo: 15, Fare: 60},
+			{UpTo: 20, Fare: 70}, {UpTo: 25, Fare: 80}, {Fare: 90},
+		},
+	},
+}}
+
+// Load reads and checks a JSON fares file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fares file: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid fares file: %w", err)
+	}
+	if err := cfg.Check(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Check reports the first inconsistent city model
+func (c *Config) Check() error {
+	cities := make([]string, 0, len(c.Cities))
+	for id := range c.Cities {
+		cities = append(cities, id)
+	}
+	sort.Strings(cities)
+
+	for _, id := range cities {
+		f := c.Cities[id]
+		if f == nil {
+			return fmt.Errorf("city '%s': fare model is null", id)
+		}
+		switch f.Model {
+		case ModelFlat:
+			if f.Flat <= 0 {
+				return fmt.Errorf("city '%s': flatFare must be positive", id)
+			}
+			continue
+		case ModelDistance:
+		case ModelZone:
+			if f.RingKm <= 0 {
+				return fmt.Errorf("city '%s': zone model needs a positive ringKm", id)
+			}
+		default:
+			return fmt.Errorf("city '%s': unknown fare model '%s'", id, f.Model)
+		}
+
+		if len(f.Slabs) == 0 {
+			return fmt.Errorf("city '%s': %s model needs slabs", id, f.Model)
+		}
+		for i, s := range f.Slabs {
+			if s.Fare < 0 {
+				return fmt.Errorf("city '%s': slab #%d has a negative fare", id, i+1)
+			}
+			last := i == len(f.Slabs)-1
+			if s.UpTo == 0 && !last {
+				return fmt.Errorf("city '%s': only the last slab may be unbounded", id)
+			}
+			if i > 0 && s.UpTo != 0 && s.UpTo <= f.Slabs[i-1].UpTo {
+				return fmt.Errorf("city '%s': slab upper bounds must increase", id)
+			}
+		}
+	}
+	return nil
+}
+
+// Quote is the fare of one journey with how it was worked out
+type Quote struct {
+	CityID     string  `json:"cityId"`
+	Currency   string  `json:"currency"`
+	Model      string  `json:"model"`
+	Fare       float64 `json:"fare"`
+	DistanceKm float64 `json:"distanceKm"` // straight-line hops between stations, summed
+	// Zones is the number of zone rings the journey spans (zone model only)
+	Zones int `json:"zones,omitempty"`
+	// Slab is the index of the slab the journey falls in (distance and zone models)
+	Slab int `json:"slab,omitempty"`
+}
+
+// Calculator prices journeys given as a sequence of stations
+type Calculator struct {
+	config   *Config
+	stations map[string]database.MetroStation
+	centers  map[string]*database.MapCenter
+}
+
+// NewCalculator indexes the stations and city centres a fare config needs
+func NewCalculator(cfg *Config, cities []database.City, stations []database.MetroStation) *Calculator {
+	c := &Calculator{
+		config:   cfg,
+		stations: make(map[string]database.MetroStation),
+		centers:  make(map[string]*database.MapCenter),
+	}
+	for _, city := range cities {
+		if mc, err := city.ParseMapCenter(); err == nil {
+			c.centers[city.ID] = mc
+		}
+	}
+	for _, st := range stations {
+		c.stations[st.ID] = st
+	}
+	return c
+}
+
+// Has reports whether a city has a fare model
+func (c *Calculator) Has(cityID string) bool {
+	_, ok := c.config.Cities[cityID]
+	return ok
+}
+
+// Quote prices a journey through the given stations, in travel order.
+// Distance slabs apply to the straight-line distance between each pair of
+// consecutive stations, summed.
+func (c *Calculator) Quote(route []string) (*Quote, error) {
+	if len(route) < 2 {
+		return nil, fmt.Errorf("a journey needs at least two stations")
+	}
+	first, ok := c.stations[route[0]]
+	if !ok {
+		return nil, fmt.Errorf("station '%s' not found", route[0])
+	}
+	f, ok := c.config.Cities[first.CityID]
+	if !ok {
+		return nil, fmt.Errorf("no fare model for city '%s'", first.CityID)
+	}
+
+	q := &Quote{CityID: first.CityID, Currency: f.Currency, Model: f.Model}
+	minZone, maxZone := math.MaxInt32, 0
+	for i, id := range route {
+		st, ok := c.stations[id]
+		if !ok {
+			return nil, fmt.Errorf("station '%s' not found", id)
+		}
+		if i > 0 {
+			prev := c.stations[route[i-1]]
+			q.DistanceKm += validators.HaversineDistance(prev.Latitude, prev.Longitude, st.Latitude, st.Longitude)
+		}
+		if f.Model == ModelZone {
+			zone, err := c.zone(st, f.RingKm)
+			if err != nil {
+				return nil, err
+			}
+			if zone < minZone {
+				minZone = zone
+			}
+			if zone > maxZone {
+				maxZone = zone
+			}
+		}
+	}
+	q.DistanceKm = math.Round(q.DistanceKm*100) / 100
+
+	switch f.Model {
+	case ModelFlat:
+		q.Fare = f.Flat
+	case ModelDistance:
+		q.Slab, q.Fare = lookup(f.Slabs, q.DistanceKm)
+	case ModelZone:
+		q.Zones = maxZone - minZone + 1
+		q.Slab, q.Fare = lookup(f.Slabs, float64(q.Zones))
+	}
+	return q, nil
+}
+
+// zone numbers rings around the city centre from 1
+func (c *Calculator) zone(st database.MetroStation, ringKm float64) (int, error) {
+	center, ok := c.centers[st.CityID]
+	if !ok {
+		return 0, fmt.Errorf("city '%s' has no map center for zone fares", st.CityID)
+	}
+	km := validators.HaversineDistance(center.Lat, center.Lng, st.Latitude, st.Longitude)
+	return int(km/ringKm) + 1, nil
+}
+
+// lookup returns the first slab covering value, numbered from 1
+func lookup(slabs []Slab, value float64) (int, float64) {
+	for i, s := range slabs {
+		if s.UpTo == 0 || value <= s.UpTo {
+			return i + 1, s.Fare
+		}
+	}
+	last := len(slabs) - 1
+	return last + 1, slabs[last].Fare
+}
@@ -0,0 +1,166 @@
+package fares
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/validators"
+)
+
+// north places a station km kilometres due north of the city centre
+func north(id, cityID string, km float64) database.MetroStation {
+	perDegree := validators.HaversineDistance(0, 0, 1, 0)
+	return database.MetroStation{ID: id, CityID: cityID, Latitude: 28.61 + km/perDegree, Longitude: 77.2}
+}
+
+func testCalculator(cfg *Config) *Calculator {
+	cities := []database.City{
+		{ID: "delhi", MapCenter: `{"lat":28.61,"lng":77.2}`},
+		{ID: "flat", MapCenter: `{"lat":28.61,"lng":77.2}`},
+		{ID: "zone", MapCenter: `{"lat":28.61,"lng":77.2}`},
+		{ID: "nocenter", MapCenter: `not json`},
+	}
+	var stations []database.MetroStation
+	for _, km := range []float64{0, 1, 2, 2.01, 5, 7, 12, 40} {
+		for _, city := range []string{"delhi", "flat", "zone", "nocenter"} {
+			stations = append(stations, north(fmt.Sprintf("%s-%g", city, km), city, km))
+		}
+	}
+	return NewCalculator(cfg, cities, stations)
+}
+
+var testConfig = &Config{Cities: map[string]*CityFares{
+	"delhi": Default.Cities["delhi"],
+	"flat":  {Currency: "INR", Model: ModelFlat, Flat: 25},
+	"zone": {Currency: "INR", Model: ModelZone, RingKm: 5,
+		Slabs: []Slab{{UpTo: 1, Fare: 10}, {UpTo: 2, Fare: 20}, {Fare: 30}}},
+	"nocenter": {Currency: "INR", Model: ModelZone, RingKm: 5, Slabs: []Slab{{Fare: 10}}},
+}}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		route []string
+		fare  float64
+		slab  int
+		zones int
+		km    float64
+	}{
+		{"distance: exactly 2 km", []string{"delhi-0", "delhi-2"}, 11, 1, 0, 2},
+		{"distance: just over 2 km", []string{"delhi-0", "delhi-2.01"}, 21, 2, 0, 2.01},
+		{"distance: hops are summed", []string{"delhi-0", "delhi-1", "delhi-2"}, 11, 1, 0, 2},
+		{"distance: exactly 5 km", []string{"delhi-5", "delhi-0"}, 21, 2, 0, 5},
+		{"distance: unbounded last slab", []string{"delhi-0", "delhi-40"}, 64, 6, 0, 40},
+		{"flat", []string{"flat-0", "flat-40"}, 25, 0, 0, 40},
+		{"zone: within the first ring", []string{"zone-0", "zone-2"}, 10, 1, 1, 2},
+		{"zone: two rings", []string{"zone-2", "zone-7"}, 20, 2, 2, 5},
+		{"zone: rings between the ends count", []string{"zone-1", "zone-12", "zone-7"}, 30, 3, 3, 16},
+		{"zone: unbounded last slab", []string{"zone-0", "zone-40"}, 30, 3, 9, 40},
+	}
+	calc := testCalculator(testConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := calc.Quote(tt.route)
+			if err != nil {
+				t.Fatalf("Quote: %v", err)
+			}
+			if q.Fare != tt.fare || q.Slab != tt.slab || q.Zones != tt.zones || q.DistanceKm != tt.km {
+				t.Errorf("quote = fare %v, slab %d, %d zones, %v km; want %v, %d, %d, %v km",
+					q.Fare, q.Slab, q.Zones, q.DistanceKm, tt.fare, tt.slab, tt.zones, tt.km)
+			}
+		})
+	}
+}
+
+func TestQuoteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		route []string
+		err   string
+	}{
+		{"one station", []string{"delhi-0"}, "at least two stations"},
+		{"unknown origin", []string{"nowhere", "delhi-0"}, "station 'nowhere' not found"},
+		{"unknown stop", []string{"delhi-0", "nowhere"}, "station 'nowhere' not found"},
+		{"no map center", []string{"nocenter-0", "nocenter-5"}, "no map center"},
+	}
+	calc := testCalculator(testConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := calc.Quote(tt.route); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Quote error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+
+	// A city without a model
+	calc = testCalculator(&Config{Cities: map[string]*CityFares{}})
+	if calc.Has("delhi") {
+		t.Error("Has(delhi) with an empty config")
+	}
+	if _, err := calc.Quote([]string{"delhi-0", "delhi-5"}); err == nil || !strings.Contains(err.Error(), "no fare model") {
+		t.Errorf("Quote error = %v, want no fare model", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		city *CityFares
+		err  string // empty if valid
+	}{
+		{"default delhi", Default.Cities["delhi"], ""},
+		{"default bangalore", Default.Cities["bangalore"], ""},
+		{"null", nil, "fare model is null"},
+		{"unknown model", &CityFares{Model: "time"}, "unknown fare model 'time'"},
+		{"flat without a fare", &CityFares{Model: ModelFlat}, "flatFare must be positive"},
+		{"zone without rings", &CityFares{Model: ModelZone, Slabs: []Slab{{Fare: 10}}}, "positive ringKm"},
+		{"distance without slabs", &CityFares{Model: ModelDistance}, "needs slabs"},
+		{"negative fare", &CityFares{Model: ModelDistance, Slabs: []Slab{{Fare: -1}}}, "negative fare"},
+		{"unbounded slab first", &CityFares{Model: ModelDistance, Slabs: []Slab{{Fare: 10}, {UpTo: 5, Fare: 20}}}, "only the last slab"},
+		{"bounds decrease", &CityFares{Model: ModelDistance, Slabs: []Slab{{UpTo: 5, Fare: 10}, {UpTo: 5, Fare: 20}}}, "must increase"},
+		{"bounded last slab", &CityFares{Model: ModelDistance, Slabs: []Slab{{UpTo: 5, Fare: 10}, {UpTo: 9, Fare: 20}}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&Config{Cities: map[string]*CityFares{"x": tt.city}}).Check()
+			if tt.err == "" && err != nil {
+				t.Errorf("Check = %v, want valid", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Check = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name, json, err string
+	}{
+		{"valid", `{"cities":{"delhi":{"currency":"INR","model":"flat","flatFare":30}}}`, ""},
+		{"null city", `{"cities":{"delhi":null}}`, "fare model is null"},
+		{"bad json", `{"cities":`, "invalid fares file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fares.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := Load(path)
+			if tt.err == "" && (err != nil || cfg.Cities["delhi"].Flat != 30) {
+				t.Errorf("Load = %+v, %v", cfg, err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load of a missing file succeeded")
+	}
+}
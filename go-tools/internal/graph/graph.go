@@ -0,0 +1,171 @@
+package graph
+
+import (
+	"container/heap"
+
+	"metro-tools/internal/database"
+)
+
+// Edge is a directed hop between adjacent stations on a line
+type Edge struct {
+	From   string
+	To     string
+	LineID string
+	Weight int // seconds: travel time plus dwell time
+	// Walk marks a walkway between stations of a complex; LineID is empty
+	Walk bool
+}
+
+// Graph is the metro network as an adjacency list
+type Graph struct {
+	adjacency map[string][]Edge
+}
+
+// New builds a graph from station connections. Like routeFinder.service.ts,
+// every connection is added in both directions with travel + stop time as weight.
+func New(connections []database.StationConnection) *Graph {
+	g := &Graph{adjacency: make(map[string][]Edge)}
+	for _, c := range connections {
+		weight := c.TravelTimeSeconds + c.StopTimeSeconds
+		g.AddEdge(Edge{From: c.FromStationID, To: c.ToStationID, LineID: c.LineID, Weight: weight})
+		g.AddEdge(Edge{From: c.ToStationID, To: c.FromStationID, LineID: c.LineID, Weight: weight})
+	}
+	return g
+}
+
+// AddWalkways joins every pair of stations in each complex in both directions
+func (g *Graph) AddWalkways(groups []database.StationGroup) {
+	for _, grp := range groups {
+		for _, a := range grp.StationIDs {
+			for _, b := range grp.StationIDs {
+				if a != b {
+					g.AddEdge(Edge{From: a, To: b, Weight: grp.WalkSeconds, Walk: true})
+				}
+			}
+		}
+	}
+}
+
+// AddEdge adds a directed edge
+func (g *Graph) AddEdge(e Edge) {
+	g.adjacency[e.From] = append(g.adjacency[e.From], e)
+	if _, ok := g.adjacency[e.To]; !ok {
+		g.adjacency[e.To] = nil
+	}
+}
+
+// Neighbors returns the edges leaving a station
+func (g *Graph) Neighbors(station string) []Edge {
+	return g.adjacency[station]
+}
+
+// HasStation reports whether the station is part of the graph
+func (g *Graph) HasStation(station string) bool {
+	_, ok := g.adjacency[station]
+	return ok
+}
+
+// Path is the result of a shortest-path search
+type Path struct {
+	Stations []string
+	Edges    []Edge
+	Seconds  int
+}
+
+// ShortestPath runs Dijkstra from one station to another
+func (g *Graph) ShortestPath(from, to string) (*Path, bool) {
+	if !g.HasStation(from) || !g.HasStation(to) {
+		return nil, false
+	}
+
+	dist, prev := g.dijkstra(from, to)
+	if _, ok := dist[to]; !ok {
+		return nil, false
+	}
+
+	return buildPath(from, to, dist[to], prev), true
+}
+
+// ShortestTimes returns the minimum travel time in seconds from a station to every reachable station
+func (g *Graph) ShortestTimes(from string) map[string]int {
+	dist, _ := g.dijkstra(from, "")
+	return dist
+}
+
+// PathsFrom returns the shortest path from a station to every reachable station
+func (g *Graph) PathsFrom(from string) map[string]*Path {
+	dist, prev := g.dijkstra(from, "")
+	paths := make(map[string]*Path, len(dist))
+	for to, seconds := range dist {
+		if to == from {
+			continue
+		}
+		paths[to] = buildPath(from, to, seconds, prev)
+	}
+	return paths
+}
+
+// buildPath follows the predecessor edges back from to
+func buildPath(from, to string, seconds int, prev map[string]Edge) *Path {
+	path := &Path{Seconds: seconds}
+	for at := to; at != from; {
+		e := prev[at]
+		path.Edges = append([]Edge{e}, path.Edges...)
+		at = e.From
+	}
+	path.Stations = append(path.Stations, from)
+	for _, e := range path.Edges {
+		path.Stations = append(path.Stations, e.To)
+	}
+	return path
+}
+
+// dijkstra computes distances from start, stopping early once target is settled
+func (g *Graph) dijkstra(start, target string) (map[string]int, map[string]Edge) {
+	dist := map[string]int{start: 0}
+	prev := make(map[string]Edge)
+	settled := make(map[string]bool)
+
+	pq := &queue{{station: start, priority: 0}}
+	for pq.Len() > 0 {
+		current := heap.Pop(pq).(item)
+		if settled[current.station] {
+			continue
+		}
+		settled[current.station] = true
+		if current.station == target {
+			break
+		}
+
+		for _, e := range g.adjacency[current.station] {
+			d := current.priority + e.Weight
+			if old, ok := dist[e.To]; !ok || d < old {
+				dist[e.To] = d
+				prev[e.To] = e
+				heap.Push(pq, item{station: e.To, priority: d})
+			}
+		}
+	}
+	return dist, prev
+}
+
+// item is a priority queue entry
+type item struct {
+	station  string
+	priority int
+}
+
+// queue is a min-heap of items ordered by priority
+type queue []item
+
+func (q queue) Len() int            { return len(q) }
+func (q queue) Less(i, j int) bool  { return q[i].priority < q[j].priority }
+func (q queue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(item)) }
+func (q *queue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	it := old[n-1]
+	*q = old[:n-1]
+	return it
+}
@@ -0,0 +1,300 @@
+package graph
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Criteria are the costs compared between route alternatives
+type Criteria struct {
+	Seconds     int `json:"totalSeconds"` // riding, dwelling and walking
+	Transfers   int `json:"interchanges"`
+	Stops       int `json:"stops"`
+	WalkSeconds int `json:"walkSeconds"` // walking between platforms at interchanges
+}
+
+// Dominates reports whether c is at least as good as o on every criterion
+// and better on at least one
+func (c Criteria) Dominates(o Criteria) bool {
+	if c.Seconds > o.Seconds || c.Transfers > o.Transfers || c.Stops > o.Stops || c.WalkSeconds > o.WalkSeconds {
+		return false
+	}
+	return c != o
+}
+
+// covers reports whether c dominates or equals o
+func (c Criteria) covers(o Criteria) bool {
+	return c == o || c.Dominates(o)
+}
+
+// Transfer is a change of line along a route
+type Transfer struct {
+	Station string `json:"station"`
+	// ToStation is the other end of the walkway when changing between
+	// stations of a complex
+	ToStation   string `json:"toStation,omitempty"`
+	FromLine    string `json:"fromLine"`
+	ToLine      string `json:"toLine"`
+	WalkSeconds int    `json:"walkSeconds"`
+}
+
+// Route is one Pareto-optimal alternative
+type Route struct {
+	Criteria
+	Stations  []string   `json:"stations"`
+	Edges     []Edge     `json:"-"`
+	Transfers []Transfer `json:"transfers"`
+	// Best names the criteria this route is the best option for
+	Best []string `json:"best"`
+}
+
+// Names of the criteria a route can be best at
+const (
+	BestFastest            = "fastest"
+	BestFewestInterchanges = "fewest_interchanges"
+	BestFewestStops        = "fewest_stops"
+	BestLeastWalking       = "least_walking"
+)
+
+// TransferFunc returns the walking time to change lines at a station
+type TransferFunc func(station, fromLine, toLine string) int
+
+// ParetoOptions bound the search
+type ParetoOptions struct {
+	// Routes slower than the fastest by more than
+	// max(MinSlackSeconds, fastest*SlackFactor) are not considered
+	SlackFactor     float64
+	MinSlackSeconds int
+	// Accessible, if set, limits changes of line and walkways to the
+	// stations it accepts, e.g. those with lifts for step-free routes
+	Accessible func(station string) bool
+}
+
+// DefaultParetoOptions keep alternatives within 15 minutes or half again the fastest time
+var DefaultParetoOptions = ParetoOptions{SlackFactor: 0.5, MinSlackSeconds: 900}
+
+// label is a partial route ending at a station, having arrived on a line
+// and possibly walked on from it to another station of the complex
+type label struct {
+	station string
+	line    string
+	walked  bool
+	cost    Criteria
+	parent  *label
+	edge    Edge
+	walk    int // walking before this edge when it changes line
+	removed bool
+}
+
+// ParetoRoutes returns every route between two stations that no other route
+// beats on all of time, interchanges, stops and walking, fastest first
+func (g *Graph) ParetoRoutes(from, to string, transfer TransferFunc, opts ParetoOptions) []Route {
+	if !g.HasStation(from) || !g.HasStation(to) || from == to {
+		return nil
+	}
+
+	// The graph is symmetric, so times from the destination are lower bounds to it
+	remaining := g.ShortestTimes(to)
+	fastest, ok := remaining[from]
+	if !ok {
+		return nil
+	}
+	slack := int(float64(fastest) * opts.SlackFactor)
+	if slack < opts.MinSlackSeconds {
+		slack = opts.MinSlackSeconds
+	}
+	budget := fastest + slack
+
+	type bagKey struct {
+		station, line string
+		walked        bool
+	}
+	bags := make(map[bagKey][]*label)
+	var arrived []*label
+
+	// insert adds a label to a bag unless something there is as good, and
+	// drops the labels it makes redundant
+	insert := func(key bagKey, l *label) bool {
+		bag := bags[key]
+		for _, other := range bag {
+			if other.cost.covers(l.cost) {
+				return false
+			}
+		}
+		kept := bag[:0]
+		for _, other := range bag {
+			if l.cost.Dominates(other.cost) {
+				other.removed = true
+				continue
+			}
+			kept = append(kept, other)
+		}
+		bags[key] = append(kept, l)
+		return true
+	}
+
+	start := &label{station: from}
+	pq := &labelQueue{start}
+	for pq.Len() > 0 {
+		current := heap.Pop(pq).(*label)
+		if current.removed {
+			continue
+		}
+
+		if current.station == to {
+			arrived = append(arrived, current)
+			continue
+		}
+
+		for _, e := range g.adjacency[current.station] {
+			cost := current.cost
+			cost.Seconds += e.Weight
+			walk := 0
+			line := e.LineID
+			if e.Walk {
+				if current.walked || !opts.accessible(current.station) || !opts.accessible(e.To) {
+					continue
+				}
+				// The walkway is the interchange; it counts once a train is boarded
+				cost.WalkSeconds += e.Weight
+				line = current.line
+			} else {
+				cost.Stops++
+				if current.line != "" && current.line != e.LineID {
+					if !opts.accessible(current.station) {
+						continue
+					}
+					cost.Transfers++
+					if !current.walked {
+						walk = transfer(current.station, current.line, e.LineID)
+						cost.WalkSeconds += walk
+						cost.Seconds += walk
+					}
+				}
+			}
+
+			bound, reachable := remaining[e.To]
+			if !reachable || cost.Seconds+bound > budget {
+				continue
+			}
+			// Skip anything a route already found beats or matches
+			dominated := false
+			for _, a := range arrived {
+				if a.cost.covers(cost) {
+					dominated = true
+					break
+				}
+			}
+			if dominated {
+				continue
+			}
+
+			next := &label{station: e.To, line: line, walked: e.Walk, cost: cost, parent: current, edge: e, walk: walk}
+			key := bagKey{e.To, line, e.Walk}
+			if e.To == to {
+				// How the destination was reached does not matter
+				key = bagKey{station: to}
+			}
+			if insert(key, next) {
+				heap.Push(pq, next)
+			}
+		}
+	}
+
+	var routes []Route
+	for _, l := range arrived {
+		if !l.removed {
+			routes = append(routes, l.route(from))
+		}
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i].Criteria, routes[j].Criteria
+		if a.Seconds != b.Seconds {
+			return a.Seconds < b.Seconds
+		}
+		return a.Transfers < b.Transfers
+	})
+	markBest(routes)
+	return routes
+}
+
+// accessible reports whether a rider may change trains at a station
+func (o ParetoOptions) accessible(station string) bool {
+	return o.Accessible == nil || o.Accessible(station)
+}
+
+// route rebuilds the route ending at a label
+func (l *label) route(from string) Route {
+	var chain []*label
+	for at := l; at.parent != nil; at = at.parent {
+		chain = append([]*label{at}, chain...)
+	}
+
+	r := Route{Criteria: l.cost, Stations: []string{from}}
+	for i, at := range chain {
+		r.Edges = append(r.Edges, at.edge)
+		r.Stations = append(r.Stations, at.edge.To)
+		if i == 0 || at.edge.Walk {
+			continue
+		}
+		prev := chain[i-1]
+		switch {
+		case prev.edge.Walk && prev.line != "" && prev.line != at.edge.LineID:
+			r.Transfers = append(r.Transfers, Transfer{
+				Station:     prev.edge.From,
+				ToStation:   prev.edge.To,
+				FromLine:    prev.line,
+				ToLine:      at.edge.LineID,
+				WalkSeconds: prev.edge.Weight,
+			})
+		case !prev.edge.Walk && prev.edge.LineID != at.edge.LineID:
+			r.Transfers = append(r.Transfers, Transfer{
+				Station:     at.edge.From,
+				FromLine:    prev.edge.LineID,
+				ToLine:      at.edge.LineID,
+				WalkSeconds: at.walk,
+			})
+		}
+	}
+	return r
+}
+
+// markBest records which routes are best for each criterion, breaking ties on time
+func markBest(routes []Route) {
+	criteria := []struct {
+		name  string
+		value func(Criteria) int
+	}{
+		{BestFastest, func(c Criteria) int { return c.Seconds }},
+		{BestFewestInterchanges, func(c Criteria) int { return c.Transfers }},
+		{BestFewestStops, func(c Criteria) int { return c.Stops }},
+		{BestLeastWalking, func(c Criteria) int { return c.WalkSeconds }},
+	}
+	for _, c := range criteria {
+		best := -1
+		for i, r := range routes {
+			if best < 0 || c.value(r.Criteria) < c.value(routes[best].Criteria) ||
+				(c.value(r.Criteria) == c.value(routes[best].Criteria) && r.Seconds < routes[best].Seconds) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			routes[best].Best = append(routes[best].Best, c.name)
+		}
+	}
+}
+
+// labelQueue is a min-heap of labels ordered by time
+type labelQueue []*label
+
+func (q labelQueue) Len() int            { return len(q) }
+func (q labelQueue) Less(i, j int) bool  { return q[i].cost.Seconds < q[j].cost.Seconds }
+func (q labelQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *labelQueue) Push(x interface{}) { *q = append(*q, x.(*label)) }
+func (q *labelQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	l := old[n-1]
+	*q = old[:n-1]
+	return l
+}
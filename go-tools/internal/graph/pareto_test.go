@@ -0,0 +1,145 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"metro-tools/internal/database"
+)
+
+// hops joins consecutive stations of a line, each hop taking seconds
+func hops(line string, seconds int, stations ...string) []database.StationConnection {
+	var conns []database.StationConnection
+	for i := 0; i+1 < len(stations); i++ {
+		conns = append(conns, database.StationConnection{
+			FromStationID: stations[i], ToStationID: stations[i+1], LineID: line, TravelTimeSeconds: seconds,
+		})
+	}
+	return conns
+}
+
+// testNetwork has three ways from S to T: the direct A line (600s, 4 stops),
+// lines B and C changing at X (300s riding, 3 stops) and the slow D line
+// (650s, 5 stops), which A beats on everything
+func testNetwork() []database.StationConnection {
+	var conns []database.StationConnection
+	conns = append(conns, hops("A", 150, "S", "a1", "a2", "a3", "T")...)
+	conns = append(conns, hops("B", 100, "S", "b1", "X")...)
+	conns = append(conns, hops("C", 100, "X", "T")...)
+	conns = append(conns, hops("D", 130, "S", "d1", "d2", "d3", "d4", "T")...)
+	return conns
+}
+
+func fixedTransfer(seconds int) TransferFunc {
+	return func(station, fromLine, toLine string) int { return seconds }
+}
+
+func TestParetoRoutes(t *testing.T) {
+	type want struct {
+		seconds, transfers, stops, walk int
+		best                            []string
+	}
+	tests := []struct {
+		name     string
+		transfer int
+		opts     ParetoOptions
+		want     []want
+	}{
+		{
+			name:     "fast with a change or slow and direct",
+			transfer: 60,
+			opts:     DefaultParetoOptions,
+			want: []want{
+				{360, 1, 3, 60, []string{BestFastest, BestFewestStops}},
+				{600, 0, 4, 0, []string{BestFewestInterchanges, BestLeastWalking}},
+			},
+		},
+		{
+			name:     "a long change is still the fewest stops",
+			transfer: 400,
+			opts:     DefaultParetoOptions,
+			want: []want{
+				{600, 0, 4, 0, []string{BestFastest, BestFewestInterchanges, BestLeastWalking}},
+				{700, 1, 3, 400, []string{BestFewestStops}},
+			},
+		},
+		{
+			name:     "slack excludes slow alternatives",
+			transfer: 60,
+			opts:     ParetoOptions{SlackFactor: 0.5},
+			want: []want{
+				{360, 1, 3, 60, []string{BestFastest, BestFewestInterchanges, BestFewestStops, BestLeastWalking}},
+			},
+		},
+		{
+			name:     "no changes at inaccessible stations",
+			transfer: 60,
+			opts:     ParetoOptions{SlackFactor: 0.5, MinSlackSeconds: 900, Accessible: func(s string) bool { return s != "X" }},
+			want: []want{
+				{600, 0, 4, 0, []string{BestFastest, BestFewestInterchanges, BestFewestStops, BestLeastWalking}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes := New(testNetwork()).ParetoRoutes("S", "T", fixedTransfer(tt.transfer), tt.opts)
+			if len(routes) != len(tt.want) {
+				t.Fatalf("got %d routes, want %d: %+v", len(routes), len(tt.want), routes)
+			}
+			for i, w := range tt.want {
+				r := routes[i]
+				got := want{r.Seconds, r.Criteria.Transfers, r.Stops, r.WalkSeconds, r.Best}
+				if !reflect.DeepEqual(got, w) {
+					t.Errorf("route %d = %+v, want %+v", i, got, w)
+				}
+				if r.Stations[0] != "S" || r.Stations[len(r.Stations)-1] != "T" {
+					t.Errorf("route %d runs %v, want S to T", i, r.Stations)
+				}
+				if len(r.Transfers) != r.Criteria.Transfers {
+					t.Errorf("route %d lists %d transfers, counts %d", i, len(r.Transfers), r.Criteria.Transfers)
+				}
+			}
+		})
+	}
+}
+
+func TestParetoRoutesTransfer(t *testing.T) {
+	routes := New(testNetwork()).ParetoRoutes("S", "T", fixedTransfer(60), DefaultParetoOptions)
+	want := []Transfer{{Station: "X", FromLine: "B", ToLine: "C", WalkSeconds: 60}}
+	if len(routes) == 0 || !reflect.DeepEqual(routes[0].Transfers, want) {
+		t.Fatalf("fastest route transfers = %+v, want %+v", routes, want)
+	}
+}
+
+func TestParetoRoutesWalkway(t *testing.T) {
+	// C leaves from X2, a separate station joined to X by a walkway
+	var conns []database.StationConnection
+	conns = append(conns, hops("A", 150, "S", "a1", "a2", "a3", "T")...)
+	conns = append(conns, hops("B", 100, "S", "b1", "X")...)
+	conns = append(conns, hops("C", 100, "X2", "T")...)
+	g := New(conns)
+	g.AddWalkways([]database.StationGroup{{ID: "x", StationIDs: []string{"X", "X2"}, WalkSeconds: 90}})
+
+	routes := g.ParetoRoutes("S", "T", fixedTransfer(1000), DefaultParetoOptions)
+	if len(routes) != 2 {
+		t.Fatalf("got %d routes, want 2: %+v", len(routes), routes)
+	}
+	fastest := routes[0]
+	// The walkway replaces the transfer time
+	if fastest.Seconds != 390 || fastest.WalkSeconds != 90 || fastest.Criteria.Transfers != 1 {
+		t.Errorf("fastest = %+v, want 390s with 90s walking and 1 interchange", fastest.Criteria)
+	}
+	want := []Transfer{{Station: "X", ToStation: "X2", FromLine: "B", ToLine: "C", WalkSeconds: 90}}
+	if !reflect.DeepEqual(fastest.Transfers, want) {
+		t.Errorf("transfers = %+v, want %+v", fastest.Transfers, want)
+	}
+}
+
+func TestParetoRoutesNone(t *testing.T) {
+	g := New(testNetwork())
+	for _, pair := range [][2]string{{"S", "S"}, {"S", "nowhere"}, {"nowhere", "T"}} {
+		if routes := g.ParetoRoutes(pair[0], pair[1], fixedTransfer(60), DefaultParetoOptions); routes != nil {
+			t.Errorf("ParetoRoutes(%s, %s) = %+v, want none", pair[0], pair[1], routes)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package graph
+
+import "metro-tools/internal/database"
+
+// TransferTimes looks up walking times between lines at interchanges
+type TransferTimes struct {
+	// Default is used for line pairs without a recorded time
+	Default int
+
+	walk map[transferKey]int
+}
+
+type transferKey struct{ station, from, to string }
+
+// NewTransferTimes indexes interchange_transfers rows
+func NewTransferTimes(transfers []database.InterchangeTransfer, fallback int) *TransferTimes {
+	t := &TransferTimes{Default: fallback, walk: make(map[transferKey]int)}
+	for _, tr := range transfers {
+		t.walk[transferKey{tr.StationID, tr.FromLineID, tr.ToLineID}] = tr.WalkSeconds
+	}
+	return t
+}
+
+// Walk returns the time to change from one line to another at a station.
+// A pair recorded in one direction only is assumed to take as long both ways.
+func (t *TransferTimes) Walk(station, fromLine, toLine string) int {
+	if seconds, ok := t.walk[transferKey{station, fromLine, toLine}]; ok {
+		return seconds
+	}
+	if seconds, ok := t.walk[transferKey{station, toLine, fromLine}]; ok {
+		return seconds
+	}
+	return t.Default
+}
+
+// Has reports whether a time is recorded for the pair in either direction
+func (t *TransferTimes) Has(station, fromLine, toLine string) bool {
+	_, forward := t.walk[transferKey{station, fromLine, toLine}]
+	_, backward := t.walk[transferKey{station, toLine, fromLine}]
+	return forward || backward
+}
@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"testing"
+
+	"metro-tools/internal/database"
+)
+
+func TestTransferTimes(t *testing.T) {
+	times := NewTransferTimes([]database.InterchangeTransfer{
+		{StationID: "X", FromLineID: "B", ToLineID: "C", WalkSeconds: 90},
+		{StationID: "Y", FromLineID: "B", ToLineID: "C", WalkSeconds: 200},
+		{StationID: "Y", FromLineID: "C", ToLineID: "B", WalkSeconds: 45},
+	}, 120)
+
+	tests := []struct {
+		name              string
+		station, from, to string
+		want              int
+		has               bool
+	}{
+		{"recorded direction", "X", "B", "C", 90, true},
+		{"reverse falls back to the recorded direction", "X", "C", "B", 90, true},
+		{"both directions recorded", "Y", "C", "B", 45, true},
+		{"pair at another station", "Y", "B", "C", 200, true},
+		{"unknown pair", "X", "B", "D", 120, false},
+		{"unknown station", "Z", "B", "C", 120, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := times.Walk(tt.station, tt.from, tt.to); got != tt.want {
+				t.Errorf("Walk(%s, %s, %s) = %d, want %d", tt.station, tt.from, tt.to, got, tt.want)
+			}
+			if got := times.Has(tt.station, tt.from, tt.to); got != tt.has {
+				t.Errorf("Has(%s, %s, %s) = %v, want %v", tt.station, tt.from, tt.to, got, tt.has)
+			}
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package journey
+
+import (
+	"sort"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/timetable"
+)
+
+// Planner defaults
+const (
+	// DefaultTransferSeconds is the time to change platforms, the same
+	// penalty routeFinder.service.ts adds per transfer
+	DefaultTransferSeconds = 120
+	// DefaultHorizon is how far ahead of the departure time trains are
+	// considered; long enough to wait overnight for the first train
+	DefaultHorizon = 12 * time.Hour
+)
+
+// Leg is a ride on one train
+type Leg struct {
+	TripID        string    `json:"tripId"`
+	LineID        string    `json:"lineId"`
+	Direction     string    `json:"direction"`
+	FromStationID string    `json:"fromStationId"`
+	ToStationID   string    `json:"toStationId"`
+	Stops         []string  `json:"stops"`
+	DepartureAt   time.Time `json:"departureAt"`
+	ArrivalAt     time.Time `json:"arrivalAt"`
+	// WaitSeconds is the time spent at the boarding station before the
+	// train leaves: at the origin, or after the previous leg incl. transfer
+	WaitSeconds int  `json:"waitSeconds"`
+	Assumed     bool `json:"assumedSchedule"`
+	// Walk marks a walkway between stations of a complex rather than a train
+	Walk bool `json:"walk,omitempty"`
+}
+
+// Itinerary is an earliest-arrival journey
+type Itinerary struct {
+	DepartAt         time.Time `json:"departAt"`
+	ArrivalAt        time.Time `json:"arrivalAt"`
+	DurationSeconds  int       `json:"durationSeconds"`
+	WaitingSeconds   int       `json:"waitingSeconds"`
+	InVehicleSeconds int       `json:"inVehicleSeconds"`
+	WalkingSeconds   int       `json:"walkingSeconds"`
+	Transfers        int       `json:"transfers"`
+	Legs             []Leg     `json:"legs"`
+}
+
+// elementary is one train moving between adjacent stations, in seconds
+// since the start of its service day
+type elementary struct {
+	trip     int
+	stop     int // index of the departure stop within the trip
+	dep, arr int
+	from, to string
+}
+
+// Planner answers earliest-arrival queries over a timetable with the
+// Connection Scan Algorithm
+type Planner struct {
+	TransferSeconds int
+	// Transfer, if set, returns the time to change between two lines at a
+	// station. TransferSeconds applies otherwise and between trains of one line.
+	Transfer func(station, fromLine, toLine string) int
+	Horizon  time.Duration
+	// Footpaths are walkways to other stations of the same complex
+	Footpaths map[string][]Footpath
+	// Accessible, if set, limits changing trains and walkways to the
+	// stations it accepts, e.g. those with lifts for step-free journeys
+	Accessible func(station string) bool
+
+	trips []timetable.Trip
+	conns []elementary // sorted by departure
+}
+
+// Footpath is a walk to another station
+type Footpath struct {
+	To      string
+	Seconds int
+}
+
+// AddWalkways lets riders walk between every pair of stations in each complex
+func (p *Planner) AddWalkways(groups []database.StationGroup) {
+	if p.Footpaths == nil {
+		p.Footpaths = make(map[string][]Footpath)
+	}
+	for _, g := range groups {
+		for _, a := range g.StationIDs {
+			for _, b := range g.StationIDs {
+				if a != b {
+					p.Footpaths[a] = append(p.Footpaths[a], Footpath{To: b, Seconds: g.WalkSeconds})
+				}
+			}
+		}
+	}
+}
+
+// NewPlanner indexes a timetable for journey planning
+func NewPlanner(tt *timetable.Timetable) *Planner {
+	p := &Planner{
+		TransferSeconds: DefaultTransferSeconds,
+		Horizon:         DefaultHorizon,
+		trips:           tt.Trips,
+	}
+	for ti, trip := range tt.Trips {
+		for si := 0; si+1 < len(trip.Stops); si++ {
+			p.conns = append(p.conns, elementary{
+				trip: ti,
+				stop: si,
+				dep:  trip.Stops[si].Departure,
+				arr:  trip.Stops[si+1].Arrival,
+				from: trip.Stops[si].StationID,
+				to:   trip.Stops[si+1].StationID,
+			})
+		}
+	}
+	sort.SliceStable(p.conns, func(i, j int) bool { return p.conns[i].dep < p.conns[j].dep })
+	return p
+}
+
+// candidate is an elementary connection on a specific service day
+type candidate struct {
+	elementary
+	day          int // 0 yesterday, 1 today, 2 tomorrow
+	depAt, arrAt int64
+}
+
+type tripKey struct{ day, trip int }
+
+// label is one way of reaching a station
+type label struct {
+	at         int64
+	cand       int    // candidate alighted from, or -1 on foot or at the origin
+	walkedFrom string // station walked from, when on foot
+}
+
+// EarliestArrival finds the journey that reaches to soonest when leaving from at the given time
+func (p *Planner) EarliestArrival(from, to string, at time.Time, loc *time.Location) (*Itinerary, bool) {
+	if from == to {
+		return nil, false
+	}
+	cands := p.candidates(at, loc)
+
+	origin := label{at: at.Unix(), cand: -1}
+	best := map[string]label{from: origin}   // earliest arrival at each station, however reached
+	walked := map[string]label{from: origin} // earliest arrival at each station on foot
+	// earliest arrival at each station by each line; the time to change
+	// trains depends on the line arrived on, so a later train on a line with
+	// a shorter walk to the next platform may connect sooner
+	byLine := make(map[string]map[string]label)
+	boarded := make(map[tripKey]int)      // candidate where each trip was boarded
+	boardedVia := make(map[tripKey]label) // how the rider reached the boarding station
+
+	// walk relaxes the footpaths out of a station just reached
+	walk := func(station string) {
+		if !p.accessible(station) {
+			return
+		}
+		for _, f := range p.Footpaths[station] {
+			if !p.accessible(f.To) {
+				continue
+			}
+			l := label{at: best[station].at + int64(f.Seconds), cand: -1, walkedFrom: station}
+			if old, ok := walked[f.To]; !ok || l.at < old.at {
+				walked[f.To] = l
+			}
+			if old, ok := best[f.To]; !ok || l.at < old.at {
+				best[f.To] = l
+			}
+		}
+	}
+	walk(from)
+
+	for i, c := range cands {
+		if l, ok := best[to]; ok && c.depAt >= l.at {
+			break
+		}
+		key := tripKey{c.day, c.trip}
+		line := p.trips[c.trip].LineID
+		if _, onBoard := boarded[key]; !onBoard {
+			if c.from != from && !p.accessible(c.from) {
+				continue
+			}
+			// Walking from another station of a complex replaces the transfer
+			via, ok := walked[c.from]
+			ready := via.at
+			for arrivedOn, l := range byLine[c.from] {
+				t := l.at + int64(p.transferSeconds(c.from, arrivedOn, line))
+				if !ok || t < ready || (t == ready && via.cand >= 0 && l.cand < via.cand) {
+					via, ready, ok = l, t, true
+				}
+			}
+			if !ok || ready > c.depAt {
+				continue
+			}
+			boarded[key] = i
+			boardedVia[key] = via
+		}
+
+		l := label{at: c.arrAt, cand: i}
+		if byLine[c.to] == nil {
+			byLine[c.to] = make(map[string]label)
+		}
+		if old, ok := byLine[c.to][line]; !ok || l.at < old.at {
+			byLine[c.to][line] = l
+		}
+		if old, ok := best[c.to]; !ok || l.at < old.at {
+			best[c.to] = l
+			walk(c.to)
+		}
+	}
+
+	if _, ok := best[to]; !ok {
+		return nil, false
+	}
+
+	// Walk back from the destination one train at a time
+	var legs []Leg
+	for station, l := to, best[to]; station != from; {
+		if l.cand < 0 {
+			prev := l.walkedFrom
+			legs = append([]Leg{{
+				FromStationID: prev,
+				ToStationID:   station,
+				Stops:         []string{prev, station},
+				DepartureAt:   time.Unix(best[prev].at, 0).In(loc),
+				ArrivalAt:     time.Unix(l.at, 0).In(loc),
+				Walk:          true,
+			}}, legs...)
+			station, l = prev, best[prev]
+			continue
+		}
+		alight := cands[l.cand]
+		key := tripKey{alight.day, alight.trip}
+		board := cands[boarded[key]]
+		trip := p.trips[alight.trip]
+
+		stops := make([]string, 0, alight.stop-board.stop+2)
+		for s := board.stop; s <= alight.stop+1; s++ {
+			stops = append(stops, trip.Stops[s].StationID)
+		}
+		legs = append([]Leg{{
+			TripID:        trip.ID,
+			LineID:        trip.LineID,
+			Direction:     trip.Direction,
+			FromStationID: board.from,
+			ToStationID:   alight.to,
+			Stops:         stops,
+			DepartureAt:   time.Unix(board.depAt, 0).In(loc),
+			ArrivalAt:     time.Unix(alight.arrAt, 0).In(loc),
+			Assumed:       trip.Assumed,
+		}}, legs...)
+		station, l = board.from, boardedVia[key]
+	}
+
+	it := &Itinerary{Legs: legs}
+	it.measureFrom(at.In(loc))
+	return it, true
+}
+
+// accessible reports whether a rider may change trains at a station
+func (p *Planner) accessible(station string) bool {
+	return p.Accessible == nil || p.Accessible(station)
+}
+
+// transferSeconds is the time needed between arriving on one line and
+// boarding another train at a station
+func (p *Planner) transferSeconds(station, fromLine, toLine string) int {
+	if p.Transfer != nil && fromLine != toLine {
+		return p.Transfer(station, fromLine, toLine)
+	}
+	return p.TransferSeconds
+}
+
+// measureFrom sets the waiting, riding and total times as seen by a rider
+// ready to leave at the given time
+func (it *Itinerary) measureFrom(at time.Time) {
+	it.DepartAt = at
+	it.ArrivalAt = it.Legs[len(it.Legs)-1].ArrivalAt
+	it.Transfers = -1
+	it.WaitingSeconds, it.InVehicleSeconds, it.WalkingSeconds = 0, 0, 0
+
+	previous := at
+	for i := range it.Legs {
+		leg := &it.Legs[i]
+		leg.WaitSeconds = int(leg.DepartureAt.Sub(previous) / time.Second)
+		it.WaitingSeconds += leg.WaitSeconds
+		seconds := int(leg.ArrivalAt.Sub(leg.DepartureAt) / time.Second)
+		if leg.Walk {
+			it.WalkingSeconds += seconds
+		} else {
+			it.InVehicleSeconds += seconds
+			it.Transfers++
+		}
+		previous = leg.ArrivalAt
+	}
+	if it.Transfers < 0 {
+		it.Transfers = 0
+	}
+	it.DurationSeconds = int(it.ArrivalAt.Sub(at) / time.Second)
+}
+
+// Plan returns up to n itineraries with successively later arrivals for a
+// rider ready to leave at the given time. Each boards the latest first train
+// that still makes its arrival, so no itinerary waits longer than it must.
+func (p *Planner) Plan(from, to string, at time.Time, loc *time.Location, n int) []Itinerary {
+	var itineraries []Itinerary
+	query := at
+	for len(itineraries) < n {
+		it, ok := p.EarliestArrival(from, to, query, loc)
+		if !ok {
+			break
+		}
+		if it.onFoot() {
+			// Stations of one complex: walking is the only sensible option
+			it.measureFrom(at.In(loc))
+			return append(itineraries, *it)
+		}
+		for {
+			later, ok := p.EarliestArrival(from, to, it.leaveAt().Add(time.Second), loc)
+			if !ok || !later.ArrivalAt.Equal(it.ArrivalAt) {
+				break
+			}
+			it = later
+		}
+		query = it.leaveAt().Add(time.Second)
+		it.measureFrom(at.In(loc))
+		itineraries = append(itineraries, *it)
+	}
+	return itineraries
+}
+
+// onFoot reports whether an itinerary takes no train
+func (it *Itinerary) onFoot() bool {
+	for _, leg := range it.Legs {
+		if !leg.Walk {
+			return false
+		}
+	}
+	return true
+}
+
+// leaveAt is the latest time a rider can set off from the origin and still
+// catch the first train, walking to it if it leaves from another station
+func (it *Itinerary) leaveAt() time.Time {
+	first := it.Legs[0]
+	if first.Walk && len(it.Legs) > 1 {
+		return it.Legs[1].DepartureAt.Add(-first.ArrivalAt.Sub(first.DepartureAt))
+	}
+	return first.DepartureAt
+}
+
+// candidates returns the connections departing within the horizon, across
+// the previous, current and next service days, in departure order
+func (p *Planner) candidates(at time.Time, loc *time.Location) []candidate {
+	today := timetable.ServiceDay(at, loc)
+	from := at.Unix()
+	until := at.Add(p.Horizon).Unix()
+
+	var cands []candidate
+	for day, midnight := range []time.Time{today.AddDate(0, 0, -1), today, today.AddDate(0, 0, 1)} {
+		base := midnight.Unix()
+		first := sort.Search(len(p.conns), func(i int) bool { return base+int64(p.conns[i].dep) >= from })
+		for _, c := range p.conns[first:] {
+			dep := timetable.At(midnight, c.dep).Unix()
+			if dep >= until {
+				break
+			}
+			cands = append(cands, candidate{
+				elementary: c,
+				day:        day,
+				depAt:      dep,
+				arrAt:      timetable.At(midnight, c.arr).Unix(),
+			})
+		}
+	}
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].depAt < cands[j].depAt })
+	return cands
+}
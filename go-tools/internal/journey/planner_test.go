@@ -0,0 +1,147 @@
+package journey
+
+import (
+	"testing"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/timetable"
+)
+
+// trip runs a train from its first station at start, taking hop seconds
+// between consecutive stations
+func trip(id, line string, start, hop int, stations ...string) timetable.Trip {
+	t := timetable.Trip{ID: id, LineID: line, Direction: "forward"}
+	for i, st := range stations {
+		at := start + i*hop
+		t.Stops = append(t.Stops, timetable.StopTime{StationID: st, Arrival: at, Departure: at})
+	}
+	return t
+}
+
+func clock(s string) int {
+	seconds, err := timetable.ParseClock(s)
+	if err != nil {
+		panic(err)
+	}
+	return seconds
+}
+
+// testPlanner builds a small network: the red and blue lines both run from
+// O to the interchange B, where the green line leaves for Y. Changing from
+// red to green is a long walk, from blue a short one.
+func testPlanner() *Planner {
+	tt := &timetable.Timetable{Trips: []timetable.Trip{
+		trip("red-1", "red", clock("08:00"), 300, "O", "M", "B"),   // at B 08:10
+		trip("red-2", "red", clock("08:20"), 300, "O", "M", "B"),   // at B 08:30
+		trip("blue-1", "blue", clock("08:02"), 600, "O", "B"),      // at B 08:12
+		trip("green-1", "green", clock("08:15"), 600, "B", "Y"),    // at Y 08:25
+		trip("green-2", "green", clock("08:30"), 600, "B", "Y"),    // at Y 08:40
+		trip("green-3", "green", clock("08:45"), 600, "B", "Y"),    // at Y 08:55
+		trip("purple-1", "purple", clock("08:20"), 300, "B2", "Z"), // at Z 08:25
+		trip("purple-2", "purple", clock("08:40"), 300, "B2", "Z"), // at Z 08:45
+	}}
+	p := NewPlanner(tt)
+	p.Transfer = func(station, fromLine, toLine string) int {
+		if fromLine == "red" && toLine == "green" {
+			return 600
+		}
+		return 60
+	}
+	p.AddWalkways([]database.StationGroup{{ID: "b-complex", StationIDs: []string{"B", "B2"}, WalkSeconds: 240}})
+	return p
+}
+
+func TestEarliestArrival(t *testing.T) {
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	at := func(s string) time.Time { return timetable.At(day, clock(s)) }
+
+	tests := []struct {
+		name     string
+		from, to string
+		depart   string
+		arrive   string // empty when no journey exists
+		lines    []string
+	}{
+		{"direct", "O", "B", "07:50", "08:10", []string{"red"}},
+		{"intermediate stop", "M", "B", "08:00", "08:10", []string{"red"}},
+		// Red reaches B first, but its long walk to green misses green-1;
+		// the later blue train connects
+		{"transfer time depends on the arriving line", "O", "Y", "07:50", "08:25", []string{"blue", "green"}},
+		{"walkway to another station of a complex", "O", "Z", "07:50", "08:25", []string{"red", "", "purple"}},
+		{"after the last train", "O", "Y", "09:00", "", nil},
+		{"no reverse service", "Y", "O", "07:00", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, ok := testPlanner().EarliestArrival(tt.from, tt.to, at(tt.depart), time.UTC)
+			if tt.arrive == "" {
+				if ok {
+					t.Fatalf("found a journey arriving %s, want none", it.ArrivalAt.Format("15:04"))
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("no journey found")
+			}
+			if !it.ArrivalAt.Equal(at(tt.arrive)) {
+				t.Errorf("arrives %s, want %s", it.ArrivalAt.Format("15:04"), tt.arrive)
+			}
+			var lines []string
+			for _, leg := range it.Legs {
+				lines = append(lines, leg.LineID)
+			}
+			if len(lines) != len(tt.lines) {
+				t.Fatalf("legs on %q, want %q", lines, tt.lines)
+			}
+			for i := range lines {
+				if lines[i] != tt.lines[i] {
+					t.Errorf("legs on %q, want %q", lines, tt.lines)
+					break
+				}
+			}
+			if first := it.Legs[0].FromStationID; first != tt.from {
+				t.Errorf("starts at %s, want %s", first, tt.from)
+			}
+			if last := it.Legs[len(it.Legs)-1].ToStationID; last != tt.to {
+				t.Errorf("ends at %s, want %s", last, tt.to)
+			}
+		})
+	}
+}
+
+func TestPlan(t *testing.T) {
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	p := testPlanner()
+
+	its := p.Plan("O", "Y", timetable.At(day, clock("07:50")), time.UTC, 3)
+	want := []struct{ leave, arrive string }{
+		{"08:02", "08:25"}, // blue-1, then green-1
+		{"08:20", "08:55"}, // red-2, whose long change misses green-2 for green-3
+	}
+	if len(its) != len(want) {
+		t.Fatalf("got %d itineraries, want %d", len(its), len(want))
+	}
+	for i, w := range want {
+		it := its[i]
+		if got := it.Legs[0].DepartureAt.Format("15:04"); got != w.leave {
+			t.Errorf("itinerary %d leaves %s, want %s", i, got, w.leave)
+		}
+		if got := it.ArrivalAt.Format("15:04"); got != w.arrive {
+			t.Errorf("itinerary %d arrives %s, want %s", i, got, w.arrive)
+		}
+		if it.Transfers != 1 {
+			t.Errorf("itinerary %d has %d transfers, want 1", i, it.Transfers)
+		}
+		if it.WaitingSeconds+it.InVehicleSeconds+it.WalkingSeconds != it.DurationSeconds {
+			t.Errorf("itinerary %d: waiting %d + riding %d + walking %d != duration %d", i,
+				it.WaitingSeconds, it.InVehicleSeconds, it.WalkingSeconds, it.DurationSeconds)
+		}
+	}
+
+	// Step-free journeys may not change trains at B
+	p.Accessible = func(station string) bool { return station != "B" }
+	if its := p.Plan("O", "Y", timetable.At(day, clock("07:50")), time.UTC, 3); len(its) != 0 {
+		t.Errorf("got %d step-free itineraries through an inaccessible interchange, want 0", len(its))
+	}
+}
@@ -0,0 +1,101 @@
+package live
+
+import "sync"
+
+// Event types
+const (
+	EventPosition = "position" // a train moved or its ETA changed
+	EventRemoved  = "removed"  // a train expired without reports
+)
+
+// Event is one change to a live train, published to subscribers
+type Event struct {
+	ID          uint64       `json:"id,omitempty"`
+	Type        string       `json:"type"`
+	Train       Train        `json:"train"`
+	NextStation *NextStation `json:"nextStation,omitempty"`
+}
+
+// Subscription receives the events of one line or city, or all events
+// when both filters are empty
+type Subscription struct {
+	LineID string
+	CityID string
+	events chan Event
+	hub    *Hub
+}
+
+// Events is closed when the subscription ends, either by Close or because
+// the subscriber fell a full buffer behind
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+func (s *Subscription) wants(e Event) bool {
+	return (s.LineID == "" || s.LineID == e.Train.LineID) && (s.CityID == "" || s.CityID == e.Train.CityID)
+}
+
+// Hub fans events out to subscribers without ever blocking the publisher
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]bool
+	seq  uint64
+}
+
+// NewHub returns a hub without subscribers
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]bool)}
+}
+
+// Subscribe starts receiving events, buffering up to buffer of them
+func (h *Hub) Subscribe(lineID, cityID string, buffer int) *Subscription {
+	s := &Subscription{LineID: lineID, CityID: cityID, events: make(chan Event, buffer), hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[s] = true
+	return s
+}
+
+// Publish numbers an event and delivers it to every interested subscriber.
+// Subscribers whose buffer is full are dropped, so a slow client cannot
+// hold up the tracker; they reconnect and start from a fresh snapshot.
+func (h *Hub) Publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	e.ID = h.seq
+	for s := range h.subs {
+		if !s.wants(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			h.drop(s)
+		}
+	}
+}
+
+// Subscribers returns the number of open subscriptions
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// drop closes a subscription once; the caller holds h.mu
+func (h *Hub) drop(s *Subscription) {
+	if h.subs[s] {
+		delete(h.subs, s)
+		close(s.events)
+	}
+}
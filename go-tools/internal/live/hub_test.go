@@ -0,0 +1,133 @@
+package live
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func event(lineID, cityID string) Event {
+	return Event{Type: EventPosition, Train: Train{TrainID: "T1", LineID: lineID, CityID: cityID}}
+}
+
+// drain reads the buffered events of a subscription, and whether it is closed
+func drain(s *Subscription) (lines []string, closed bool) {
+	for {
+		select {
+		case e, ok := <-s.Events():
+			if !ok {
+				return lines, true
+			}
+			lines = append(lines, e.Train.LineID)
+		default:
+			return lines, false
+		}
+	}
+}
+
+func TestHubFilters(t *testing.T) {
+	tests := []struct {
+		name           string
+		lineID, cityID string
+		want           []string
+	}{
+		{"everything", "", "", []string{"yellow", "blue", "purple"}},
+		{"one line", "blue", "", []string{"blue"}},
+		{"one city", "", "delhi", []string{"yellow", "blue"}},
+		{"line and city", "purple", "delhi", nil},
+	}
+	hub := NewHub()
+	subs := make([]*Subscription, len(tests))
+	for i, tt := range tests {
+		subs[i] = hub.Subscribe(tt.lineID, tt.cityID, 8)
+	}
+	hub.Publish(event("yellow", "delhi"))
+	hub.Publish(event("blue", "delhi"))
+	hub.Publish(event("purple", "bangalore"))
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, closed := drain(subs[i])
+			if closed || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("received %v (closed %v), want %v", got, closed, tt.want)
+			}
+		})
+	}
+}
+
+func TestHubEventIDs(t *testing.T) {
+	hub := NewHub()
+	all := hub.Subscribe("", "", 8)
+	blue := hub.Subscribe("blue", "", 8)
+	for _, line := range []string{"yellow", "blue", "yellow", "blue"} {
+		hub.Publish(event(line, "delhi"))
+	}
+
+	// IDs count every event published, so a filtered subscriber sees gaps
+	for _, tt := range []struct {
+		sub  *Subscription
+		want []uint64
+	}{{all, []uint64{1, 2, 3, 4}}, {blue, []uint64{2, 4}}} {
+		var ids []uint64
+		for len(tt.sub.Events()) > 0 {
+			ids = append(ids, (<-tt.sub.Events()).ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("IDs = %v, want %v", ids, tt.want)
+		}
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe("", "", 1)
+	fast := hub.Subscribe("", "", 8)
+
+	hub.Publish(event("yellow", "delhi"))
+	hub.Publish(event("blue", "delhi")) // slow's buffer is full
+
+	if got, closed := drain(slow); !closed || !reflect.DeepEqual(got, []string{"yellow"}) {
+		t.Errorf("slow subscriber received %v (closed %v), want yellow then closed", got, closed)
+	}
+	if got, closed := drain(fast); closed || len(got) != 2 {
+		t.Errorf("fast subscriber received %v (closed %v), want both events", got, closed)
+	}
+	if n := hub.Subscribers(); n != 1 {
+		t.Errorf("%d subscribers, want 1", n)
+	}
+
+	// Closing a dropped subscription, even twice, must not close its channel again
+	slow.Close()
+	slow.Close()
+	fast.Close()
+	if n := hub.Subscribers(); n != 0 {
+		t.Errorf("%d subscribers after Close, want 0", n)
+	}
+	hub.Publish(event("yellow", "delhi"))
+}
+
+func TestHubConcurrent(t *testing.T) {
+	hub := NewHub()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				hub.Publish(event("yellow", "delhi"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				s := hub.Subscribe("yellow", "", 4)
+				drain(s)
+				s.Close()
+			}
+		}()
+	}
+	wg.Wait()
+	if n := hub.Subscribers(); n != 0 {
+		t.Errorf("%d subscribers left, want 0", n)
+	}
+}
@@ -0,0 +1,341 @@
+package live
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// SnapshotInterval is how often changed live state is written to disk
+const SnapshotInterval = 30 * time.Second
+
+// State is everything a tracker knows, as saved between restarts
+type State struct {
+	SavedAt     time.Time
+	Trains      map[string]Train
+	Reports     map[string][]Report
+	Segments    map[string]SegmentSpeed
+	Attachments []Attachment
+}
+
+// Snapshot copies the tracker's state along with its version
+func (t *Tracker) Snapshot() (State, uint64) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	st := State{
+		SavedAt:  t.now(),
+		Trains:   make(map[string]Train, len(t.trains)),
+		Reports:  make(map[string][]Report, len(t.reports)),
+		Segments: make(map[string]SegmentSpeed, len(t.segments)),
+	}
+	for key, train := range t.trains {
+		st.Trains[key] = *train
+	}
+	for key, reports := range t.reports {
+		st.Reports[key] = append([]Report(nil), reports...)
+	}
+	for key, s := range t.segments {
+		st.Segments[key] = *s
+	}
+	for _, a := range t.attachments {
+		st.Attachments = append(st.Attachments, *a)
+	}
+	return st, t.version
+}
+
+// Restore replaces the tracker's state with a saved one, then drops
+// whatever expired while the server was down
+func (t *Tracker) Restore(st State) {
+	t.mu.Lock()
+	t.trains = make(map[string]*Train, len(st.Trains))
+	for key, train := range st.Trains {
+		train := train
+		t.trains[key] = &train
+	}
+	t.reports = make(map[string][]Report, len(st.Reports))
+	for key, reports := range st.Reports {
+		t.reports[key] = append([]Report(nil), reports...)
+	}
+	t.segments = make(map[string]*SegmentSpeed, len(st.Segments))
+	for key, s := range st.Segments {
+		s := s
+		t.segments[key] = &s
+	}
+	t.attachments = make(map[string]*Attachment, len(st.Attachments))
+	for _, a := range st.Attachments {
+		a := a
+		t.attachments[a.UserID] = &a
+	}
+	t.mu.Unlock()
+
+	t.CleanupStaleData()
+}
+
+// StateStore keeps the latest snapshot of live state in its own SQLite
+// file, apart from the read-only network database
+type StateStore struct {
+	conn *sql.DB
+	path string
+}
+
+const stateSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS trains (
+	key TEXT PRIMARY KEY,
+	train_id TEXT NOT NULL,
+	line_id TEXT NOT NULL,
+	city_id TEXT NOT NULL,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	direction TEXT NOT NULL,
+	speed REAL NOT NULL,
+	confidence REAL NOT NULL,
+	last_update INTEGER NOT NULL,
+	report_count INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS reports (
+	train_key TEXT NOT NULL,
+	train_id TEXT NOT NULL,
+	line_id TEXT NOT NULL,
+	city_id TEXT NOT NULL,
+	latitude REAL NOT NULL,
+	longitude REAL NOT NULL,
+	direction TEXT NOT NULL,
+	source TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	user_id TEXT NOT NULL,
+	speed REAL,
+	accuracy REAL
+);
+CREATE TABLE IF NOT EXISTS segment_speeds (
+	key TEXT PRIMARY KEY,
+	from_station_id TEXT NOT NULL,
+	to_station_id TEXT NOT NULL,
+	avg_speed REAL NOT NULL,
+	sample_count INTEGER NOT NULL,
+	last_updated INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS attachments (
+	user_id TEXT PRIMARY KEY,
+	train_id TEXT NOT NULL,
+	line_id TEXT NOT NULL,
+	attached_at INTEGER NOT NULL,
+	last_report_at INTEGER NOT NULL
+);
+`
+
+// OpenStateStore opens or creates a live state file. Freed pages are
+// returned to the filesystem on every save, so the file stays the size of
+// one snapshot.
+func OpenStateStore(path string) (*StateStore, error) {
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open live state: %w", err)
+	}
+	// One connection, so the pragma applies to every statement
+	conn.SetMaxOpenConns(1)
+	// auto_vacuum only takes effect before the first table is created
+	if _, err := conn.Exec("PRAGMA auto_vacuum = FULL"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to configure live state: %w", err)
+	}
+	if _, err := conn.Exec(stateSchema); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create live state tables: %w", err)
+	}
+	return &StateStore{conn: conn, path: path}, nil
+}
+
+// Path returns the file the state is kept in
+func (s *StateStore) Path() string {
+	return s.path
+}
+
+// Close closes the state file
+func (s *StateStore) Close() error {
+	return s.conn.Close()
+}
+
+// Save replaces the stored snapshot in one transaction, so a crash mid-save
+// keeps the previous one
+func (s *StateStore) Save(st State) error {
+	tx, err := s.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin live state save: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"meta", "trains", "reports", "segment_speeds", "attachments"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('saved_at', ?)`, st.SavedAt.UTC().Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("failed to save meta: %w", err)
+	}
+	for key, t := range st.Trains {
+		if _, err := tx.Exec(`INSERT INTO trains VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			key, t.TrainID, t.LineID, t.CityID, t.CurrentLatitude, t.CurrentLongitude,
+			t.Direction, t.Speed, t.Confidence, t.LastUpdate, t.ReportCount); err != nil {
+			return fmt.Errorf("failed to save train: %w", err)
+		}
+	}
+	for key, reports := range st.Reports {
+		for _, r := range reports {
+			if _, err := tx.Exec(`INSERT INTO reports VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				key, r.TrainID, r.LineID, r.CityID, r.Latitude, r.Longitude, r.Direction,
+				r.Source, millis(r.Timestamp), r.UserID, r.Speed, r.Accuracy); err != nil {
+				return fmt.Errorf("failed to save report: %w", err)
+			}
+		}
+	}
+	for key, seg := range st.Segments {
+		if _, err := tx.Exec(`INSERT INTO segment_speeds VALUES (?, ?, ?, ?, ?, ?)`,
+			key, seg.FromStationID, seg.ToStationID, seg.AvgSpeed, seg.SampleCount, seg.LastUpdated); err != nil {
+			return fmt.Errorf("failed to save segment speed: %w", err)
+		}
+	}
+	for _, a := range st.Attachments {
+		if _, err := tx.Exec(`INSERT INTO attachments VALUES (?, ?, ?, ?, ?)`,
+			a.UserID, a.TrainID, a.LineID, a.AttachedAt, a.LastReportAt); err != nil {
+			return fmt.Errorf("failed to save attachment: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit live state: %w", err)
+	}
+	return nil
+}
+
+// Load reads the stored snapshot; a new file gives an empty state
+func (s *StateStore) Load() (State, error) {
+	st := State{
+		Trains:   make(map[string]Train),
+		Reports:  make(map[string][]Report),
+		Segments: make(map[string]SegmentSpeed),
+	}
+
+	var savedAt string
+	err := s.conn.QueryRow(`SELECT value FROM meta WHERE key = 'saved_at'`).Scan(&savedAt)
+	if err == sql.ErrNoRows {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("failed to read live state meta: %w", err)
+	}
+	st.SavedAt, _ = time.Parse(time.RFC3339Nano, savedAt)
+
+	rows, err := s.conn.Query(`SELECT key, train_id, line_id, city_id, latitude, longitude,
+		direction, speed, confidence, last_update, report_count FROM trains`)
+	if err != nil {
+		return st, fmt.Errorf("failed to query trains: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		var t Train
+		if err := rows.Scan(&key, &t.TrainID, &t.LineID, &t.CityID, &t.CurrentLatitude, &t.CurrentLongitude,
+			&t.Direction, &t.Speed, &t.Confidence, &t.LastUpdate, &t.ReportCount); err != nil {
+			rows.Close()
+			return st, fmt.Errorf("failed to scan train: %w", err)
+		}
+		t.IsActive = true
+		st.Trains[key] = t
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("failed to read trains: %w", err)
+	}
+
+	rows, err = s.conn.Query(`SELECT train_key, train_id, line_id, city_id, latitude, longitude,
+		direction, source, timestamp, user_id, speed, accuracy FROM reports ORDER BY rowid`)
+	if err != nil {
+		return st, fmt.Errorf("failed to query reports: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		var r Report
+		var ts int64
+		var speed, accuracy sql.NullFloat64
+		if err := rows.Scan(&key, &r.TrainID, &r.LineID, &r.CityID, &r.Latitude, &r.Longitude,
+			&r.Direction, &r.Source, &ts, &r.UserID, &speed, &accuracy); err != nil {
+			rows.Close()
+			return st, fmt.Errorf("failed to scan report: %w", err)
+		}
+		r.Timestamp = time.Unix(0, ts*int64(time.Millisecond))
+		if speed.Valid {
+			r.Speed = &speed.Float64
+		}
+		if accuracy.Valid {
+			r.Accuracy = &accuracy.Float64
+		}
+		st.Reports[key] = append(st.Reports[key], r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("failed to read reports: %w", err)
+	}
+
+	rows, err = s.conn.Query(`SELECT key, from_station_id, to_station_id, avg_speed, sample_count, last_updated FROM segment_speeds`)
+	if err != nil {
+		return st, fmt.Errorf("failed to query segment speeds: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		var seg SegmentSpeed
+		if err := rows.Scan(&key, &seg.FromStationID, &seg.ToStationID, &seg.AvgSpeed, &seg.SampleCount, &seg.LastUpdated); err != nil {
+			rows.Close()
+			return st, fmt.Errorf("failed to scan segment speed: %w", err)
+		}
+		st.Segments[key] = seg
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("failed to read segment speeds: %w", err)
+	}
+
+	rows, err = s.conn.Query(`SELECT user_id, train_id, line_id, attached_at, last_report_at FROM attachments`)
+	if err != nil {
+		return st, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.UserID, &a.TrainID, &a.LineID, &a.AttachedAt, &a.LastReportAt); err != nil {
+			return st, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		st.Attachments = append(st.Attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("failed to read attachments: %w", err)
+	}
+	return st, nil
+}
+
+// Persist saves the tracker's state every interval when it has changed,
+// forever. Failed saves are logged and retried on the next tick.
+func (t *Tracker) Persist(store *StateStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var saved uint64
+	for range ticker.C {
+		st, version := t.Snapshot()
+		if version == saved {
+			continue
+		}
+		if err := store.Save(st); err != nil {
+			log.Printf("live state: %v", err)
+			continue
+		}
+		saved = version
+	}
+}
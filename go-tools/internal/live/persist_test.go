@@ -0,0 +1,152 @@
+package live
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"metro-tools/internal/topology"
+)
+
+func float(v float64) *float64 { return &v }
+
+// testState is a snapshot with one of everything, taken at now
+func testState(now time.Time) State {
+	ms := millis(now)
+	key := trainKey("yellow", topology.Forward, "T1")
+	return State{
+		SavedAt: now,
+		Trains: map[string]Train{key: {
+			TrainID: "T1", LineID: "yellow", CityID: "delhi", CurrentLatitude: 28.6328, CurrentLongitude: 77.2197,
+			Direction: topology.Forward, Speed: 42.5, Confidence: 0.8, LastUpdate: ms, ReportCount: 2, IsActive: true,
+		}},
+		Reports: map[string][]Report{key: {
+			{TrainID: "T1", LineID: "yellow", CityID: "delhi", Latitude: 28.6320, Longitude: 77.2190,
+				Direction: topology.Forward, Source: SourceOnboard, Timestamp: now.Add(-time.Minute), UserID: "u1",
+				Speed: float(40), Accuracy: float(12)},
+			{TrainID: "T1", LineID: "yellow", CityID: "delhi", Latitude: 28.6328, Longitude: 77.2197,
+				Direction: topology.Forward, Source: SourceObserver, Timestamp: now, UserID: "u2"},
+		}},
+		Segments: map[string]SegmentSpeed{segmentKey("yellow", "a", "b"): {
+			FromStationID: "a", ToStationID: "b", AvgSpeed: 38.2, SampleCount: 5, LastUpdated: ms,
+		}},
+		Attachments: []Attachment{{UserID: "u1", TrainID: "T1", LineID: "yellow", AttachedAt: ms - 60000, LastReportAt: ms}},
+	}
+}
+
+// sameState compares states, allowing times to come back in another zone
+func sameState(t *testing.T, got, want State) {
+	t.Helper()
+	if !got.SavedAt.Equal(want.SavedAt) {
+		t.Errorf("SavedAt = %v, want %v", got.SavedAt, want.SavedAt)
+	}
+	if !reflect.DeepEqual(got.Trains, want.Trains) {
+		t.Errorf("Trains = %+v, want %+v", got.Trains, want.Trains)
+	}
+	if !reflect.DeepEqual(got.Segments, want.Segments) {
+		t.Errorf("Segments = %+v, want %+v", got.Segments, want.Segments)
+	}
+	if !reflect.DeepEqual(got.Attachments, want.Attachments) {
+		t.Errorf("Attachments = %+v, want %+v", got.Attachments, want.Attachments)
+	}
+	if len(got.Reports) != len(want.Reports) {
+		t.Fatalf("Reports = %+v, want %+v", got.Reports, want.Reports)
+	}
+	for key, reports := range want.Reports {
+		if len(got.Reports[key]) != len(reports) {
+			t.Fatalf("Reports[%s] = %+v, want %+v", key, got.Reports[key], reports)
+		}
+		for i, w := range reports {
+			g := got.Reports[key][i]
+			if !g.Timestamp.Equal(w.Timestamp) {
+				t.Errorf("report %d timestamp = %v, want %v", i, g.Timestamp, w.Timestamp)
+			}
+			g.Timestamp = w.Timestamp
+			if !reflect.DeepEqual(g, w) {
+				t.Errorf("report %d = %+v, want %+v", i, g, w)
+			}
+		}
+	}
+}
+
+func TestStateStoreRoundTrip(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	empty := State{Trains: map[string]Train{}, Reports: map[string][]Report{}, Segments: map[string]SegmentSpeed{}}
+	tests := []struct {
+		name  string
+		saves []State
+		want  State
+	}{
+		{"new file is empty", nil, empty},
+		{"one snapshot", []State{testState(now)}, testState(now)},
+		{"a save replaces the previous snapshot", []State{testState(now.Add(-time.Hour)), testState(now)}, testState(now)},
+		{"an empty snapshot clears the file", []State{testState(now), {SavedAt: now}}, State{
+			SavedAt: now, Trains: map[string]Train{}, Reports: map[string][]Report{}, Segments: map[string]SegmentSpeed{},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "live.db")
+			store, err := OpenStateStore(path)
+			if err != nil {
+				t.Fatalf("OpenStateStore: %v", err)
+			}
+			for _, st := range tt.saves {
+				if err := store.Save(st); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+			}
+			store.Close()
+
+			// Reopen, as a restarted server would
+			store, err = OpenStateStore(path)
+			if err != nil {
+				t.Fatalf("OpenStateStore: %v", err)
+			}
+			defer store.Close()
+			got, err := store.Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			sameState(t, got, tt.want)
+		})
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	now := time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+	clock := now
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return clock }
+
+	want := testState(now)
+	tracker.Restore(want)
+	got, version := tracker.Snapshot()
+	sameState(t, got, want)
+	if version != 0 {
+		t.Errorf("restoring fresh state bumped the version to %d", version)
+	}
+
+	// Nothing has expired, so cleanup changes nothing and saves nothing
+	tracker.CleanupStaleData()
+	if _, v := tracker.Snapshot(); v != version {
+		t.Errorf("idle cleanup bumped the version from %d to %d", version, v)
+	}
+
+	// A restart after the train went stale drops it on restore
+	clock = now.Add(TrainStale + time.Second)
+	restarted := NewTracker()
+	restarted.now = func() time.Time { return clock }
+	restarted.Restore(want)
+	st, v := restarted.Snapshot()
+	if len(st.Trains) != 0 || len(st.Reports) != 0 {
+		t.Errorf("stale state survived restore: %+v", st)
+	}
+	if len(st.Segments) != 1 || len(st.Attachments) != 1 {
+		t.Errorf("segment speeds and attachments within their TTL were dropped: %+v", st)
+	}
+	if v == 0 {
+		t.Error("dropping stale state did not bump the version")
+	}
+}
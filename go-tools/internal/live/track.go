@@ -0,0 +1,110 @@
+package live
+
+import (
+	"math"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+	"metro-tools/internal/validators"
+)
+
+// LatLng is a coordinate
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Segment is the straight track between two adjacent stations of a line,
+// in forward order
+type Segment struct {
+	LineID string
+	From   string
+	To     string
+	A      LatLng // From's coordinates
+	B      LatLng // To's coordinates
+}
+
+// Snapped is a position moved onto the nearest segment of a line
+type Snapped struct {
+	LatLng
+	DistanceToTrack float64 // km from the original position
+	Segment         int     // index into the track
+}
+
+// BuildTracks returns the segments of every line, following each forward
+// hop so that branches are covered
+func BuildTracks(topo *topology.Topology, stations []database.MetroStation) map[string][]Segment {
+	coords := make(map[string]LatLng)
+	for _, st := range stations {
+		coords[st.ID] = LatLng{st.Latitude, st.Longitude}
+	}
+
+	tracks := make(map[string][]Segment)
+	for _, lineID := range topo.Lines() {
+		var hops []topology.Hop
+		for _, id := range topo.Stations(lineID, topology.Forward) {
+			hops = append(hops, topo.Next(lineID, id, topology.Forward)...)
+		}
+		hops = append(hops, topo.Unordered(lineID)...)
+
+		for _, h := range hops {
+			a, okA := coords[h.From]
+			b, okB := coords[h.To]
+			if okA && okB {
+				tracks[lineID] = append(tracks[lineID], Segment{LineID: lineID, From: h.From, To: h.To, A: a, B: b})
+			}
+		}
+	}
+	return tracks
+}
+
+// SnapToTrack moves a position to the nearest point of a line's track, like
+// snapToTrack in liveTracking.service.ts. ok is false for an empty track.
+func SnapToTrack(lat, lng float64, track []Segment) (snapped Snapped, ok bool) {
+	for i, s := range track {
+		p := snapToSegment(lat, lng, s.A, s.B)
+		dist := validators.HaversineDistance(lat, lng, p.Lat, p.Lng)
+		if !ok || dist < snapped.DistanceToTrack {
+			snapped = Snapped{LatLng: p, DistanceToTrack: dist, Segment: i}
+			ok = true
+		}
+	}
+	return snapped, ok
+}
+
+// snapToSegment projects a point onto a segment, treating degrees as planar
+// over the few kilometres between stations
+func snapToSegment(lat, lng float64, a, b LatLng) LatLng {
+	dx, dy := b.Lng-a.Lng, b.Lat-a.Lat
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0 {
+		return a
+	}
+	t := ((lng-a.Lng)*dx + (lat-a.Lat)*dy) / lenSq
+	switch {
+	case t < 0:
+		return a
+	case t > 1:
+		return b
+	}
+	return LatLng{a.Lat + t*dy, a.Lng + t*dx}
+}
+
+// IsValidSpeed reports whether moving between two positions in the given
+// time is within a metro train's speed range
+func IsValidSpeed(lat1, lng1, lat2, lng2, timeDeltaSeconds float64) bool {
+	if timeDeltaSeconds < 1 {
+		return false
+	}
+	speed := validators.HaversineDistance(lat1, lng1, lat2, lng2) / timeDeltaSeconds * 3600
+	return speed >= MinSpeed && speed <= MaxSpeed
+}
+
+// IsPlausibleMove reports whether a train could have moved between two fixes
+// in the given time when each may be off by up to toleranceMeters in total.
+// Gaps under a second, or out of order, count as one second.
+func IsPlausibleMove(lat1, lng1, lat2, lng2, timeDeltaSeconds, toleranceMeters float64) bool {
+	km := math.Max(0, validators.HaversineDistance(lat1, lng1, lat2, lng2)-toleranceMeters/1000)
+	seconds := math.Max(math.Abs(timeDeltaSeconds), 1)
+	return km/seconds*3600 <= MaxSpeed
+}
@@ -0,0 +1,474 @@
+package live
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"metro-tools/internal/topology"
+	"metro-tools/internal/validators"
+)
+
+// Constants from liveTracking.service.ts
+const (
+	ReportRetention  = 5 * time.Minute  // reports older than this are dropped
+	TrainStale       = 5 * time.Minute  // a train without reports for this long is inactive
+	ConfidenceDecay  = time.Minute      // recency weight falls by 1/e per interval
+	SegmentSpeedTTL  = 30 * time.Minute // segment speeds without samples expire
+	CleanupInterval  = 5 * time.Minute
+	MaxSpeed         = 90.0 // km/h
+	MinSpeed         = 0.0
+	DefaultSpeedKmh  = 40.0 // used for ETAs without speed data
+	AttachmentExpiry = 30 * time.Minute
+	// MaxTrackDistanceKm is how far off the line a report may be, on top of its GPS accuracy
+	MaxTrackDistanceKm = 0.5
+	// MaxAccuracyMeters caps the GPS accuracy added to MaxTrackDistanceKm
+	MaxAccuracyMeters = 300.0
+	// SpeedSampleSeconds is the shortest gap between reports whose implied
+	// speed is sampled for a segment; closer reports differ mostly by GPS noise
+	SpeedSampleSeconds = 10
+	// ReportNoiseMeters is the GPS error assumed for reports without an accuracy
+	ReportNoiseMeters = 30.0
+)
+
+// Report sources, most reliable first
+const (
+	SourceOnboard  = "onboard"
+	SourcePlatform = "platform"
+	SourceObserver = "observer"
+)
+
+// sourceWeights weight reports by how reliable their source is
+var sourceWeights = map[string]float64{SourceOnboard: 3, SourcePlatform: 2, SourceObserver: 1}
+
+// ValidSource reports whether a report source is known
+func ValidSource(source string) bool {
+	_, ok := sourceWeights[source]
+	return ok
+}
+
+// Report is one crowd-sourced position of a train, as in types/tracking.ts
+type Report struct {
+	TrainID   string
+	LineID    string
+	CityID    string
+	Latitude  float64
+	Longitude float64
+	Direction string
+	Source    string
+	Timestamp time.Time
+	UserID    string
+	Speed     *float64 // km/h
+	Accuracy  *float64 // metres
+}
+
+// NextStation is the estimated arrival at the next station ahead
+type NextStation struct {
+	StationID  string `json:"stationId,omitempty"`
+	ETASeconds *int   `json:"etaSeconds,omitempty"`
+}
+
+// Train is the aggregated position of one train, in the shape of LiveTrain
+type Train struct {
+	TrainID          string  `json:"trainId"`
+	LineID           string  `json:"lineId"`
+	CityID           string  `json:"cityId"`
+	CurrentLatitude  float64 `json:"currentLatitude"`
+	CurrentLongitude float64 `json:"currentLongitude"`
+	Direction        string  `json:"direction"`
+	Speed            float64 `json:"speed"`
+	Confidence       float64 `json:"confidence"`
+	LastUpdate       int64   `json:"lastUpdate"` // Unix milliseconds
+	NextStationID    string  `json:"nextStationId,omitempty"`
+	NextStationETA   *int    `json:"nextStationETA,omitempty"`
+	ReportCount      int     `json:"reportCount"`
+	IsActive         bool    `json:"isActive"`
+}
+
+// SegmentSpeed is the rolling average speed of trains between two stations
+type SegmentSpeed struct {
+	FromStationID string  `json:"fromStationId"`
+	ToStationID   string  `json:"toStationId"`
+	AvgSpeed      float64 `json:"avgSpeed"` // km/h
+	SampleCount   int     `json:"sampleCount"`
+	LastUpdated   int64   `json:"lastUpdated"` // Unix milliseconds
+}
+
+// Attachment records a user sharing their location from a train
+type Attachment struct {
+	UserID       string `json:"userId"`
+	TrainID      string `json:"trainId"`
+	LineID       string `json:"lineId"`
+	AttachedAt   int64  `json:"attachedAt"`
+	LastReportAt int64  `json:"lastReportAt"`
+}
+
+// Tracker aggregates train reports into live positions. It is safe for
+// concurrent use.
+type Tracker struct {
+	mu          sync.RWMutex
+	trains      map[string]*Train
+	reports     map[string][]Report
+	segments    map[string]*SegmentSpeed
+	attachments map[string]*Attachment // by user ID
+	now         func() time.Time
+	// version counts changes, so persistence can skip unchanged state
+	version uint64
+
+	// Events, if set, receives every position update and removal
+	Events *Hub
+}
+
+// NewTracker returns an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		trains:      make(map[string]*Train),
+		reports:     make(map[string][]Report),
+		segments:    make(map[string]*SegmentSpeed),
+		attachments: make(map[string]*Attachment),
+		now:         time.Now,
+	}
+}
+
+// trainKey identifies a train's run in one direction. Unlike the Node
+// service, which keyed by line and direction only, trains sharing a line
+// are kept apart.
+func trainKey(lineID, direction, trainID string) string {
+	return lineID + "-" + direction + "-" + trainID
+}
+
+func segmentKey(lineID, from, to string) string {
+	return lineID + "-" + from + "-" + to
+}
+
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// expired reports whether more than ttl has passed since a Unix millisecond time
+func expired(lastMillis int64, now time.Time, ttl time.Duration) bool {
+	return millis(now)-lastMillis > int64(ttl/time.Millisecond)
+}
+
+// ProcessReport snaps a report onto its line, checks it against the train's
+// previous report and folds it into the train's position. Positions are
+// averaged over fresh reports, weighted by source and recency. track may be
+// empty when the line has no geometry, and the report is then used as is.
+func (t *Tracker) ProcessReport(r Report, track []Segment) (Train, error) {
+	now := t.now()
+	if r.Timestamp.IsZero() {
+		r.Timestamp = now
+	}
+	if r.Speed != nil && (*r.Speed < MinSpeed || *r.Speed > MaxSpeed) {
+		return Train{}, fmt.Errorf("speed of %.0f km/h is outside %.0f-%.0f km/h", *r.Speed, MinSpeed, MaxSpeed)
+	}
+
+	segment := -1
+	if snapped, ok := SnapToTrack(r.Latitude, r.Longitude, track); ok {
+		limit := MaxTrackDistanceKm
+		if r.Accuracy != nil && *r.Accuracy > 0 {
+			limit += math.Min(*r.Accuracy, MaxAccuracyMeters) / 1000
+		}
+		if snapped.DistanceToTrack > limit {
+			return Train{}, fmt.Errorf("report is %.0fm from line %s", snapped.DistanceToTrack*1000, r.LineID)
+		}
+		r.Latitude, r.Longitude = snapped.Lat, snapped.Lng
+		segment = snapped.Segment
+	}
+
+	key := trainKey(r.LineID, r.Direction, r.TrainID)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var fresh []Report
+	for _, old := range t.reports[key] {
+		if now.Sub(old.Timestamp) < ReportRetention {
+			fresh = append(fresh, old)
+		}
+	}
+
+	if len(fresh) > 0 {
+		prev := fresh[len(fresh)-1]
+		dt := r.Timestamp.Sub(prev.Timestamp).Seconds()
+		// However close together, two reports cannot be further apart than a
+		// train could go, give or take the error of each fix
+		tolerance := noiseMeters(prev) + noiseMeters(r)
+		if !IsPlausibleMove(prev.Latitude, prev.Longitude, r.Latitude, r.Longitude, dt, tolerance) {
+			km := validators.HaversineDistance(prev.Latitude, prev.Longitude, r.Latitude, r.Longitude)
+			return Train{}, fmt.Errorf("report is %.1f km from the last one %.0fs earlier, beyond %.0f km/h", km, dt, MaxSpeed)
+		}
+		if dt >= SpeedSampleSeconds {
+			if s, ok := t.segmentOf(prev, track); ok && s == segment {
+				from, to := track[s].From, track[s].To
+				if r.Direction == topology.Backward {
+					from, to = to, from
+				}
+				km := validators.HaversineDistance(prev.Latitude, prev.Longitude, r.Latitude, r.Longitude)
+				t.updateSegmentSpeed(r.LineID, from, to, km/dt*3600, now)
+			}
+		}
+	}
+	fresh = append(fresh, r)
+	t.reports[key] = fresh
+
+	var totalWeight, lat, lng, speed float64
+	weights := make([]float64, len(fresh))
+	for i, rep := range fresh {
+		recency := math.Exp(-float64(now.Sub(rep.Timestamp)) / float64(ConfidenceDecay))
+		weights[i] = sourceWeights[rep.Source] * recency
+		totalWeight += weights[i]
+	}
+	for i, rep := range fresh {
+		w := weights[i] / totalWeight
+		lat += rep.Latitude * w
+		lng += rep.Longitude * w
+		if rep.Speed != nil {
+			speed += *rep.Speed * w
+		}
+	}
+	// The average of points on a curve may fall off it
+	if snapped, ok := SnapToTrack(lat, lng, track); ok {
+		lat, lng = snapped.Lat, snapped.Lng
+	}
+
+	train := &Train{
+		TrainID:          r.TrainID,
+		LineID:           r.LineID,
+		CityID:           r.CityID,
+		CurrentLatitude:  lat,
+		CurrentLongitude: lng,
+		Direction:        r.Direction,
+		Speed:            speed,
+		Confidence:       math.Min(1, float64(len(fresh))/3*0.7+0.3),
+		LastUpdate:       millis(now),
+		ReportCount:      len(fresh),
+		IsActive:         true,
+	}
+	t.trains[key] = train
+	t.version++
+	if t.Events != nil {
+		next := t.nextStation(*train, track)
+		t.Events.Publish(Event{Type: EventPosition, Train: *train, NextStation: &next})
+	}
+
+	if a, ok := t.attachments[r.UserID]; ok && r.UserID != "" {
+		a.LastReportAt = millis(now)
+	}
+	return *train, nil
+}
+
+// noiseMeters is how far a report's fix may be from where the train was
+func noiseMeters(r Report) float64 {
+	if r.Accuracy != nil && *r.Accuracy > 0 {
+		return math.Min(*r.Accuracy, MaxAccuracyMeters)
+	}
+	return ReportNoiseMeters
+}
+
+// segmentOf finds the segment a stored, already snapped report lies on
+func (t *Tracker) segmentOf(r Report, track []Segment) (int, bool) {
+	snapped, ok := SnapToTrack(r.Latitude, r.Longitude, track)
+	return snapped.Segment, ok
+}
+
+// Trains returns the live trains, optionally of one line or city. Trains
+// without recent reports are marked inactive and kept while confidence is
+// above 0.5, as getLiveTrains does.
+func (t *Tracker) Trains(lineID, cityID string) []Train {
+	now := t.now()
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	trains := []Train{}
+	for _, train := range t.trains {
+		if lineID != "" && train.LineID != lineID {
+			continue
+		}
+		if cityID != "" && train.CityID != cityID {
+			continue
+		}
+		tr := *train
+		stale := expired(tr.LastUpdate, now, TrainStale)
+		if stale {
+			tr.IsActive = false
+		}
+		if !stale || tr.Confidence > 0.5 {
+			trains = append(trains, tr)
+		}
+	}
+	sort.Slice(trains, func(i, j int) bool {
+		if trains[i].LineID != trains[j].LineID {
+			return trains[i].LineID < trains[j].LineID
+		}
+		return trains[i].TrainID < trains[j].TrainID
+	})
+	return trains
+}
+
+// Train returns the most recently updated train with an ID
+func (t *Tracker) Train(trainID string) (Train, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var found *Train
+	for _, train := range t.trains {
+		if train.TrainID == trainID && (found == nil || train.LastUpdate > found.LastUpdate) {
+			found = train
+		}
+	}
+	if found == nil {
+		return Train{}, false
+	}
+	tr := *found
+	tr.IsActive = !expired(tr.LastUpdate, t.now(), TrainStale)
+	return tr, true
+}
+
+// UpdateSegmentSpeed adds a speed sample to the rolling average of a segment
+func (t *Tracker) UpdateSegmentSpeed(lineID, from, to string, speed float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.updateSegmentSpeed(lineID, from, to, speed, t.now())
+}
+
+func (t *Tracker) updateSegmentSpeed(lineID, from, to string, speed float64, now time.Time) {
+	key := segmentKey(lineID, from, to)
+	s, ok := t.segments[key]
+	if !ok {
+		t.segments[key] = &SegmentSpeed{FromStationID: from, ToStationID: to, AvgSpeed: speed, SampleCount: 1, LastUpdated: millis(now)}
+		t.version++
+		return
+	}
+	s.AvgSpeed = (s.AvgSpeed*float64(s.SampleCount) + speed) / float64(s.SampleCount+1)
+	s.SampleCount++
+	s.LastUpdated = millis(now)
+	t.version++
+}
+
+// NextStation estimates when a train reaches the next station ahead on its
+// line, using its reported speed, else the segment's average speed, else
+// DefaultSpeedKmh
+func (t *Tracker) NextStation(train Train, track []Segment) NextStation {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.nextStation(train, track)
+}
+
+// nextStation is NextStation for callers holding t.mu
+func (t *Tracker) nextStation(train Train, track []Segment) NextStation {
+	snapped, ok := SnapToTrack(train.CurrentLatitude, train.CurrentLongitude, track)
+	if !ok {
+		return NextStation{}
+	}
+	s := track[snapped.Segment]
+	next, at, from := s.To, s.B, s.From
+	if train.Direction == topology.Backward {
+		next, at, from = s.From, s.A, s.To
+	}
+
+	speed := train.Speed
+	if avg, ok := t.segments[segmentKey(train.LineID, from, next)]; speed <= 0 && ok && avg.AvgSpeed > 0 {
+		speed = avg.AvgSpeed
+	}
+	if speed <= 0 {
+		speed = DefaultSpeedKmh
+	}
+
+	km := validators.HaversineDistance(snapped.Lat, snapped.Lng, at.Lat, at.Lng)
+	eta := int(math.Round(km / speed * 3600))
+	return NextStation{StationID: next, ETASeconds: &eta}
+}
+
+// Attach records that a user is sharing their location from a train
+func (t *Tracker) Attach(userID, trainID, lineID string) Attachment {
+	now := millis(t.now())
+	a := &Attachment{UserID: userID, TrainID: trainID, LineID: lineID, AttachedAt: now, LastReportAt: now}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.attachments[userID] = a
+	t.version++
+	return *a
+}
+
+// Detach stops tracking a user's train, reporting whether they were attached to it
+func (t *Tracker) Detach(userID, trainID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	a, ok := t.attachments[userID]
+	if !ok || a.TrainID != trainID {
+		return false
+	}
+	delete(t.attachments, userID)
+	t.version++
+	return true
+}
+
+// CleanupStaleData drops stale trains, expired reports, segment speeds and
+// attachments, returning how many trains were removed
+func (t *Tracker) CleanupStaleData() int {
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	removed, changed := 0, false
+	for key, train := range t.trains {
+		if expired(train.LastUpdate, now, TrainStale) {
+			delete(t.trains, key)
+			removed++
+			if t.Events != nil {
+				gone := *train
+				gone.IsActive = false
+				t.Events.Publish(Event{Type: EventRemoved, Train: gone})
+			}
+		}
+	}
+	for key, reports := range t.reports {
+		var fresh []Report
+		for _, r := range reports {
+			if now.Sub(r.Timestamp) < ReportRetention {
+				fresh = append(fresh, r)
+			}
+		}
+		if len(fresh) == len(reports) {
+			continue
+		}
+		changed = true
+		if len(fresh) == 0 {
+			delete(t.reports, key)
+		} else {
+			t.reports[key] = fresh
+		}
+	}
+	for key, s := range t.segments {
+		if expired(s.LastUpdated, now, SegmentSpeedTTL) {
+			delete(t.segments, key)
+			changed = true
+		}
+	}
+	for user, a := range t.attachments {
+		if expired(a.LastReportAt, now, AttachmentExpiry) {
+			delete(t.attachments, user)
+			changed = true
+		}
+	}
+	// Only a change needs saving; an idle tracker is not rewritten
+	if changed || removed > 0 {
+		t.version++
+	}
+	return removed
+}
+
+// Janitor runs CleanupStaleData every interval, forever
+func (t *Tracker) Janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.CleanupStaleData()
+	}
+}
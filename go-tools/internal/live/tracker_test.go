@@ -0,0 +1,299 @@
+package live
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"metro-tools/internal/topology"
+)
+
+var testNow = time.Date(2026, 3, 2, 8, 30, 0, 0, time.UTC)
+
+// testTrack runs north from a through b to c, about 1.1km a segment
+func testTrack() []Segment {
+	a, b, c := LatLng{Lat: 28.60, Lng: 77.20}, LatLng{Lat: 28.61, Lng: 77.20}, LatLng{Lat: 28.62, Lng: 77.20}
+	return []Segment{
+		{LineID: "yellow", From: "a", To: "b", A: a, B: b},
+		{LineID: "yellow", From: "b", To: "c", A: b, B: c},
+	}
+}
+
+// testTracker is a tracker whose clock reads *clock
+func testTracker(clock *time.Time) *Tracker {
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return *clock }
+	return tracker
+}
+
+// report is an onboard report of train T1 going forward on the yellow line
+func report(lat, lng float64, at time.Time) Report {
+	return Report{
+		TrainID: "T1", LineID: "yellow", CityID: "delhi", Latitude: lat, Longitude: lng,
+		Direction: topology.Forward, Source: SourceOnboard, Timestamp: at, UserID: "u1",
+	}
+}
+
+func withSpeed(r Report, speed float64) Report { r.Speed = &speed; return r }
+
+func withAccuracy(r Report, accuracy float64) Report { r.Accuracy = &accuracy; return r }
+
+func TestProcessReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		prev   *Report
+		gap    time.Duration // since prev
+		report Report
+		ok     bool
+	}{
+		{name: "on the track", report: report(28.605, 77.2001, testNow), ok: true},
+		{name: "too fast", report: withSpeed(report(28.605, 77.20, testNow), 120)},
+		{name: "negative speed", report: withSpeed(report(28.605, 77.20, testNow), -5)},
+		{name: "1km off the track", report: report(28.605, 77.21, testNow)},
+		{name: "600m off the track within its accuracy", report: withAccuracy(report(28.605, 77.2061, testNow), 200), ok: true},
+		{name: "accuracy is capped", report: withAccuracy(report(28.605, 77.2092, testNow), 5000)},
+		{
+			name:   "a minute to the next station",
+			prev:   &Report{Latitude: 28.60, Longitude: 77.20},
+			gap:    time.Minute,
+			report: report(28.61, 77.20, testNow), ok: true,
+		},
+		{
+			name:   "2km in 2 seconds",
+			prev:   &Report{Latitude: 28.60, Longitude: 77.20},
+			gap:    2 * time.Second,
+			report: report(28.62, 77.20, testNow),
+		},
+		{
+			name:   "2km in 10 seconds",
+			prev:   &Report{Latitude: 28.60, Longitude: 77.20},
+			gap:    10 * time.Second,
+			report: report(28.62, 77.20, testNow),
+		},
+		{
+			name:   "GPS noise between close reports",
+			prev:   &Report{Latitude: 28.6000, Longitude: 77.20},
+			gap:    2 * time.Second,
+			report: report(28.6004, 77.20, testNow), ok: true,
+		},
+		{
+			name:   "noise within the reports' accuracy",
+			prev:   &Report{Latitude: 28.6000, Longitude: 77.20},
+			gap:    2 * time.Second,
+			report: withAccuracy(report(28.6020, 77.20, testNow), 200), ok: true,
+		},
+		{
+			name:   "same second, far apart",
+			prev:   &Report{Latitude: 28.60, Longitude: 77.20},
+			report: report(28.605, 77.20, testNow),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := testNow
+			tracker := testTracker(&clock)
+			if tt.prev != nil {
+				prev := report(tt.prev.Latitude, tt.prev.Longitude, testNow.Add(-tt.gap))
+				if _, err := tracker.ProcessReport(prev, testTrack()); err != nil {
+					t.Fatalf("previous report rejected: %v", err)
+				}
+			}
+			_, err := tracker.ProcessReport(tt.report, testTrack())
+			if (err == nil) != tt.ok {
+				t.Fatalf("ProcessReport error = %v, want accepted %v", err, tt.ok)
+			}
+			want := 0
+			if tt.prev != nil {
+				want++
+			}
+			if tt.ok {
+				want++
+			}
+			if st, _ := tracker.Snapshot(); len(st.Reports[trainKey("yellow", topology.Forward, "T1")]) != want {
+				t.Errorf("kept %d reports, want %d", len(st.Reports[trainKey("yellow", topology.Forward, "T1")]), want)
+			}
+		})
+	}
+}
+
+func TestProcessReportWeightedPosition(t *testing.T) {
+	clock := testNow
+	tracker := testTracker(&clock)
+
+	// An onboard report a minute old against a fresh observer report
+	onboard := withSpeed(report(28.6000, 77.20, testNow.Add(-time.Minute)), 40)
+	observer := withSpeed(report(28.6040, 77.20, testNow), 60)
+	observer.Source, observer.UserID = SourceObserver, "u2"
+	if _, err := tracker.ProcessReport(onboard, nil); err != nil {
+		t.Fatalf("onboard report rejected: %v", err)
+	}
+	train, err := tracker.ProcessReport(observer, nil)
+	if err != nil {
+		t.Fatalf("observer report rejected: %v", err)
+	}
+
+	wOnboard, wObserver := 3*math.Exp(-1), 1.0
+	total := wOnboard + wObserver
+	wantLat := (28.6000*wOnboard + 28.6040*wObserver) / total
+	wantSpeed := (40*wOnboard + 60*wObserver) / total
+	if math.Abs(train.CurrentLatitude-wantLat) > 1e-9 || math.Abs(train.Speed-wantSpeed) > 1e-9 {
+		t.Errorf("position %.6f at %.2f km/h, want %.6f at %.2f km/h", train.CurrentLatitude, train.Speed, wantLat, wantSpeed)
+	}
+	if train.ReportCount != 2 || math.Abs(train.Confidence-(2.0/3*0.7+0.3)) > 1e-9 {
+		t.Errorf("%d reports with confidence %.3f, want 2 with %.3f", train.ReportCount, train.Confidence, 2.0/3*0.7+0.3)
+	}
+
+	// Expired reports no longer count
+	clock = testNow.Add(ReportRetention)
+	train, err = tracker.ProcessReport(withSpeed(report(28.6042, 77.20, clock), 30), nil)
+	if err != nil {
+		t.Fatalf("report rejected: %v", err)
+	}
+	if train.ReportCount != 1 || train.CurrentLatitude != 28.6042 || train.Speed != 30 {
+		t.Errorf("train = %+v, want only the latest report", train)
+	}
+}
+
+func TestProcessReportSegmentSpeed(t *testing.T) {
+	clock := testNow
+	tracker := testTracker(&clock)
+	for _, r := range []Report{
+		report(28.601, 77.20, testNow.Add(-time.Minute)),
+		report(28.609, 77.20, testNow),
+		report(28.6091, 77.20, testNow.Add(2*time.Second)), // too soon to sample
+	} {
+		if _, err := tracker.ProcessReport(r, testTrack()); err != nil {
+			t.Fatalf("report rejected: %v", err)
+		}
+	}
+	st, _ := tracker.Snapshot()
+	s, ok := st.Segments[segmentKey("yellow", "a", "b")]
+	if !ok || s.SampleCount != 1 || math.Abs(s.AvgSpeed-53.4) > 0.5 {
+		t.Errorf("segment a-b = %+v, want one sample of about 53 km/h", s)
+	}
+}
+
+func TestTrainsStaleness(t *testing.T) {
+	clock := testNow
+	tracker := testTracker(&clock)
+	other := report(28.605, 77.20, testNow)
+	other.TrainID, other.LineID, other.CityID, other.UserID = "B1", "blue", "noida", "u2"
+	for _, r := range []Report{report(28.605, 77.20, testNow), other} {
+		if _, err := tracker.ProcessReport(r, nil); err != nil {
+			t.Fatalf("report rejected: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		after          time.Duration
+		lineID, cityID string
+		want           []string
+		active         bool
+	}{
+		{"fresh", 0, "", "", []string{"B1", "T1"}, true},
+		{"by line", 0, "yellow", "", []string{"T1"}, true},
+		{"by city", 0, "", "noida", []string{"B1"}, true},
+		{"just fresh", TrainStale, "", "", []string{"B1", "T1"}, true},
+		// Confident trains are kept, inactive, until cleanup drops them
+		{"stale", TrainStale + time.Second, "", "", []string{"B1", "T1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = testNow.Add(tt.after)
+			trains := tracker.Trains(tt.lineID, tt.cityID)
+			var ids []string
+			for _, tr := range trains {
+				ids = append(ids, tr.TrainID)
+				if tr.IsActive != tt.active {
+					t.Errorf("%s active = %v, want %v", tr.TrainID, tr.IsActive, tt.active)
+				}
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.want) {
+				t.Errorf("Trains = %v, want %v", ids, tt.want)
+			}
+			if tr, ok := tracker.Train("T1"); !ok || tr.IsActive != tt.active {
+				t.Errorf("Train(T1) = %+v, %v, want active %v", tr, ok, tt.active)
+			}
+		})
+	}
+}
+
+func TestCleanupStaleData(t *testing.T) {
+	clock := testNow
+	tracker := testTracker(&clock)
+	if _, err := tracker.ProcessReport(report(28.605, 77.20, testNow), nil); err != nil {
+		t.Fatalf("report rejected: %v", err)
+	}
+	tracker.UpdateSegmentSpeed("yellow", "a", "b", 50)
+	tracker.Attach("u1", "T1", "yellow")
+
+	tests := []struct {
+		after                                    time.Duration
+		removed, trains, reports, segments, atts int
+		changed                                  bool
+	}{
+		{0, 0, 1, 1, 1, 1, false},
+		{ReportRetention + time.Second, 1, 0, 0, 1, 1, true},
+		{ReportRetention + 2*time.Second, 0, 0, 0, 1, 1, false},
+		{SegmentSpeedTTL + time.Second, 0, 0, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		clock = testNow.Add(tt.after)
+		_, before := tracker.Snapshot()
+		removed := tracker.CleanupStaleData()
+		st, after := tracker.Snapshot()
+		if removed != tt.removed || len(st.Trains) != tt.trains || len(st.Reports) != tt.reports ||
+			len(st.Segments) != tt.segments || len(st.Attachments) != tt.atts {
+			t.Errorf("after %v: removed %d, left %d trains, %d reports, %d segments, %d attachments; want %d, %d, %d, %d, %d",
+				tt.after, removed, len(st.Trains), len(st.Reports), len(st.Segments), len(st.Attachments),
+				tt.removed, tt.trains, tt.reports, tt.segments, tt.atts)
+		}
+		if (after != before) != tt.changed {
+			t.Errorf("after %v: version %d -> %d, want changed %v", tt.after, before, after, tt.changed)
+		}
+	}
+}
+
+func TestConcurrentReports(t *testing.T) {
+	clock := testNow
+	tracker := testTracker(&clock)
+	tracker.Events = NewHub()
+	sub := tracker.Events.Subscribe("", "", 1)
+	defer sub.Close()
+
+	const trains, reports = 8, 50
+	var wg sync.WaitGroup
+	for i := 0; i < trains; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for n := 0; n < reports; n++ {
+				r := report(28.601+float64(n)*0.0001, 77.20, testNow.Add(time.Duration(n-reports)*time.Second))
+				r.TrainID, r.UserID = fmt.Sprintf("T%d", i), fmt.Sprintf("u%d", i)
+				if _, err := tracker.ProcessReport(r, testTrack()); err != nil {
+					t.Errorf("train %d report %d rejected: %v", i, n, err)
+					return
+				}
+			}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < reports; n++ {
+				tracker.Trains("yellow", "")
+				tracker.Snapshot()
+				tracker.CleanupStaleData()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(tracker.Trains("", "")); got != trains {
+		t.Errorf("got %d trains, want %d", got, trains)
+	}
+}
@@ -0,0 +1,432 @@
+package mapmatch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"metro-tools/internal/live"
+	"metro-tools/internal/topology"
+	"metro-tools/internal/validators"
+)
+
+// Model parameters, in metres unless noted
+const (
+	// CandidateRadius is how far from a fix segments are considered, on top of its accuracy
+	CandidateRadius = 250.0
+	// MinSigma is the smallest GPS error assumed, whatever accuracy a fix reports
+	MinSigma = 20.0
+	// MaxAccuracy is the largest GPS error trusted; worse fixes are clamped to it
+	MaxAccuracy = 200.0
+	// MaxCandidates is how many of the nearest segments are considered per fix
+	MaxCandidates = 8
+	// Beta scales how much the distance along the track may differ from the
+	// straight-line distance between two fixes
+	Beta = 60.0
+	// NoiseMeters of movement against the direction of travel are ignored
+	NoiseMeters = 40.0
+	// ReversalLogProb penalises a change of direction between two fixes
+	ReversalLogProb = -12.0
+	// LineChangeLogProb penalises a change of line between two fixes
+	LineChangeLogProb = -10.0
+	// BreakLogProb penalises restarting the model when no transition is possible
+	BreakLogProb = -30.0
+)
+
+// maxSpeed is the fastest a train moves, in metres per second
+const maxSpeed = live.MaxSpeed / 3.6
+
+// Point is one GPS fix
+type Point struct {
+	Lat      float64
+	Lng      float64
+	Time     time.Time // zero if unknown, which disables the speed check
+	Accuracy float64   // metres; 0 if unknown
+}
+
+// MatchedPoint is a fix snapped onto the segment it was matched to
+type MatchedPoint struct {
+	Index          int     `json:"index"` // position in the input
+	LineID         string  `json:"lineId"`
+	Direction      string  `json:"direction"`
+	FromStationID  string  `json:"fromStationId"` // in the direction of travel
+	ToStationID    string  `json:"toStationId"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	DistanceMeters float64 `json:"distanceMeters"` // from the fix to the track
+}
+
+// Match is the most likely journey behind a trace. The line, direction and
+// segment are those of the last matched fix.
+type Match struct {
+	LineID        string `json:"lineId"`
+	Direction     string `json:"direction"`
+	FromStationID string `json:"fromStationId"`
+	ToStationID   string `json:"toStationId"`
+	// Probability is the share of the final likelihood on this line and direction
+	Probability float64        `json:"probability"`
+	Points      []MatchedPoint `json:"points"`
+	// Skipped are the indexes of fixes too far from any line to use
+	Skipped []int `json:"skipped"`
+}
+
+// lineGeometry is one line's track with the distances needed for transitions
+type lineGeometry struct {
+	id       string
+	segments []live.Segment
+	lengths  []float64
+	// chain is each station's distance along the line in the forward direction
+	chain map[string]float64
+	// dist is the shortest track distance between any two stations
+	dist map[string]map[string]float64
+}
+
+// Network is the track geometry of every line
+type Network struct {
+	lines []*lineGeometry
+}
+
+// NewNetwork prepares line geometry, as built by live.BuildTracks, for matching
+func NewNetwork(tracks map[string][]live.Segment) *Network {
+	ids := make([]string, 0, len(tracks))
+	for id := range tracks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	n := &Network{}
+	for _, id := range ids {
+		g := &lineGeometry{id: id, segments: tracks[id], chain: make(map[string]float64)}
+		adjacent := make(map[string]map[string]float64)
+		link := func(a, b string, d float64) {
+			if adjacent[a] == nil {
+				adjacent[a] = make(map[string]float64)
+			}
+			if old, ok := adjacent[a][b]; !ok || d < old {
+				adjacent[a][b] = d
+			}
+		}
+		arriving := make(map[string]bool)
+		for _, s := range g.segments {
+			d := validators.HaversineDistance(s.A.Lat, s.A.Lng, s.B.Lat, s.B.Lng) * 1000
+			g.lengths = append(g.lengths, d)
+			link(s.From, s.To, d)
+			link(s.To, s.From, d)
+			arriving[s.To] = true
+		}
+
+		// Chainage runs from the forward termini, continuing onto branches
+		var queue []string
+		for _, s := range g.segments {
+			if _, seen := g.chain[s.From]; !arriving[s.From] && !seen {
+				g.chain[s.From] = 0
+				queue = append(queue, s.From)
+			}
+		}
+		for len(queue) > 0 {
+			at := queue[0]
+			queue = queue[1:]
+			for i, s := range g.segments {
+				if _, seen := g.chain[s.To]; s.From == at && !seen {
+					g.chain[s.To] = g.chain[at] + g.lengths[i]
+					queue = append(queue, s.To)
+				}
+			}
+		}
+
+		g.dist = make(map[string]map[string]float64)
+		for station := range adjacent {
+			g.dist[station] = shortestDistances(station, adjacent)
+		}
+		n.lines = append(n.lines, g)
+	}
+	return n
+}
+
+// shortestDistances runs Dijkstra over a line's stations; lines are small
+// enough that a linear scan for the next station is fine
+func shortestDistances(from string, adjacent map[string]map[string]float64) map[string]float64 {
+	dist := map[string]float64{from: 0}
+	done := make(map[string]bool)
+	for {
+		at, best := "", math.Inf(1)
+		for s, d := range dist {
+			if !done[s] && d < best {
+				at, best = s, d
+			}
+		}
+		if at == "" {
+			return dist
+		}
+		done[at] = true
+		for next, d := range adjacent[at] {
+			if old, ok := dist[next]; !ok || best+d < old {
+				dist[next] = best + d
+			}
+		}
+	}
+}
+
+// candidate is one hidden state: a fix snapped to a segment, travelling one way
+type candidate struct {
+	line     *lineGeometry
+	segment  int
+	forward  bool
+	lat, lng float64
+	t        float64 // position along the segment, 0 at From and 1 at To
+	distance float64 // metres from the fix
+	emission float64 // log probability of the fix given this state
+	score    float64 // best log probability of any path ending here
+	back     int     // index of the previous candidate on that path, or -1
+}
+
+// chain returns the candidate's distance along its line
+func (c *candidate) chain() float64 {
+	s := c.line.segments[c.segment]
+	return c.line.chain[s.From] + c.t*c.line.lengths[c.segment]
+}
+
+// Match finds the most likely line, direction and segments for a trace.
+// Each fix is snapped to the nearby segments of every line, and a hidden
+// Markov model picks the snaps that best explain both the fixes and the
+// distances travelled between them (Newson & Krumm, 2009), so parallel lines
+// and interchange areas are told apart by where the rider goes next.
+// keep, if not nil, limits matching to some lines, such as those of a city.
+func (n *Network) Match(points []Point, keep func(lineID string) bool) (*Match, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no GPS points to match")
+	}
+	// The speed check needs time to run forwards between timed fixes
+	timed := -1
+	for i, p := range points {
+		if p.Time.IsZero() {
+			continue
+		}
+		if timed >= 0 && !p.Time.After(points[timed].Time) {
+			return nil, fmt.Errorf("point %d is not after point %d", i, timed)
+		}
+		timed = i
+	}
+
+	var steps [][]*candidate
+	var stepPoint []int
+	var skipped []int
+	for i, p := range points {
+		cands := n.candidates(p, keep)
+		if len(cands) == 0 {
+			skipped = append(skipped, i)
+			continue
+		}
+
+		if len(steps) == 0 {
+			for _, c := range cands {
+				c.score, c.back = c.emission, -1
+			}
+		} else {
+			viterbiStep(steps[len(steps)-1], cands, points[stepPoint[len(stepPoint)-1]], p)
+		}
+		steps = append(steps, cands)
+		stepPoint = append(stepPoint, i)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no GPS point is within %.0fm of a line", CandidateRadius)
+	}
+
+	last := steps[len(steps)-1]
+	best := 0
+	for i, c := range last {
+		if c.score > last[best].score {
+			best = i
+		}
+	}
+
+	m := &Match{Skipped: skipped, Probability: probability(last, last[best])}
+	if m.Skipped == nil {
+		m.Skipped = []int{}
+	}
+	m.Points = make([]MatchedPoint, len(steps))
+	c := last[best]
+	for step := len(steps) - 1; step >= 0; step-- {
+		m.Points[step] = c.matched(stepPoint[step])
+		if c.back >= 0 {
+			c = steps[step-1][c.back]
+		}
+	}
+
+	final := m.Points[len(m.Points)-1]
+	m.LineID, m.Direction = final.LineID, final.Direction
+	m.FromStationID, m.ToStationID = final.FromStationID, final.ToStationID
+	return m, nil
+}
+
+// candidates snaps a fix to the nearest segments within reach, once per
+// direction. Bounding both keeps each Viterbi step small however poor the fix.
+func (n *Network) candidates(p Point, keep func(string) bool) []*candidate {
+	accuracy := math.Min(math.Max(p.Accuracy, 0), MaxAccuracy)
+	sigma := math.Max(accuracy, MinSigma)
+	radius := CandidateRadius + accuracy
+
+	var near []*candidate
+	for _, g := range n.lines {
+		if keep != nil && !keep(g.id) {
+			continue
+		}
+		for i, s := range g.segments {
+			lat, lng, t := project(p.Lat, p.Lng, s.A, s.B)
+			d := validators.HaversineDistance(p.Lat, p.Lng, lat, lng) * 1000
+			if d > radius {
+				continue
+			}
+			near = append(near, &candidate{
+				line: g, segment: i, lat: lat, lng: lng, t: t, distance: d,
+				emission: -0.5 * (d / sigma) * (d / sigma),
+			})
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool { return near[i].distance < near[j].distance })
+	if len(near) > MaxCandidates {
+		near = near[:MaxCandidates]
+	}
+
+	cands := make([]*candidate, 0, 2*len(near))
+	for _, c := range near {
+		backward := *c
+		c.forward = true
+		cands = append(cands, c, &backward)
+	}
+	return cands
+}
+
+// viterbiStep scores each candidate of a fix by its best predecessor. When
+// no predecessor can reach a candidate, the model restarts from the best
+// path so far with a penalty rather than losing the trace.
+func viterbiStep(prev, cands []*candidate, from, to Point) {
+	straight := validators.HaversineDistance(from.Lat, from.Lng, to.Lat, to.Lng) * 1000
+	seconds := -1.0
+	if !from.Time.IsZero() && !to.Time.IsZero() {
+		seconds = to.Time.Sub(from.Time).Seconds()
+	}
+
+	bestPrev := 0
+	for i, a := range prev {
+		if a.score > prev[bestPrev].score {
+			bestPrev = i
+		}
+	}
+
+	for _, b := range cands {
+		b.score, b.back = math.Inf(-1), -1
+		for i, a := range prev {
+			logp := transition(a, b, straight, seconds)
+			if s := a.score + logp; s > b.score {
+				b.score, b.back = s, i
+			}
+		}
+		if math.IsInf(b.score, -1) {
+			b.score, b.back = prev[bestPrev].score+BreakLogProb, bestPrev
+		}
+		b.score += b.emission
+	}
+}
+
+// transition is the log probability of moving from one state to another
+// between two fixes straight metres apart, seconds apart (negative if
+// unknown; Match rejects traces where time stands still or runs backwards)
+func transition(a, b *candidate, straight, seconds float64) float64 {
+	if a.line != b.line {
+		// Changing line means walking across an interchange, not riding
+		return LineChangeLogProb - straight/Beta
+	}
+
+	route := a.line.trackDistance(a, b)
+	if seconds > 0 && (route-2*MinSigma)/seconds > maxSpeed {
+		return math.Inf(-1)
+	}
+
+	logp := -math.Abs(straight-route) / Beta
+	if a.forward != b.forward {
+		logp += ReversalLogProb
+	}
+	progress := b.chain() - a.chain()
+	if !b.forward {
+		progress = -progress
+	}
+	if progress < -NoiseMeters {
+		logp -= (-progress - NoiseMeters) / Beta
+	}
+	return logp
+}
+
+// trackDistance is the shortest distance along the line between two snapped positions
+func (g *lineGeometry) trackDistance(a, b *candidate) float64 {
+	if a.segment == b.segment {
+		return math.Abs(a.t-b.t) * g.lengths[a.segment]
+	}
+	sa, sb := g.segments[a.segment], g.segments[b.segment]
+	la, lb := g.lengths[a.segment], g.lengths[b.segment]
+	ends := []struct {
+		station string
+		meters  float64
+	}{{sa.From, a.t * la}, {sa.To, (1 - a.t) * la}}
+	starts := []struct {
+		station string
+		meters  float64
+	}{{sb.From, b.t * lb}, {sb.To, (1 - b.t) * lb}}
+
+	best := math.Inf(1)
+	for _, e := range ends {
+		for _, s := range starts {
+			if between, ok := g.dist[e.station][s.station]; ok {
+				best = math.Min(best, e.meters+between+s.meters)
+			}
+		}
+	}
+	return best
+}
+
+// matched describes a candidate as a snapped fix, with stations in travel order
+func (c *candidate) matched(index int) MatchedPoint {
+	s := c.line.segments[c.segment]
+	p := MatchedPoint{
+		Index: index, LineID: c.line.id, Direction: topology.Forward,
+		FromStationID: s.From, ToStationID: s.To,
+		Latitude: c.lat, Longitude: c.lng, DistanceMeters: math.Round(c.distance*10) / 10,
+	}
+	if !c.forward {
+		p.Direction = topology.Backward
+		p.FromStationID, p.ToStationID = s.To, s.From
+	}
+	return p
+}
+
+// probability is the share of the final likelihood held by paths ending on
+// the best candidate's line and direction
+func probability(last []*candidate, best *candidate) float64 {
+	var total, same float64
+	for _, c := range last {
+		w := math.Exp(c.score - best.score)
+		total += w
+		if c.line == best.line && c.forward == best.forward {
+			same += w
+		}
+	}
+	return math.Round(same/total*1000) / 1000
+}
+
+// project finds the closest point of segment a-b to a fix, working in
+// metres on a local flat projection, and its position t along the segment
+func project(lat, lng float64, a, b live.LatLng) (float64, float64, float64) {
+	scale := math.Cos(lat * math.Pi / 180)
+	ax, ay := a.Lng*scale, a.Lat
+	bx, by := b.Lng*scale, b.Lat
+	px, py := lng*scale, lat
+
+	dx, dy := bx-ax, by-ay
+	lenSq := dx*dx + dy*dy
+	t := 0.0
+	if lenSq > 0 {
+		t = math.Max(0, math.Min(1, ((px-ax)*dx+(py-ay)*dy)/lenSq))
+	}
+	return a.Lat + t*(b.Lat-a.Lat), a.Lng + t*(b.Lng-a.Lng), t
+}
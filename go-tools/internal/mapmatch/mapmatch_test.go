@@ -0,0 +1,198 @@
+package mapmatch
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"metro-tools/internal/live"
+	"metro-tools/internal/topology"
+	"metro-tools/internal/validators"
+)
+
+type stop struct {
+	id       string
+	lat, lng float64
+}
+
+// line joins stations in forward order
+func line(id string, stops ...stop) []live.Segment {
+	var segments []live.Segment
+	for i := 0; i+1 < len(stops); i++ {
+		a, b := stops[i], stops[i+1]
+		segments = append(segments, live.Segment{
+			LineID: id, From: a.id, To: b.id,
+			A: live.LatLng{Lat: a.lat, Lng: a.lng}, B: live.LatLng{Lat: b.lat, Lng: b.lng},
+		})
+	}
+	return segments
+}
+
+// testNetwork has lines P and Q running east side by side about 110m apart,
+// until Q turns north at q3. R is a separate line 3km south.
+func testNetwork() *Network {
+	return NewNetwork(map[string][]live.Segment{
+		"P": line("P", stop{"p1", 28.600, 77.200}, stop{"p2", 28.600, 77.210}, stop{"p3", 28.600, 77.220}, stop{"p4", 28.600, 77.230}),
+		"Q": line("Q", stop{"q1", 28.601, 77.200}, stop{"q2", 28.601, 77.210}, stop{"q3", 28.601, 77.220}, stop{"q4", 28.611, 77.220}),
+		"R": line("R", stop{"r1", 28.573, 77.200}, stop{"r2", 28.573, 77.230}),
+	})
+}
+
+// trace spaces fixes 30 seconds apart
+func trace(coords ...[2]float64) []Point {
+	start := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	points := make([]Point, len(coords))
+	for i, c := range coords {
+		points[i] = Point{Lat: c[0], Lng: c[1], Time: start.Add(time.Duration(i*30) * time.Second)}
+	}
+	return points
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		points    []Point
+		keep      func(string) bool
+		line      string
+		direction string
+		from, to  string
+		skipped   []int
+	}{
+		{
+			// Halfway between P and Q until the trace turns north with Q
+			name: "parallel lines told apart by where the trace goes",
+			points: trace([2]float64{28.6005, 77.202}, [2]float64{28.6005, 77.207}, [2]float64{28.6005, 77.212},
+				[2]float64{28.6005, 77.217}, [2]float64{28.6040, 77.2201}, [2]float64{28.6080, 77.2201}),
+			line: "Q", direction: topology.Forward, from: "q3", to: "q4", skipped: []int{},
+		},
+		{
+			name: "travelling west is backward",
+			points: trace([2]float64{28.5999, 77.228}, [2]float64{28.5999, 77.224}, [2]float64{28.5999, 77.2195},
+				[2]float64{28.6004, 77.212}, [2]float64{28.6004, 77.207}),
+			line: "P", direction: topology.Backward, from: "p2", to: "p1", skipped: []int{},
+		},
+		{
+			name: "fixes far from every line are skipped",
+			points: trace([2]float64{28.5999, 77.228}, [2]float64{28.650, 77.300}, [2]float64{28.5999, 77.224},
+				[2]float64{28.5999, 77.2195}),
+			line: "P", direction: topology.Backward, from: "p3", to: "p2", skipped: []int{1},
+		},
+		{
+			name:   "keep limits the lines matched",
+			points: trace([2]float64{28.6005, 77.202}, [2]float64{28.6005, 77.207}, [2]float64{28.6005, 77.212}),
+			keep:   func(id string) bool { return id == "P" },
+			line:   "P", direction: topology.Forward, from: "p2", to: "p3", skipped: []int{},
+		},
+	}
+	n := testNetwork()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := n.Match(tt.points, tt.keep)
+			if err != nil {
+				t.Fatalf("Match: %v", err)
+			}
+			if m.LineID != tt.line || m.Direction != tt.direction || m.FromStationID != tt.from || m.ToStationID != tt.to {
+				t.Errorf("matched %s %s %s->%s, want %s %s %s->%s",
+					m.LineID, m.Direction, m.FromStationID, m.ToStationID, tt.line, tt.direction, tt.from, tt.to)
+			}
+			if len(m.Skipped) != len(tt.skipped) {
+				t.Fatalf("skipped %v, want %v", m.Skipped, tt.skipped)
+			}
+			for i := range tt.skipped {
+				if m.Skipped[i] != tt.skipped[i] {
+					t.Errorf("skipped %v, want %v", m.Skipped, tt.skipped)
+				}
+			}
+			if len(m.Points)+len(m.Skipped) != len(tt.points) {
+				t.Errorf("%d matched and %d skipped of %d fixes", len(m.Points), len(m.Skipped), len(tt.points))
+			}
+			// The whole trace is on one line in one direction
+			for _, p := range m.Points {
+				if p.LineID != tt.line || p.Direction != tt.direction {
+					t.Errorf("fix %d matched to %s %s", p.Index, p.LineID, p.Direction)
+				}
+			}
+			if m.Probability <= 0.5 || m.Probability > 1 {
+				t.Errorf("probability = %v, want the matched line to hold most of it", m.Probability)
+			}
+		})
+	}
+}
+
+func TestMatchErrors(t *testing.T) {
+	n := testNetwork()
+	if _, err := n.Match(nil, nil); err == nil {
+		t.Error("Match(nil) succeeded, want an error")
+	}
+	if _, err := n.Match(trace([2]float64{28.650, 77.300}), nil); err == nil {
+		t.Error("Match of a fix far from every line succeeded, want an error")
+	}
+
+	// A 2km jump is only plausible if the clock is believed, so time must run forwards
+	for _, tt := range []struct {
+		name string
+		gap  time.Duration
+	}{{"same time", 0}, {"back in time", -30 * time.Second}} {
+		points := trace([2]float64{28.6005, 77.202}, [2]float64{28.6005, 77.207}, [2]float64{28.6005, 77.228})
+		points[1].Time = time.Time{} // unknown times are not compared
+		points[2].Time = points[0].Time.Add(tt.gap)
+		if _, err := n.Match(points, nil); err == nil || err.Error() != "point 2 is not after point 0" {
+			t.Errorf("%s: Match error = %v, want point 2 is not after point 0", tt.name, err)
+		}
+	}
+}
+
+func TestCandidatesBounded(t *testing.T) {
+	n := testNetwork()
+	tests := []struct {
+		name     string
+		accuracy float64
+		lines    map[string]bool
+	}{
+		{"precise fix sees only nearby lines", 10, map[string]bool{"P": true, "Q": true}},
+		{"negative accuracy counts as unknown", -500, map[string]bool{"P": true, "Q": true}},
+		// R is 3km away: an unclamped 5km accuracy would reach it
+		{"poor accuracy is clamped", 5000, map[string]bool{"P": true, "Q": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Point{Lat: 28.6005, Lng: 77.215, Accuracy: tt.accuracy}
+			cands := n.candidates(p, nil)
+			if len(cands) == 0 || len(cands) > 2*MaxCandidates {
+				t.Fatalf("got %d candidates, want 1 to %d", len(cands), 2*MaxCandidates)
+			}
+			limit := CandidateRadius + math.Min(math.Max(tt.accuracy, 0), MaxAccuracy)
+			for _, c := range cands {
+				if !tt.lines[c.line.id] {
+					t.Errorf("candidate on line %s", c.line.id)
+				}
+				if d := validators.HaversineDistance(p.Lat, p.Lng, c.lat, c.lng) * 1000; d > limit+0.5 {
+					t.Errorf("candidate %.0fm away, beyond %.0fm", d, limit)
+				}
+			}
+		})
+	}
+}
+
+func TestCandidatesNearestFirst(t *testing.T) {
+	// A dense line puts more segments in reach than MaxCandidates
+	var stops []stop
+	for i := 0; i < 40; i++ {
+		stops = append(stops, stop{string(rune('a'+i%26)) + string(rune('0'+i/26)), 28.600, 77.200 + float64(i)*0.0005})
+	}
+	n := NewNetwork(map[string][]live.Segment{"D": line("D", stops...)})
+	cands := n.candidates(Point{Lat: 28.6001, Lng: 77.210}, nil)
+	if len(cands) != 2*MaxCandidates {
+		t.Fatalf("got %d candidates, want %d", len(cands), 2*MaxCandidates)
+	}
+	for i := 2; i < len(cands); i += 2 {
+		if cands[i].distance < cands[i-2].distance {
+			t.Errorf("candidate %d is nearer than candidate %d", i, i-2)
+		}
+	}
+	for i := 0; i < len(cands); i += 2 {
+		if !cands[i].forward || cands[i+1].forward || cands[i].segment != cands[i+1].segment {
+			t.Errorf("candidates %d and %d are not the two directions of one segment", i, i+1)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package names
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"metro-tools/internal/database"
+)
+
+// Default is the language of MetroStation.Name
+const Default = "en"
+
+// Scripts maps language codes to the script their names are written in
+var Scripts = map[string]*unicode.RangeTable{
+	"en": unicode.Latin,
+	"hi": unicode.Devanagari,
+	"mr": unicode.Devanagari,
+	"kn": unicode.Kannada,
+	"bn": unicode.Bengali,
+	"ta": unicode.Tamil,
+	"te": unicode.Telugu,
+	"ur": unicode.Arabic,
+}
+
+// Languages maps city IDs to the languages every station of the city must
+// be named in, besides English, in configured order
+type Languages map[string][]string
+
+// NewLanguages groups city_languages rows by city
+func NewLanguages(rows []database.CityLanguage) Languages {
+	languages := make(Languages)
+	for _, l := range rows {
+		languages[l.CityID] = append(languages[l.CityID], l.Lang)
+	}
+	return languages
+}
+
+// MatchesScript reports whether every letter of text is in the script of
+// lang. Spaces, digits and punctuation are allowed in any script. known is
+// false for languages without a script in Scripts.
+func MatchesScript(text, lang string) (ok, known bool) {
+	script, known := Scripts[lang]
+	if !known {
+		return false, false
+	}
+	for _, r := range text {
+		if unicode.In(r, unicode.Common, unicode.Inherited) {
+			continue
+		}
+		if (unicode.IsLetter(r) || unicode.IsMark(r)) && !unicode.Is(script, r) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// Catalog looks up station names by language
+type Catalog struct {
+	names     map[string]map[string]database.StationName
+	langs     map[string]map[string]bool // city ID -> languages with names
+	languages Languages
+}
+
+// NewCatalog indexes station_names rows by station and by the city each
+// station is in; languages are the ones each city offers besides English
+func NewCatalog(rows []database.StationName, stations []database.MetroStation, languages Languages) *Catalog {
+	c := &Catalog{
+		names:     make(map[string]map[string]database.StationName),
+		langs:     make(map[string]map[string]bool),
+		languages: languages,
+	}
+	cityOf := make(map[string]string)
+	for _, st := range stations {
+		cityOf[st.ID] = st.CityID
+	}
+	for _, n := range rows {
+		city := cityOf[n.StationID]
+		if c.langs[city] == nil {
+			c.langs[city] = make(map[string]bool)
+		}
+		c.langs[city][n.Lang] = true
+
+		if c.names[n.StationID] == nil {
+			c.names[n.StationID] = make(map[string]database.StationName)
+		}
+		c.names[n.StationID][n.Lang] = n
+	}
+	return c
+}
+
+// Lookup returns the name of a station in a language
+func (c *Catalog) Lookup(stationID, lang string) (database.StationName, bool) {
+	n, ok := c.names[stationID][lang]
+	return n, ok
+}
+
+// Available returns the languages station names of a city can be shown in:
+// English, then each configured language that has names
+func (c *Catalog) Available(cityID string) []string {
+	available := []string{Default}
+	for _, lang := range c.languages[cityID] {
+		if c.langs[cityID][lang] {
+			available = append(available, lang)
+		}
+	}
+	return available
+}
+
+// All returns every name of a station, ordered by language
+func (c *Catalog) All(stationID string) []database.StationName {
+	var all []database.StationName
+	for _, n := range c.names[stationID] {
+		all = append(all, n)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Lang < all[j].Lang })
+	return all
+}
+
+// Negotiate picks the first language of an Accept-Language header that is
+// available, by base language ("hi-IN" matches "hi"), defaulting to English
+func Negotiate(header string, available []string) string {
+	for _, lang := range ParseAcceptLanguage(header) {
+		for _, a := range available {
+			if lang == a {
+				return a
+			}
+		}
+	}
+	return Default
+}
+
+// ParseAcceptLanguage returns the base languages of an Accept-Language
+// header in order of preference, dropping those with q=0
+func ParseAcceptLanguage(header string) []string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		if i := strings.IndexByte(tag, '-'); i > 0 {
+			tag = tag[:i]
+		}
+		prefs = append(prefs, pref{tag, q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	langs := make([]string, 0, len(prefs))
+	for _, p := range prefs {
+		langs = append(langs, p.lang)
+	}
+	return langs
+}
@@ -0,0 +1,121 @@
+package names
+
+import (
+	"reflect"
+	"testing"
+
+	"metro-tools/internal/database"
+)
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"hi", []string{"hi"}},
+		{"hi-IN, en;q=0.8", []string{"hi", "en"}},
+		{"en;q=0.5, kn-IN;q=0.9, ta", []string{"ta", "kn", "en"}},
+		{"EN-gb", []string{"en"}},
+		{"hi;q=0, en", []string{"en"}},
+		{"mr;q=0.7, bn;q=0.7", []string{"mr", "bn"}},
+		{"te;q=bad", []string{"te"}},
+		{" , ;q=1, ur ", []string{"ur"}},
+		{"*", []string{"*"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	available := []string{"en", "hi"}
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"hi-IN,hi;q=0.9,en;q=0.8", "hi"},
+		{"en-US,hi;q=0.5", "en"},
+		{"kn, hi;q=0.4", "hi"},
+		{"kn, ta", "en"},
+		{"hi;q=0", "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := Negotiate(tt.header, available); got != tt.want {
+				t.Errorf("Negotiate(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesScript(t *testing.T) {
+	tests := []struct {
+		text, lang string
+		ok, known  bool
+	}{
+		{"राजीव चौक", "hi", true, true},
+		{"Rajiv Chowk", "hi", false, true},
+		{"सेक्टर 18 (नोएडा)", "hi", true, true},
+		{"ಮೆಜೆಸ್ಟಿಕ್", "kn", true, true},
+		{"ಮೆಜೆಸ್ಟಿಕ್", "ta", false, true},
+		{"Majestic", "en", true, true},
+		{"Majestic", "fr", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.text, func(t *testing.T) {
+			ok, known := MatchesScript(tt.text, tt.lang)
+			if ok != tt.ok || known != tt.known {
+				t.Errorf("MatchesScript(%q, %q) = %v, %v, want %v, %v", tt.text, tt.lang, ok, known, tt.ok, tt.known)
+			}
+		})
+	}
+}
+
+func TestCatalogAvailable(t *testing.T) {
+	stations := []database.MetroStation{
+		{ID: "rajiv-chowk", CityID: "delhi"},
+		{ID: "majestic", CityID: "bangalore"},
+	}
+	rows := []database.StationName{
+		{StationID: "rajiv-chowk", Lang: "hi", Name: "राजीव चौक"},
+		{StationID: "rajiv-chowk", Lang: "ur", Name: "راجیو چوک"},
+		{StationID: "majestic", Lang: "kn", Name: "ಮೆಜೆಸ್ಟಿಕ್"},
+	}
+	languages := NewLanguages([]database.CityLanguage{
+		{CityID: "delhi", Lang: "hi"},
+		{CityID: "bangalore", Lang: "kn"},
+		{CityID: "bangalore", Lang: "ta"},
+	})
+	c := NewCatalog(rows, stations, languages)
+
+	tests := []struct {
+		city string
+		want []string
+	}{
+		// ur has names but is not configured, ta is configured but has none
+		{"delhi", []string{"en", "hi"}},
+		{"bangalore", []string{"en", "kn"}},
+		{"mumbai", []string{"en"}},
+	}
+	for _, tt := range tests {
+		if got := c.Available(tt.city); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Available(%s) = %q, want %q", tt.city, got, tt.want)
+		}
+	}
+
+	if n, ok := c.Lookup("majestic", "kn"); !ok || n.Name != "ಮೆಜೆಸ್ಟಿಕ್" {
+		t.Errorf("Lookup(majestic, kn) = %+v, %v", n, ok)
+	}
+	if _, ok := c.Lookup("majestic", "hi"); ok {
+		t.Error("Lookup(majestic, hi) found a name that does not exist")
+	}
+	if all := c.All("rajiv-chowk"); len(all) != 2 || all[0].Lang != "hi" || all[1].Lang != "ur" {
+		t.Errorf("All(rajiv-chowk) = %+v, want hi then ur", all)
+	}
+}
@@ -0,0 +1,119 @@
+package rules
+
+import (
+	"metro-tools/internal/database"
+)
+
+// Network is the loaded metro data that rules are evaluated against
+type Network struct {
+	Cities       []database.City
+	Lines        []database.MetroLine
+	Stations     []database.MetroStation
+	LineStations []database.LineStation
+	Connections  []database.StationConnection
+
+	linesByCity     map[string][]database.MetroLine
+	stationsByCity  map[string][]database.MetroStation
+	linesByStation  map[string][]string
+	terminals       map[string]bool
+	stationsByID    map[string]database.MetroStation
+	connectionsFrom map[string][]database.StationConnection
+}
+
+// NewNetwork builds a Network and its lookup indexes
+func NewNetwork(
+	cities []database.City,
+	lines []database.MetroLine,
+	stations []database.MetroStation,
+	lineStations []database.LineStation,
+	connections []database.StationConnection,
+) *Network {
+	n := &Network{
+		Cities:          cities,
+		Lines:           lines,
+		Stations:        stations,
+		LineStations:    lineStations,
+		Connections:     connections,
+		linesByCity:     make(map[string][]database.MetroLine),
+		stationsByCity:  make(map[string][]database.MetroStation),
+		linesByStation:  make(map[string][]string),
+		terminals:       make(map[string]bool),
+		stationsByID:    make(map[string]database.MetroStation),
+		connectionsFrom: make(map[string][]database.StationConnection),
+	}
+
+	for _, l := range lines {
+		n.linesByCity[l.CityID] = append(n.linesByCity[l.CityID], l)
+	}
+	for _, s := range stations {
+		n.stationsByCity[s.CityID] = append(n.stationsByCity[s.CityID], s)
+		n.stationsByID[s.ID] = s
+	}
+	for _, c := range connections {
+		n.connectionsFrom[c.FromStationID] = append(n.connectionsFrom[c.FromStationID], c)
+	}
+
+	// Terminals are the first and last station (by sequence) of each line
+	type bounds struct {
+		minSeq, maxSeq         int
+		minStation, maxStation string
+	}
+	lineBounds := make(map[string]*bounds)
+	seen := make(map[string]bool)
+	for _, ls := range lineStations {
+		key := ls.StationID + "@" + ls.LineID
+		if !seen[key] {
+			seen[key] = true
+			n.linesByStation[ls.StationID] = append(n.linesByStation[ls.StationID], ls.LineID)
+		}
+
+		b, ok := lineBounds[ls.LineID]
+		if !ok {
+			lineBounds[ls.LineID] = &bounds{ls.SequenceNumber, ls.SequenceNumber, ls.StationID, ls.StationID}
+			continue
+		}
+		if ls.SequenceNumber < b.minSeq {
+			b.minSeq, b.minStation = ls.SequenceNumber, ls.StationID
+		}
+		if ls.SequenceNumber > b.maxSeq {
+			b.maxSeq, b.maxStation = ls.SequenceNumber, ls.StationID
+		}
+	}
+	for _, b := range lineBounds {
+		n.terminals[b.minStation] = true
+		n.terminals[b.maxStation] = true
+	}
+
+	return n
+}
+
+// LinesInCity returns the lines belonging to a city
+func (n *Network) LinesInCity(cityID string) []database.MetroLine {
+	return n.linesByCity[cityID]
+}
+
+// StationsInCity returns the stations belonging to a city
+func (n *Network) StationsInCity(cityID string) []database.MetroStation {
+	return n.stationsByCity[cityID]
+}
+
+// LinesAt returns the IDs of the lines serving a station
+func (n *Network) LinesAt(stationID string) []string {
+	return n.linesByStation[stationID]
+}
+
+// IsTerminal reports whether a station is the first or last stop of any line
+func (n *Network) IsTerminal(stationID string) bool {
+	return n.terminals[stationID]
+}
+
+// Station returns a station by ID
+func (n *Network) Station(stationID string) (database.MetroStation, bool) {
+	s, ok := n.stationsByID[stationID]
+	return s, ok
+}
+
+// ConnectionsFrom returns the connections leaving a station
+func (n *Network) ConnectionsFrom(stationID string) []database.StationConnection {
+	return n.connectionsFrom[stationID]
+}
@@ -0,0 +1,245 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"metro-tools/internal/colors"
+	"metro-tools/internal/database"
+	"metro-tools/internal/validators"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Rule targets
+const (
+	TargetCity       = "city"
+	TargetLine       = "line"
+	TargetStation    = "station"
+	TargetConnection = "connection"
+)
+
+// Rule is a custom validation rule written as expressions
+type Rule struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description,omitempty"`
+	Target      string              `json:"target"`           // city, line, station or connection
+	Cities      []string            `json:"cities,omitempty"` // limit the rule to these city IDs
+	When        string              `json:"when,omitempty"`   // optional filter expression
+	Assert      string              `json:"assert"`           // must evaluate to true
+	Severity    validators.Severity `json:"severity,omitempty"`
+	Message     string              `json:"message,omitempty"`
+
+	when   *vm.Program
+	assert *vm.Program
+}
+
+// RuleSet is the contents of a rules file
+type RuleSet struct {
+	Rules []*Rule `json:"rules"`
+}
+
+// Load reads and compiles a JSON rules file
+func Load(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rs RuleSet
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+
+	if err := rs.Compile(); err != nil {
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// Compile type-checks and compiles every rule in the set
+func (rs *RuleSet) Compile() error {
+	seen := make(map[string]bool)
+	for i, r := range rs.Rules {
+		if strings.TrimSpace(r.Name) == "" {
+			return fmt.Errorf("rule #%d has no name", i+1)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate rule name '%s'", r.Name)
+		}
+		seen[r.Name] = true
+
+		if r.Severity == "" {
+			r.Severity = validators.SeverityError
+		}
+		if r.Severity != validators.SeverityError && r.Severity != validators.SeverityWarning {
+			return fmt.Errorf("rule '%s': invalid severity '%s'", r.Name, r.Severity)
+		}
+
+		env, err := sampleEnv(r.Target)
+		if err != nil {
+			return fmt.Errorf("rule '%s': %w", r.Name, err)
+		}
+
+		if strings.TrimSpace(r.Assert) == "" {
+			return fmt.Errorf("rule '%s': assert expression is empty", r.Name)
+		}
+		r.assert, err = expr.Compile(r.Assert, expr.Env(env), expr.AsBool())
+		if err != nil {
+			return fmt.Errorf("rule '%s': invalid assert expression: %w", r.Name, err)
+		}
+
+		if strings.TrimSpace(r.When) != "" {
+			r.when, err = expr.Compile(r.When, expr.Env(env), expr.AsBool())
+			if err != nil {
+				return fmt.Errorf("rule '%s': invalid when expression: %w", r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate runs every rule against the network and collects the outcome
+func (rs *RuleSet) Evaluate(n *Network) *validators.Result {
+	result := validators.NewResult("rules")
+
+	cityByID := make(map[string]database.City)
+	for _, c := range n.Cities {
+		cityByID[c.ID] = c
+	}
+	lineByID := make(map[string]database.MetroLine)
+	for _, l := range n.Lines {
+		lineByID[l.ID] = l
+	}
+
+	for _, r := range rs.Rules {
+		switch r.Target {
+		case TargetCity:
+			for _, c := range n.Cities {
+				env := baseEnv(n)
+				env["city"] = c
+				r.check(result, c.ID, c.ID, env)
+			}
+		case TargetLine:
+			for _, l := range n.Lines {
+				env := baseEnv(n)
+				env["city"] = cityByID[l.CityID]
+				env["line"] = l
+				r.check(result, l.CityID, l.ID, env)
+			}
+		case TargetStation:
+			for _, s := range n.Stations {
+				env := baseEnv(n)
+				env["city"] = cityByID[s.CityID]
+				env["station"] = s
+				r.check(result, s.CityID, s.ID, env)
+			}
+		case TargetConnection:
+			for _, c := range n.Connections {
+				line := lineByID[c.LineID]
+				from, _ := n.Station(c.FromStationID)
+				to, _ := n.Station(c.ToStationID)
+				env := baseEnv(n)
+				env["city"] = cityByID[line.CityID]
+				env["line"] = line
+				env["connection"] = c
+				env["from"] = from
+				env["to"] = to
+				r.check(result, line.CityID, strconv.Itoa(c.ID), env)
+			}
+		}
+	}
+
+	return result
+}
+
+// check evaluates a single rule for one entity and records the outcome
+func (r *Rule) check(result *validators.Result, cityID, entityID string, env map[string]interface{}) {
+	if len(r.Cities) > 0 && !contains(r.Cities, cityID) {
+		return
+	}
+
+	if r.when != nil {
+		out, err := expr.Run(r.when, env)
+		if err != nil {
+			result.AddError(entityID, fmt.Sprintf("[%s] when expression failed: %v", r.Name, err))
+			return
+		}
+		if !out.(bool) {
+			return
+		}
+	}
+
+	out, err := expr.Run(r.assert, env)
+	if err != nil {
+		result.AddError(entityID, fmt.Sprintf("[%s] assert expression failed: %v", r.Name, err))
+		return
+	}
+	if out.(bool) {
+		result.AddPass()
+		return
+	}
+
+	message := r.Message
+	if message == "" {
+		message = fmt.Sprintf("Rule violated: %s", r.Assert)
+	}
+	message = fmt.Sprintf("[%s] %s", r.Name, message)
+
+	if r.Severity == validators.SeverityWarning {
+		result.AddWarning(entityID, message)
+		result.AddPass()
+	} else {
+		result.AddError(entityID, message)
+	}
+}
+
+// baseEnv returns the variables and helper functions available to every rule
+func baseEnv(n *Network) map[string]interface{} {
+	return map[string]interface{}{
+		"network":         n,
+		"linesInCity":     n.LinesInCity,
+		"stationsInCity":  n.StationsInCity,
+		"linesAt":         n.LinesAt,
+		"isTerminal":      n.IsTerminal,
+		"connectionsFrom": n.ConnectionsFrom,
+		"deltaE":          colors.DeltaE,
+		"contrastRatio":   colors.ContrastRatioHex,
+		"distanceKm":      validators.HaversineDistance,
+	}
+}
+
+// sampleEnv returns a zero-valued environment used to type-check a target's expressions
+func sampleEnv(target string) (map[string]interface{}, error) {
+	env := baseEnv(NewNetwork(nil, nil, nil, nil, nil))
+	env["city"] = database.City{}
+
+	switch target {
+	case TargetCity:
+	case TargetLine:
+		env["line"] = database.MetroLine{}
+	case TargetStation:
+		env["station"] = database.MetroStation{}
+	case TargetConnection:
+		env["line"] = database.MetroLine{}
+		env["connection"] = database.StationConnection{}
+		env["from"] = database.MetroStation{}
+		env["to"] = database.MetroStation{}
+	default:
+		return nil, fmt.Errorf("unknown target '%s' (expected city, line, station or connection)", target)
+	}
+	return env, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/validators"
+)
+
+func testNetwork() *Network {
+	return NewNetwork(
+		[]database.City{{ID: "delhi"}, {ID: "bangalore"}},
+		[]database.MetroLine{
+			{ID: "yellow", CityID: "delhi", Color: "#FFCC00"},
+			{ID: "blue", CityID: "delhi", Color: "#06B"},
+			{ID: "purple", CityID: "bangalore", Color: "#800080"},
+		},
+		[]database.MetroStation{
+			{ID: "a", CityID: "delhi", Latitude: 28.60, Longitude: 77.20},
+			{ID: "b", CityID: "delhi", Latitude: 28.61, Longitude: 77.20},
+			{ID: "c", CityID: "delhi", Latitude: 28.70, Longitude: 77.20},
+			{ID: "m", CityID: "bangalore", Latitude: 12.97, Longitude: 77.57},
+		},
+		[]database.LineStation{
+			{LineID: "yellow", StationID: "a", SequenceNumber: 1},
+			{LineID: "yellow", StationID: "b", SequenceNumber: 2},
+			{LineID: "yellow", StationID: "c", SequenceNumber: 3},
+			{LineID: "blue", StationID: "b", SequenceNumber: 1},
+		},
+		[]database.StationConnection{
+			{ID: 1, FromStationID: "a", ToStationID: "b", LineID: "yellow"},
+			{ID: 2, FromStationID: "b", ToStationID: "c", LineID: "yellow"},
+		},
+	)
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  string
+	}{
+		{"no name", Rule{Target: TargetCity, Assert: "true"}, "rule #1 has no name"},
+		{"unknown target", Rule{Name: "r", Target: "platform", Assert: "true"}, "unknown target 'platform'"},
+		{"bad severity", Rule{Name: "r", Target: TargetCity, Assert: "true", Severity: "fatal"}, "invalid severity 'fatal'"},
+		{"empty assert", Rule{Name: "r", Target: TargetCity, Assert: "  "}, "assert expression is empty"},
+		{"bad assert syntax", Rule{Name: "r", Target: TargetStation, Assert: "station.Latitude >"}, "invalid assert expression"},
+		{"assert not boolean", Rule{Name: "r", Target: TargetStation, Assert: "station.Name"}, "invalid assert expression"},
+		{"variable of another target", Rule{Name: "r", Target: TargetCity, Assert: "line.Color != ''"}, "invalid assert expression"},
+		{"unknown field", Rule{Name: "r", Target: TargetLine, Assert: "line.Colour != ''"}, "invalid assert expression"},
+		{"bad when", Rule{Name: "r", Target: TargetLine, When: "line.CityID", Assert: "true"}, "invalid when expression"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			err := (&RuleSet{Rules: []*Rule{&rule}}).Compile()
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Compile error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+
+	duplicate := &RuleSet{Rules: []*Rule{
+		{Name: "r", Target: TargetCity, Assert: "true"},
+		{Name: "r", Target: TargetLine, Assert: "true"},
+	}}
+	if err := duplicate.Compile(); err == nil || !strings.Contains(err.Error(), "duplicate rule name 'r'") {
+		t.Errorf("Compile error = %v, want a duplicate name", err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	type issue struct {
+		id       string
+		severity validators.Severity
+	}
+	tests := []struct {
+		name     string
+		rule     Rule
+		passed   int
+		issues   []issue
+		contains string // in every issue message
+	}{
+		{
+			name:   "all pass",
+			rule:   Rule{Target: TargetStation, Assert: "station.Latitude > 0"},
+			passed: 4,
+		},
+		{
+			name:     "errors by default",
+			rule:     Rule{Target: TargetLine, Assert: "len(line.Color) == 7"},
+			passed:   2,
+			issues:   []issue{{"blue", validators.SeverityError}},
+			contains: "[r] Rule violated: len(line.Color) == 7",
+		},
+		{
+			name:     "warnings still pass",
+			rule:     Rule{Target: TargetLine, Assert: "len(line.Color) == 7", Severity: validators.SeverityWarning, Message: "Use #RRGGBB"},
+			passed:   3,
+			issues:   []issue{{"blue", validators.SeverityWarning}},
+			contains: "[r] Use #RRGGBB",
+		},
+		{
+			name:   "when filters entities",
+			rule:   Rule{Target: TargetLine, When: "line.CityID == 'bangalore'", Assert: "len(line.Color) == 7"},
+			passed: 1,
+		},
+		{
+			name:   "cities filter",
+			rule:   Rule{Target: TargetStation, Cities: []string{"bangalore"}, Assert: "false"},
+			issues: []issue{{"m", validators.SeverityError}},
+		},
+		{
+			name:   "city target",
+			rule:   Rule{Target: TargetCity, Assert: "len(linesInCity(city.ID)) >= 2"},
+			passed: 1,
+			issues: []issue{{"bangalore", validators.SeverityError}},
+		},
+		{
+			name:   "connection helpers",
+			rule:   Rule{Target: TargetConnection, Assert: "distanceKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude) < 5"},
+			passed: 1,
+			issues: []issue{{"2", validators.SeverityError}},
+		},
+		{
+			name:   "network helpers",
+			rule:   Rule{Target: TargetStation, When: "city.ID == 'delhi'", Assert: "isTerminal(station.ID) || len(linesAt(station.ID)) > 1"},
+			passed: 3,
+		},
+		{
+			name:     "runtime failure is an error",
+			rule:     Rule{Target: TargetStation, Assert: "linesAt(station.ID)[0] != ''"},
+			passed:   3,
+			issues:   []issue{{"m", validators.SeverityError}},
+			contains: "assert expression failed",
+		},
+	}
+	n := testNetwork()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.Name = "r"
+			rs := &RuleSet{Rules: []*Rule{&rule}}
+			if err := rs.Compile(); err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			result := rs.Evaluate(n)
+
+			var got []issue
+			for _, i := range result.Issues {
+				got = append(got, issue{i.ID, i.Severity})
+				if !strings.Contains(i.Message, tt.contains) {
+					t.Errorf("message %q does not contain %q", i.Message, tt.contains)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.issues) {
+				t.Errorf("issues = %v, want %v", got, tt.issues)
+			}
+			if result.Passed != tt.passed {
+				t.Errorf("passed = %d, want %d", result.Passed, tt.passed)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name, json, err string
+	}{
+		{"valid", `{"rules":[{"name":"named","target":"station","assert":"station.Name != ''"}]}`, ""},
+		{"bad json", `{"rules":[`, "invalid rules file"},
+		{"bad rule", `{"rules":[{"name":"r","target":"station","assert":"station."}]}`, "rule 'r'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			rs, err := Load(path)
+			if tt.err == "" {
+				if err != nil || len(rs.Rules) != 1 || rs.Rules[0].Severity != validators.SeverityError {
+					t.Errorf("Load = %+v, %v, want one rule defaulting to errors", rs, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Load error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
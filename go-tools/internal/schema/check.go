@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/validators"
+)
+
+// Check compares the database schema against Expected and reports drift
+func Check(db *database.DB) (*validators.Result, error) {
+	result := validators.NewResult("schema")
+
+	actualTables, err := db.ListTables()
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(actualTables))
+	for _, t := range actualTables {
+		present[t] = true
+	}
+
+	known := make(map[string]bool, len(Expected))
+	for _, table := range Expected {
+		known[table.Name] = true
+
+		if !present[table.Name] {
+			if table.Optional {
+				result.AddPass()
+			} else {
+				result.AddError(table.Name, "Table is missing")
+			}
+			continue
+		}
+
+		valid, err := checkTable(db, table, result)
+		if err != nil {
+			return nil, err
+		}
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	// Tables created by migrations but never added to schema.ts
+	for _, t := range actualTables {
+		if !known[t] {
+			result.AddWarning(t, "Table exists in the database but not in schema.ts")
+		}
+	}
+
+	return result, nil
+}
+
+// checkTable compares columns, foreign keys and indexes of one table.
+// It returns false if any error-level drift was found.
+func checkTable(db *database.DB, table Table, result *validators.Result) (bool, error) {
+	valid := true
+
+	columns, err := db.TableInfo(table.Name)
+	if err != nil {
+		return false, err
+	}
+	actual := make(map[string]database.ColumnInfo, len(columns))
+	for _, c := range columns {
+		actual[c.Name] = c
+	}
+
+	expectedCols := make(map[string]bool, len(table.Columns))
+	for _, want := range table.Columns {
+		expectedCols[want.Name] = true
+		id := table.Name + "." + want.Name
+
+		got, ok := actual[want.Name]
+		if !ok {
+			result.AddError(id, fmt.Sprintf("Column is missing (expected %s%s)", want.Type, notNullSuffix(want.NotNull)))
+			valid = false
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(got.Type), want.Type) {
+			result.AddError(id, fmt.Sprintf("Type mismatch: database has '%s', schema.ts expects '%s'", got.Type, want.Type))
+			valid = false
+		}
+		if got.NotNull != want.NotNull && !want.PrimaryKey {
+			result.AddWarning(id, fmt.Sprintf("Nullability mismatch: database is %s, schema.ts expects %s", nullability(got.NotNull), nullability(want.NotNull)))
+		}
+		if got.PrimaryKey && !want.PrimaryKey {
+			result.AddWarning(id, "Column is a primary key in the database but not in schema.ts")
+		} else if !got.PrimaryKey && want.PrimaryKey {
+			result.AddWarning(id, "Column is not a primary key in the database but is in schema.ts")
+		}
+	}
+	for _, c := range columns {
+		if !expectedCols[c.Name] {
+			result.AddWarning(table.Name+"."+c.Name, "Column exists in the database but not in schema.ts")
+		}
+	}
+
+	fks, err := db.ForeignKeys(table.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, want := range table.ForeignKeys {
+		found := false
+		for _, got := range fks {
+			if got.Column == want.Column && got.RefTable == want.RefTable && got.RefColumn == want.RefColumn {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result.AddWarning(table.Name+"."+want.Column, fmt.Sprintf("Missing foreign key: REFERENCES %s(%s)", want.RefTable, want.RefColumn))
+		}
+	}
+
+	indexes, err := db.Indexes(table.Name)
+	if err != nil {
+		return false, err
+	}
+	for _, want := range table.Indexes {
+		if !hasCoveringIndex(indexes, want.Columns) {
+			result.AddWarning(table.Name, fmt.Sprintf("Missing index on (%s); suggested: CREATE INDEX %s ON %s(%s);",
+				strings.Join(want.Columns, ", "), want.Name, table.Name, strings.Join(want.Columns, ", ")))
+		}
+	}
+
+	return valid, nil
+}
+
+// hasCoveringIndex reports whether an index starts with the given columns
+func hasCoveringIndex(indexes []database.IndexInfo, columns []string) bool {
+	for _, idx := range indexes {
+		if len(idx.Columns) < len(columns) {
+			continue
+		}
+		match := true
+		for i, c := range columns {
+			if idx.Columns[i] != c {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func notNullSuffix(notNull bool) string {
+	if notNull {
+		return " NOT NULL"
+	}
+	return ""
+}
+
+func nullability(notNull bool) string {
+	if notNull {
+		return "NOT NULL"
+	}
+	return "nullable"
+}
@@ -0,0 +1,295 @@
+package schema
+
+// Column is an expected column definition
+type Column struct {
+	Name       string
+	Type       string // declared SQLite type: TEXT, INTEGER or REAL
+	NotNull    bool
+	PrimaryKey bool
+}
+
+// ForeignKey is an expected REFERENCES constraint
+type ForeignKey struct {
+	Column    string
+	RefTable  string
+	RefColumn string
+}
+
+// Index is an index the application's queries rely on
+type Index struct {
+	Name    string // suggested name when the index is missing
+	Columns []string
+}
+
+// Table is the expected definition of a table
+type Table struct {
+	Name        string
+	Columns     []Column
+	ForeignKeys []ForeignKey
+	Indexes     []Index
+	Optional    bool // the table may be absent (e.g. not yet migrated)
+}
+
+// Expected mirrors backend/src/db/schema.ts plus the indexes used by the
+// backend and the Go tools. Keep it in sync when the Drizzle schema changes.
+var Expected = []Table{
+	{
+		Name: "cities",
+		Columns: []Column{
+			{"id", "TEXT", false, true},
+			{"name", "TEXT", true, false},
+			{"display_name", "TEXT", true, false},
+			{"country", "TEXT", true, false},
+			{"timezone", "TEXT", true, false},
+			{"map_center", "TEXT", true, false},
+			{"is_active", "INTEGER", true, false},
+		},
+	},
+	{
+		Name: "metro_lines",
+		Columns: []Column{
+			{"id", "TEXT", false, true},
+			{"city_id", "TEXT", true, false},
+			{"name", "TEXT", true, false},
+			{"color", "TEXT", true, false},
+			{"display_order", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"city_id", "cities", "id"},
+		},
+		Indexes: []Index{
+			{"idx_metro_lines_city_id", []string{"city_id"}},
+		},
+	},
+	{
+		Name: "metro_stations",
+		Columns: []Column{
+			{"id", "TEXT", false, true},
+			{"city_id", "TEXT", true, false},
+			{"name", "TEXT", true, false},
+			{"latitude", "REAL", true, false},
+			{"longitude", "REAL", true, false},
+			{"is_interchange", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"city_id", "cities", "id"},
+		},
+		Indexes: []Index{
+			{"idx_metro_stations_city_id", []string{"city_id"}},
+		},
+	},
+	{
+		Name: "line_stations",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"line_id", "TEXT", true, false},
+			{"station_id", "TEXT", true, false},
+			{"sequence_number", "INTEGER", true, false},
+			{"direction", "TEXT", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"line_id", "metro_lines", "id"},
+			{"station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_line_stations_line_sequence", []string{"line_id", "sequence_number"}},
+			{"idx_line_stations_station_id", []string{"station_id"}},
+		},
+	},
+	{
+		Name: "station_connections",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"from_station_id", "TEXT", true, false},
+			{"to_station_id", "TEXT", true, false},
+			{"line_id", "TEXT", true, false},
+			{"travel_time_seconds", "INTEGER", true, false},
+			{"stop_time_seconds", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"from_station_id", "metro_stations", "id"},
+			{"to_station_id", "metro_stations", "id"},
+			{"line_id", "metro_lines", "id"},
+		},
+		Indexes: []Index{
+			{"idx_station_connections_from", []string{"from_station_id"}},
+			{"idx_station_connections_line_id", []string{"line_id"}},
+		},
+	},
+	{
+		Name: "train_schedules",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"line_id", "TEXT", true, false},
+			{"direction", "TEXT", true, false},
+			{"start_station_id", "TEXT", true, false},
+			{"end_station_id", "TEXT", true, false},
+			{"first_train_time", "TEXT", true, false},
+			{"last_train_time", "TEXT", true, false},
+			{"peak_frequency_minutes", "INTEGER", true, false},
+			{"off_peak_frequency_minutes", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"line_id", "metro_lines", "id"},
+			{"start_station_id", "metro_stations", "id"},
+			{"end_station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_train_schedules_line_direction", []string{"line_id", "direction"}},
+		},
+	},
+	{
+		Name: "peak_hours",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"schedule_id", "INTEGER", true, false},
+			{"start_time", "TEXT", true, false},
+			{"end_time", "TEXT", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"schedule_id", "train_schedules", "id"},
+		},
+		Indexes: []Index{
+			{"idx_peak_hours_schedule_id", []string{"schedule_id"}},
+		},
+	},
+	{
+		Name: "train_sightings",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"line_id", "TEXT", true, false},
+			{"station_id", "TEXT", true, false},
+			{"direction", "TEXT", true, false},
+			{"timestamp", "INTEGER", true, false},
+			{"user_id", "TEXT", false, false},
+			{"user_latitude", "REAL", false, false},
+			{"user_longitude", "REAL", false, false},
+			{"confidence_score", "REAL", false, false},
+			{"is_verified", "INTEGER", false, false},
+			{"created_at", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"line_id", "metro_lines", "id"},
+			{"station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_train_sightings_line_id", []string{"line_id"}},
+			{"idx_train_sightings_station_id", []string{"station_id"}},
+			{"idx_train_sightings_timestamp", []string{"timestamp"}},
+			{"idx_train_sightings_line_timestamp", []string{"line_id", "timestamp"}},
+		},
+		Optional: true, // added by add-train-sightings.ts
+	},
+	{
+		Name: "interchange_transfers",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"station_id", "TEXT", true, false},
+			{"from_line_id", "TEXT", true, false},
+			{"to_line_id", "TEXT", true, false},
+			{"walk_seconds", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"station_id", "metro_stations", "id"},
+			{"from_line_id", "metro_lines", "id"},
+			{"to_line_id", "metro_lines", "id"},
+		},
+		Indexes: []Index{
+			{"idx_interchange_transfers_station_lines", []string{"station_id", "from_line_id", "to_line_id"}},
+		},
+		Optional: true, // added by add-interchange-transfers.ts
+	},
+	{
+		Name: "station_groups",
+		Columns: []Column{
+			{"id", "TEXT", false, true},
+			{"city_id", "TEXT", true, false},
+			{"name", "TEXT", true, false},
+			{"walk_seconds", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"city_id", "cities", "id"},
+		},
+		Optional: true, // added by add-station-groups.ts
+	},
+	{
+		Name: "station_group_members",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"group_id", "TEXT", true, false},
+			{"station_id", "TEXT", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"group_id", "station_groups", "id"},
+			{"station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_station_group_members_station_id", []string{"station_id"}},
+		},
+		Optional: true, // added by add-station-groups.ts
+	},
+	{
+		Name: "station_facilities",
+		Columns: []Column{
+			{"station_id", "TEXT", false, true},
+			{"step_free", "INTEGER", true, false},
+			{"lifts", "INTEGER", true, false},
+			{"escalators", "INTEGER", true, false},
+			{"parking", "INTEGER", true, false},
+			{"toilets", "INTEGER", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"station_id", "metro_stations", "id"},
+		},
+		Optional: true, // added by add-station-facilities.ts
+	},
+	{
+		Name: "station_exits",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"station_id", "TEXT", true, false},
+			{"name", "TEXT", true, false},
+			{"latitude", "REAL", true, false},
+			{"longitude", "REAL", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_station_exits_station_id", []string{"station_id"}},
+		},
+		Optional: true, // added by add-station-facilities.ts
+	},
+	{
+		Name: "station_names",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"station_id", "TEXT", true, false},
+			{"lang", "TEXT", true, false},
+			{"name", "TEXT", true, false},
+			{"transliteration", "TEXT", false, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"station_id", "metro_stations", "id"},
+		},
+		Indexes: []Index{
+			{"idx_station_names_station_lang", []string{"station_id", "lang"}},
+		},
+		Optional: true, // added by add-station-names.ts
+	},
+	{
+		Name: "city_languages",
+		Columns: []Column{
+			{"id", "INTEGER", false, true},
+			{"city_id", "TEXT", true, false},
+			{"lang", "TEXT", true, false},
+		},
+		ForeignKeys: []ForeignKey{
+			{"city_id", "cities", "id"},
+		},
+		Indexes: []Index{
+			{"idx_city_languages_city_lang", []string{"city_id", "lang"}},
+		},
+		Optional: true, // added by add-city-languages.ts
+	},
+}
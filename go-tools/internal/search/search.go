@@ -0,0 +1,426 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"metro-tools/internal/validators"
+)
+
+// Match quality of each way a query can match a name. The best signal wins.
+const (
+	scoreExact      = 1.0
+	scorePrefix     = 0.9
+	scoreWordPrefix = 0.85
+	scorePhonetic   = 0.8
+	scoreSubstring  = 0.75
+	scoreFuzzy      = 0.7 // scaled by similarity
+	scoreNGram      = 0.6 // scaled by trigram overlap
+
+	// MinScore is the weakest match returned
+	MinScore = 0.35
+	// NearBoost is added for a station at the given point, halving every NearHalfKm
+	NearBoost  = 0.2
+	NearHalfKm = 2.0
+
+	gramSize = 3
+)
+
+// Words dropped before matching, so "Majestic Metro Station" finds "Majestic"
+var stopWords = map[string]bool{"station": true, "metro": true, "stn": true}
+
+// Entry is one station and the names it can be found by
+type Entry struct {
+	StationID string
+	CityID    string
+	Name      string
+	Latitude  float64
+	Longitude float64
+	// Aliases are other names: translations, transliterations, former names
+	Aliases []string
+}
+
+// Point is a location to rank results by distance from
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Query is one search
+type Query struct {
+	Text   string
+	CityID string // empty searches every city
+	Near   *Point
+	Limit  int
+}
+
+// Match is one station found by a query
+type Match struct {
+	StationID string  `json:"stationId"`
+	CityID    string  `json:"cityId"`
+	Name      string  `json:"name"`
+	MatchedOn string  `json:"matchedOn"` // the name or alias that matched
+	Score     float64 `json:"score"`
+	// DistanceKm is the straight-line distance from Query.Near
+	DistanceKm *float64 `json:"distanceKm,omitempty"`
+}
+
+// term is one searchable name of a station
+type term struct {
+	entry    int
+	text     string
+	norm     string
+	words    []string
+	phonetic string
+	phWords  []string
+}
+
+// Index finds stations by fuzzy name matching
+type Index struct {
+	entries []Entry
+	terms   []term
+	// grams maps trigrams of normalised and phonetic names to terms
+	grams map[string][]int
+}
+
+// NewIndex indexes the names and aliases of stations. A name with a
+// parenthesised or comma-separated part, like "Kempegowda (Majestic)",
+// is also indexed by each part.
+func NewIndex(entries []Entry) *Index {
+	ix := &Index{entries: entries, grams: make(map[string][]int)}
+	for i, e := range entries {
+		seen := make(map[string]bool)
+		for _, name := range append([]string{e.Name}, e.Aliases...) {
+			for _, text := range variants(name) {
+				n := normalize(text)
+				if n == "" || seen[n] {
+					continue
+				}
+				seen[n] = true
+				t := term{entry: i, text: text, norm: n, words: strings.Fields(n)}
+				t.phonetic = phonetic(n)
+				t.phWords = strings.Fields(t.phonetic)
+				id := len(ix.terms)
+				ix.terms = append(ix.terms, t)
+
+				added := make(map[string]bool)
+				for _, g := range append(trigrams(t.norm), trigrams(t.phonetic)...) {
+					if !added[g] {
+						added[g] = true
+						ix.grams[g] = append(ix.grams[g], id)
+					}
+				}
+			}
+		}
+	}
+	return ix
+}
+
+// Search returns the best match per station, best first
+func (ix *Index) Search(q Query) []Match {
+	n := normalize(q.Text)
+	if n == "" {
+		return nil
+	}
+	qt := term{text: q.Text, norm: n, words: strings.Fields(n)}
+	qt.phonetic = phonetic(n)
+	qt.phWords = strings.Fields(qt.phonetic)
+
+	best := make(map[int]Match)
+	for _, id := range ix.candidates(qt) {
+		t := ix.terms[id]
+		e := ix.entries[t.entry]
+		if q.CityID != "" && e.CityID != q.CityID {
+			continue
+		}
+		score := similarity(qt, t)
+		if score < MinScore {
+			continue
+		}
+		if m, ok := best[t.entry]; ok && m.Score >= score {
+			continue
+		}
+		best[t.entry] = Match{StationID: e.StationID, CityID: e.CityID, Name: e.Name, MatchedOn: t.text, Score: score}
+	}
+
+	matches := make([]Match, 0, len(best))
+	rank := make(map[string]float64)
+	for i, m := range best {
+		r := m.Score
+		if q.Near != nil {
+			e := ix.entries[i]
+			km := validators.HaversineDistance(q.Near.Lat, q.Near.Lng, e.Latitude, e.Longitude)
+			km = math.Round(km*100) / 100
+			m.DistanceKm = &km
+			r += NearBoost * math.Pow(0.5, km/NearHalfKm)
+		}
+		m.Score = math.Round(m.Score*1000) / 1000
+		rank[m.StationID] = r
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if rank[a.StationID] != rank[b.StationID] {
+			return rank[a.StationID] > rank[b.StationID]
+		}
+		return a.Name < b.Name
+	})
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+	}
+	return matches
+}
+
+// candidates returns the terms sharing a trigram with the query. Queries too
+// short to have trigrams are compared with every term.
+func (ix *Index) candidates(q term) []int {
+	if len([]rune(q.norm)) < gramSize {
+		all := make([]int, len(ix.terms))
+		for i := range all {
+			all[i] = i
+		}
+		return all
+	}
+	seen := make(map[int]bool)
+	var ids []int
+	for _, g := range append(trigrams(q.norm), trigrams(q.phonetic)...) {
+		for _, id := range ix.grams[g] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// similarity scores how well a query matches a name, from 0 to 1
+func similarity(q, t term) float64 {
+	score := 0.0
+	consider := func(s float64) {
+		if s > score {
+			score = s
+		}
+	}
+
+	switch {
+	case t.norm == q.norm:
+		consider(scoreExact)
+	case strings.HasPrefix(t.norm, q.norm):
+		consider(scorePrefix)
+	case strings.Contains(" "+t.norm, " "+q.norm):
+		consider(scoreWordPrefix)
+	case strings.Contains(t.norm, q.norm):
+		consider(scoreSubstring)
+	}
+	if q.phonetic != "" && (t.phonetic == q.phonetic || strings.HasPrefix(t.phonetic, q.phonetic)) {
+		consider(scorePhonetic)
+	}
+	consider(scoreFuzzy * editSimilarity(q.norm, t.norm))
+	consider(scoreNGram * dice(trigrams(q.norm), trigrams(t.norm)))
+
+	// Word by word, so typos in one word of a longer name still match
+	if len(q.words) > 0 && len(t.words) > 0 {
+		total := 0.0
+		for i, w := range q.words {
+			total += wordSimilarity(w, q.phWords[i], t)
+		}
+		consider(0.95 * total / float64(len(q.words)))
+	}
+	return score
+}
+
+// wordSimilarity scores the best match of one query word among a name's words
+func wordSimilarity(word, ph string, t term) float64 {
+	best := 0.0
+	for i, w := range t.words {
+		s := 0.0
+		switch {
+		case w == word:
+			s = scoreExact
+		case strings.HasPrefix(w, word):
+			s = scorePrefix
+		case ph != "" && t.phWords[i] == ph:
+			s = scorePhonetic
+		default:
+			s = scoreFuzzy * editSimilarity(word, w)
+		}
+		if s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// editSimilarity is 1 minus the edit distance relative to the longer string
+func editSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein counts the insertions, deletions and substitutions between two strings
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// dice is the Sørensen–Dice coefficient of two trigram lists
+func dice(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	counts := make(map[string]int)
+	for _, g := range a {
+		counts[g]++
+	}
+	shared := 0
+	for _, g := range b {
+		if counts[g] > 0 {
+			counts[g]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+// trigrams returns the padded character trigrams of a normalised string
+func trigrams(s string) []string {
+	if s == "" {
+		return nil
+	}
+	r := []rune(" " + s + " ")
+	var grams []string
+	for i := 0; i+gramSize <= len(r); i++ {
+		grams = append(grams, string(r[i:i+gramSize]))
+	}
+	return grams
+}
+
+// variants splits a name into the full name and its parenthesised and
+// comma-separated parts
+func variants(name string) []string {
+	out := []string{name}
+	if open := strings.IndexByte(name, '('); open >= 0 {
+		if end := strings.IndexByte(name[open:], ')'); end > 0 {
+			out = append(out, name[:open]+name[open+end+1:], name[open+1:open+end])
+		}
+	}
+	if strings.Contains(name, ",") {
+		out = append(out, strings.Split(name, ",")...)
+	}
+	return out
+}
+
+// Accented letters of scholarly transliteration (IAST) and common European names
+var accents = map[rune]rune{
+	'ā': 'a', 'á': 'a', 'à': 'a', 'â': 'a', 'ä': 'a', 'ī': 'i', 'í': 'i', 'ì': 'i', 'î': 'i',
+	'ū': 'u', 'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u', 'ē': 'e', 'é': 'e', 'è': 'e', 'ê': 'e',
+	'ō': 'o', 'ó': 'o', 'ò': 'o', 'ô': 'o', 'ö': 'o', 'ṛ': 'r', 'ṝ': 'r', 'ḷ': 'l',
+	'ṭ': 't', 'ḍ': 'd', 'ṇ': 'n', 'ñ': 'n', 'ṅ': 'n', 'ś': 's', 'ṣ': 's', 'ḥ': 'h', 'ṃ': 'm', 'ç': 'c',
+}
+
+// normalize lowercases, strips accents and punctuation and drops stop words.
+// Combining marks are kept, as they are vowel signs in Indic scripts.
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if plain, ok := accents[r]; ok {
+			r = plain
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	var words []string
+	for _, w := range strings.Fields(b.String()) {
+		if !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Spelling variants of romanised Indian names, longest first: aspirates
+// lose their h, long vowels are shortened and look-alike sounds merged
+var phoneticRules = []struct{ from, to string }{
+	{"chh", "c"}, {"ksh", "ks"}, {"sh", "s"}, {"ch", "c"}, {"kh", "k"}, {"gh", "g"},
+	{"th", "t"}, {"dh", "d"}, {"ph", "f"}, {"bh", "b"}, {"jh", "j"}, {"ck", "k"},
+	{"aa", "a"}, {"ee", "i"}, {"ii", "i"}, {"oo", "u"}, {"uu", "u"}, {"ou", "u"},
+	{"au", "u"}, {"ow", "u"}, {"ai", "e"}, {"ay", "e"}, {"ey", "e"},
+	{"q", "k"}, {"w", "v"}, {"z", "j"}, {"x", "ks"}, {"y", "i"}, {"o", "u"}, {"e", "i"},
+}
+
+// phonetic reduces a normalised Latin name to a key shared by its common
+// spellings, e.g. "Kashmere Gate" and "Kashmiri Gate". Words in other
+// scripts are kept as they are.
+func phonetic(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		if !isASCII(w) {
+			continue
+		}
+		for _, rule := range phoneticRules {
+			w = strings.ReplaceAll(w, rule.from, rule.to)
+		}
+		// Doubled letters and a trailing schwa are not written consistently
+		var b strings.Builder
+		var last rune
+		for _, r := range w {
+			if r != last {
+				b.WriteRune(r)
+			}
+			last = r
+		}
+		w = b.String()
+		if len(w) > 3 {
+			w = strings.TrimSuffix(w, "a")
+		}
+		words[i] = w
+	}
+	return strings.Join(words, " ")
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"testing"
+)
+
+func testIndex() *Index {
+	return NewIndex([]Entry{
+		{StationID: "kashmere-gate", CityID: "delhi", Name: "Kashmere Gate", Latitude: 28.6675, Longitude: 77.2282,
+			Aliases: []string{"कश्मीरी गेट"}},
+		{StationID: "rajiv-chowk", CityID: "delhi", Name: "Rajiv Chowk", Latitude: 28.6328, Longitude: 77.2197},
+		{StationID: "chandni-chowk", CityID: "delhi", Name: "Chandni Chowk", Latitude: 28.6579, Longitude: 77.2300},
+		{StationID: "majestic", CityID: "bangalore", Name: "Nadaprabhu Kempegowda Station, Majestic", Latitude: 12.9757, Longitude: 77.5729,
+			Aliases: []string{"ಮೆಜೆಸ್ಟಿಕ್"}},
+		{StationID: "mg-road-blr", CityID: "bangalore", Name: "Mahatma Gandhi Road (MG Road)", Latitude: 12.9755, Longitude: 77.6068},
+		{StationID: "mg-road-gurgaon", CityID: "delhi", Name: "MG Road", Latitude: 28.4795, Longitude: 77.0800},
+		{StationID: "shivaji-park", CityID: "mumbai", Name: "Śivājī Park", Latitude: 19.0269, Longitude: 72.8383},
+	})
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query Query
+		want  string // station ID of the top match, empty for no matches
+	}{
+		{"exact", Query{Text: "Rajiv Chowk"}, "rajiv-chowk"},
+		{"case and punctuation", Query{Text: "rajiv-CHOWK!"}, "rajiv-chowk"},
+		{"prefix", Query{Text: "Chand"}, "chandni-chowk"},
+		{"word prefix", Query{Text: "Gate"}, "kashmere-gate"},
+		{"spelling variant", Query{Text: "Kashmiri Gate"}, "kashmere-gate"},
+		{"typo", Query{Text: "Rajeev Chouk"}, "rajiv-chowk"},
+		{"stop words", Query{Text: "Majestic Metro Station"}, "majestic"},
+		{"comma part", Query{Text: "Kempegowda"}, "majestic"},
+		{"native script alias", Query{Text: "ಮೆಜೆಸ್ಟಿಕ್"}, "majestic"},
+		{"accents", Query{Text: "Shivaji Park"}, "shivaji-park"},
+		{"city filter", Query{Text: "MG Road", CityID: "bangalore"}, "mg-road-blr"},
+		{"near", Query{Text: "MG Road", Near: &Point{Lat: 12.97, Lng: 77.60}}, "mg-road-blr"},
+		{"no match", Query{Text: "Victoria Terminus"}, ""},
+		{"blank", Query{Text: "  -- "}, ""},
+		{"only stop words", Query{Text: "metro station"}, ""},
+	}
+	ix := testIndex()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := ix.Search(tt.query)
+			if tt.want == "" {
+				if len(matches) != 0 {
+					t.Fatalf("Search(%q) = %+v, want no matches", tt.query.Text, matches)
+				}
+				return
+			}
+			if len(matches) == 0 || matches[0].StationID != tt.want {
+				t.Fatalf("Search(%q) = %+v, want %s first", tt.query.Text, matches, tt.want)
+			}
+			for i, m := range matches {
+				if m.Score < MinScore || m.Score > 1 {
+					t.Errorf("match %d scored %v, outside [%v, 1]", i, m.Score, MinScore)
+				}
+				if tt.query.CityID != "" && m.CityID != tt.query.CityID {
+					t.Errorf("match %d is in %s, want only %s", i, m.CityID, tt.query.CityID)
+				}
+				if (m.DistanceKm != nil) != (tt.query.Near != nil) {
+					t.Errorf("match %d distance = %v with near point %v", i, m.DistanceKm, tt.query.Near)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchOnePerStation(t *testing.T) {
+	// MG Road is indexed by its full name and both parenthesised parts
+	seen := make(map[string]bool)
+	for _, m := range testIndex().Search(Query{Text: "MG Road"}) {
+		if seen[m.StationID] {
+			t.Errorf("%s matched more than once", m.StationID)
+		}
+		seen[m.StationID] = true
+	}
+	if !seen["mg-road-blr"] || !seen["mg-road-gurgaon"] {
+		t.Errorf("Search(MG Road) found %v, want both MG Road stations", seen)
+	}
+}
+
+func TestSearchLimit(t *testing.T) {
+	ix := testIndex()
+	all := ix.Search(Query{Text: "Chowk"})
+	if len(all) < 2 {
+		t.Fatalf("Search(Chowk) = %+v, want both Chowk stations", all)
+	}
+	limited := ix.Search(Query{Text: "Chowk", Limit: 1})
+	if len(limited) != 1 || limited[0].StationID != all[0].StationID {
+		t.Errorf("Search(Chowk, limit 1) = %+v, want only %s", limited, all[0].StationID)
+	}
+}
+
+func TestPhonetic(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"kashmere gate", "kashmiri gate"},
+		{"chandni chowk", "chandni chauk"},
+		{"shivaji", "sivaji"},
+		{"dhaula kuan", "daula kuan"},
+	}
+	for _, tt := range tests {
+		if pa, pb := phonetic(tt.a), phonetic(tt.b); pa != pb {
+			t.Errorf("phonetic(%q) = %q, phonetic(%q) = %q, want equal", tt.a, pa, tt.b, pb)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package sightings
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/graph"
+	"metro-tools/internal/validators"
+)
+
+// Options tunes the spam heuristics
+type Options struct {
+	// JumpFactor flags consecutive reports by one user that are faster than
+	// this fraction of the minimum network travel time between the stations
+	JumpFactor float64
+	// FarMeters is the GPS distance from the station that counts as "far away"
+	FarMeters float64
+	// BurstCount far-away reports within BurstWindow count as a burst
+	BurstCount  int
+	BurstWindow time.Duration
+}
+
+// DefaultOptions are the heuristics used by `analyze sightings`
+var DefaultOptions = Options{
+	JumpFactor:  0.5,
+	FarMeters:   1000,
+	BurstCount:  3,
+	BurstWindow: 10 * time.Minute,
+}
+
+// VolumeKey groups sightings by line, direction and local hour of day
+type VolumeKey struct {
+	LineID    string `json:"lineId"`
+	Direction string `json:"direction"`
+	Hour      int    `json:"hour"`
+}
+
+// Volume is the number of sightings for a VolumeKey
+type Volume struct {
+	VolumeKey
+	Count int `json:"count"`
+}
+
+// ConfidenceBucket is one bar of the confidence_score histogram
+type ConfidenceBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// Suspect is a group of sightings that look like spam
+type Suspect struct {
+	Kind        string  `json:"kind"` // "impossible_jump" or "far_burst"
+	Reporter    string  `json:"reporter"`
+	SightingIDs []int   `json:"sightingIds"`
+	Detail      string  `json:"detail"`
+	Start       string  `json:"start"`
+	Severity    float64 `json:"severity"` // higher is more suspicious
+}
+
+// Report is the outcome of analysing the train_sightings table
+type Report struct {
+	Total          int                `json:"total"`
+	From           string             `json:"from,omitempty"`
+	To             string             `json:"to,omitempty"`
+	WithGPS        int                `json:"withGps"`
+	GPSShare       float64            `json:"gpsShare"`
+	Anonymous      int                `json:"anonymous"`
+	Verified       int                `json:"verified"`
+	Volumes        []Volume           `json:"volumes"`
+	Confidence     []ConfidenceBucket `json:"confidence"`
+	MeanConfidence float64            `json:"meanConfidence"`
+	Suspects       []Suspect          `json:"suspects"`
+}
+
+// Analyze summarises sighting volume and quality and flags likely spam
+func Analyze(
+	sightings []database.TrainSighting,
+	cities []database.City,
+	lines []database.MetroLine,
+	stations []database.MetroStation,
+	connections []database.StationConnection,
+	opts Options,
+) *Report {
+	report := &Report{Total: len(sightings)}
+	if len(sightings) == 0 {
+		return report
+	}
+
+	// Hours are bucketed in each line's city timezone
+	locations := make(map[string]*time.Location)
+	for _, c := range cities {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			locations[c.ID] = loc
+		}
+	}
+	lineLocation := make(map[string]*time.Location)
+	for _, l := range lines {
+		lineLocation[l.ID] = locations[l.CityID]
+	}
+	stationByID := make(map[string]database.MetroStation)
+	for _, s := range stations {
+		stationByID[s.ID] = s
+	}
+
+	volumes := make(map[VolumeKey]int)
+	report.Confidence = make([]ConfidenceBucket, 10)
+	for i := range report.Confidence {
+		report.Confidence[i] = ConfidenceBucket{Min: float64(i) / 10, Max: float64(i+1) / 10}
+	}
+
+	first, last := sightings[0].Timestamp, sightings[0].Timestamp
+	var confidenceSum float64
+	for _, s := range sightings {
+		if s.Timestamp.Before(first) {
+			first = s.Timestamp
+		}
+		if s.Timestamp.After(last) {
+			last = s.Timestamp
+		}
+
+		loc := lineLocation[s.LineID]
+		if loc == nil {
+			loc = time.UTC
+		}
+		volumes[VolumeKey{s.LineID, s.Direction, s.Timestamp.In(loc).Hour()}]++
+
+		if s.HasLocation() {
+			report.WithGPS++
+		}
+		if s.UserID == "" {
+			report.Anonymous++
+		}
+		if s.IsVerified {
+			report.Verified++
+		}
+
+		bucket := int(s.ConfidenceScore * 10)
+		if bucket >= 10 {
+			bucket = 9
+		}
+		if bucket < 0 {
+			bucket = 0
+		}
+		report.Confidence[bucket].Count++
+		confidenceSum += s.ConfidenceScore
+	}
+
+	report.From = first.Format(time.RFC3339)
+	report.To = last.Format(time.RFC3339)
+	report.GPSShare = float64(report.WithGPS) / float64(report.Total)
+	report.MeanConfidence = confidenceSum / float64(report.Total)
+
+	for k, count := range volumes {
+		report.Volumes = append(report.Volumes, Volume{VolumeKey: k, Count: count})
+	}
+	sort.Slice(report.Volumes, func(i, j int) bool {
+		a, b := report.Volumes[i], report.Volumes[j]
+		if a.LineID != b.LineID {
+			return a.LineID < b.LineID
+		}
+		if a.Direction != b.Direction {
+			return a.Direction < b.Direction
+		}
+		return a.Hour < b.Hour
+	})
+
+	g := graph.New(connections)
+	report.Suspects = append(report.Suspects, findImpossibleJumps(sightings, g, opts)...)
+	report.Suspects = append(report.Suspects, findFarBursts(sightings, stationByID, opts)...)
+	sort.SliceStable(report.Suspects, func(i, j int) bool {
+		return report.Suspects[i].Severity > report.Suspects[j].Severity
+	})
+
+	return report
+}
+
+// findImpossibleJumps flags users whose consecutive reports are further apart
+// in the network than they could have travelled in the elapsed time
+func findImpossibleJumps(sightings []database.TrainSighting, g *graph.Graph, opts Options) []Suspect {
+	byUser := make(map[string][]database.TrainSighting)
+	for _, s := range sightings {
+		if s.UserID != "" {
+			byUser[s.UserID] = append(byUser[s.UserID], s)
+		}
+	}
+
+	travelTimes := make(map[string]map[string]int)
+	minTravel := func(from, to string) (int, bool) {
+		if _, ok := travelTimes[from]; !ok {
+			travelTimes[from] = g.ShortestTimes(from)
+		}
+		t, ok := travelTimes[from][to]
+		return t, ok
+	}
+
+	var suspects []Suspect
+	for user, reports := range byUser {
+		sort.Slice(reports, func(i, j int) bool { return reports[i].Timestamp.Before(reports[j].Timestamp) })
+		for i := 1; i < len(reports); i++ {
+			prev, cur := reports[i-1], reports[i]
+			if prev.StationID == cur.StationID {
+				continue
+			}
+			elapsed := cur.Timestamp.Sub(prev.Timestamp).Seconds()
+
+			needed, reachable := minTravel(prev.StationID, cur.StationID)
+			var detail string
+			var severity float64
+			switch {
+			case !reachable && elapsed < 2*3600:
+				detail = fmt.Sprintf("%s -> %s in %.0fs, but the stations are not connected", prev.StationID, cur.StationID, elapsed)
+				severity = 10
+			case reachable && elapsed < float64(needed)*opts.JumpFactor:
+				detail = fmt.Sprintf("%s -> %s in %.0fs, minimum travel time is %ds", prev.StationID, cur.StationID, elapsed, needed)
+				severity = float64(needed) / math.Max(elapsed, 1)
+			default:
+				continue
+			}
+
+			suspects = append(suspects, Suspect{
+				Kind:        "impossible_jump",
+				Reporter:    user,
+				SightingIDs: []int{prev.ID, cur.ID},
+				Detail:      detail,
+				Start:       prev.Timestamp.Format(time.RFC3339),
+				Severity:    severity,
+			})
+		}
+	}
+	return suspects
+}
+
+// findFarBursts flags several reports in a short window from a reporter who
+// was far from the station they reported at
+func findFarBursts(sightings []database.TrainSighting, stationByID map[string]database.MetroStation, opts Options) []Suspect {
+	// Anonymous reports are grouped by a ~1 km grid cell of their GPS position
+	byReporter := make(map[string][]database.TrainSighting)
+	for _, s := range sightings {
+		if !s.HasLocation() {
+			continue
+		}
+		station, ok := stationByID[s.StationID]
+		if !ok {
+			continue
+		}
+		meters := validators.HaversineDistance(*s.UserLatitude, *s.UserLongitude, station.Latitude, station.Longitude) * 1000
+		if meters < opts.FarMeters {
+			continue
+		}
+
+		reporter := s.UserID
+		if reporter == "" {
+			reporter = fmt.Sprintf("anonymous@%.2f,%.2f", *s.UserLatitude, *s.UserLongitude)
+		}
+		byReporter[reporter] = append(byReporter[reporter], s)
+	}
+
+	var suspects []Suspect
+	for reporter, reports := range byReporter {
+		sort.Slice(reports, func(i, j int) bool { return reports[i].Timestamp.Before(reports[j].Timestamp) })
+
+		// Sliding window over far-away reports; each burst is reported once
+		for start := 0; start < len(reports); {
+			end := start
+			for end+1 < len(reports) && reports[end+1].Timestamp.Sub(reports[start].Timestamp) <= opts.BurstWindow {
+				end++
+			}
+			count := end - start + 1
+			if count < opts.BurstCount {
+				start++
+				continue
+			}
+
+			ids := make([]int, 0, count)
+			stationsSeen := make(map[string]bool)
+			for _, s := range reports[start : end+1] {
+				ids = append(ids, s.ID)
+				stationsSeen[s.StationID] = true
+			}
+			names := make([]string, 0, len(stationsSeen))
+			for id := range stationsSeen {
+				names = append(names, id)
+			}
+			sort.Strings(names)
+
+			suspects = append(suspects, Suspect{
+				Kind:        "far_burst",
+				Reporter:    reporter,
+				SightingIDs: ids,
+				Detail: fmt.Sprintf("%d reports within %s while over %.0fm from the station (%s)",
+					count, reports[end].Timestamp.Sub(reports[start].Timestamp).Round(time.Second), opts.FarMeters, strings.Join(names, ", ")),
+				Start:    reports[start].Timestamp.Format(time.RFC3339),
+				Severity: float64(count) / float64(opts.BurstCount),
+			})
+			start = end + 1
+		}
+	}
+	return suspects
+}
@@ -0,0 +1,346 @@
+package sightings
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+)
+
+// CalibrationOptions tunes how sightings are paired into travel time samples
+type CalibrationOptions struct {
+	// MinFactor and MaxFactor bound an interval relative to the seeded
+	// travel + stop time; pairs outside the window are assumed to be
+	// different trains
+	MinFactor float64
+	MaxFactor float64
+	// MinSamples is the number of samples a segment needs before a new
+	// travel time is recommended
+	MinSamples int
+	// Tolerance and MinDeltaSeconds decide whether the observed time differs
+	// enough from the seeded one to be worth patching
+	Tolerance       float64
+	MinDeltaSeconds int
+	// MaxSpread is the largest IQR, as a fraction of the median, that still
+	// counts as a consistent measurement
+	MaxSpread float64
+}
+
+// DefaultCalibrationOptions are the settings used by `analyze travel-times`
+var DefaultCalibrationOptions = CalibrationOptions{
+	MinFactor:       0.25,
+	MaxFactor:       4,
+	MinSamples:      10,
+	Tolerance:       0.15,
+	MinDeltaSeconds: 15,
+	MaxSpread:       0.5,
+}
+
+// TimeBand is a range of local hours [Start, End), wrapping past midnight
+type TimeBand struct {
+	Name  string
+	Start int
+	End   int
+}
+
+// TimeBands split the service day for per-band statistics
+var TimeBands = []TimeBand{
+	{"early", 5, 8},
+	{"morning_peak", 8, 11},
+	{"midday", 11, 17},
+	{"evening_peak", 17, 21},
+	{"night", 21, 5},
+}
+
+// Contains reports whether a local hour falls in the band
+func (b TimeBand) Contains(hour int) bool {
+	if b.Start <= b.End {
+		return hour >= b.Start && hour < b.End
+	}
+	return hour >= b.Start || hour < b.End
+}
+
+// Stats are robust summary statistics of a set of samples
+type Stats struct {
+	N      int     `json:"n"`
+	Median float64 `json:"median"`
+	Q1     float64 `json:"q1"`
+	Q3     float64 `json:"q3"`
+	IQR    float64 `json:"iqr"`
+}
+
+// Summarize computes the median and quartiles of samples
+func Summarize(samples []float64) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	s := Stats{
+		N:      len(sorted),
+		Median: quantile(sorted, 0.5),
+		Q1:     quantile(sorted, 0.25),
+		Q3:     quantile(sorted, 0.75),
+	}
+	s.IQR = s.Q3 - s.Q1
+	return s
+}
+
+// quantile interpolates linearly between the closest ranks of sorted data
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+// BandStats are the statistics of one segment within a time band
+type BandStats struct {
+	Band string `json:"band"`
+	Stats
+}
+
+// SegmentCalibration compares observed and seeded times for one station_connections row
+type SegmentCalibration struct {
+	ConnectionID      int    `json:"connectionId"`
+	LineID            string `json:"lineId"`
+	FromStationID     string `json:"fromStationId"`
+	ToStationID       string `json:"toStationId"`
+	Direction         string `json:"direction"`
+	TravelTimeSeconds int    `json:"travelTimeSeconds"`
+	StopTimeSeconds   int    `json:"stopTimeSeconds"`
+	// Observed is the station-to-station interval, i.e. travel plus dwell
+	Observed Stats `json:"observed"`
+	// SameUserSamples were paired from one reporter riding the train
+	SameUserSamples int         `json:"sameUserSamples"`
+	Bands           []BandStats `json:"bands"`
+	// EstimatedTravelSeconds is the observed median minus the seeded stop time
+	EstimatedTravelSeconds int  `json:"estimatedTravelSeconds"`
+	DeltaSeconds           int  `json:"deltaSeconds"`
+	Recommended            bool `json:"recommended"`
+}
+
+// Calibration is the outcome of comparing sightings against station_connections
+type Calibration struct {
+	Sightings   int                  `json:"sightings"`
+	Samples     int                  `json:"samples"`
+	Segments    []SegmentCalibration `json:"segments"`
+	Unobserved  int                  `json:"unobserved"`
+	Recommended int                  `json:"recommended"`
+}
+
+type sample struct {
+	seconds  float64
+	hour     int
+	sameUser bool
+}
+
+// Calibrate estimates inter-station travel times from consecutive sightings
+// of the same train. A sighting at station A is paired with a sighting at the
+// next station B on the same line and direction: preferably by the same
+// reporter, otherwise the first report at B inside the plausible window,
+// provided no later train was reported at A in between.
+func Calibrate(
+	sightings []database.TrainSighting,
+	cities []database.City,
+	lines []database.MetroLine,
+	lineStations []database.LineStation,
+	connections []database.StationConnection,
+	opts CalibrationOptions,
+) *Calibration {
+	cal := &Calibration{Sightings: len(sightings)}
+	topo := topology.New(lineStations, connections)
+
+	locations := make(map[string]*time.Location)
+	for _, c := range cities {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			locations[c.ID] = loc
+		}
+	}
+	lineLocation := make(map[string]*time.Location)
+	for _, l := range lines {
+		lineLocation[l.ID] = locations[l.CityID]
+	}
+
+	// Index sightings by line, direction and station in time order
+	type stationKey struct{ lineID, direction, stationID string }
+	byStation := make(map[stationKey][]database.TrainSighting)
+	for _, s := range sightings {
+		key := stationKey{s.LineID, s.Direction, s.StationID}
+		byStation[key] = append(byStation[key], s)
+	}
+	for _, list := range byStation {
+		sort.Slice(list, func(i, j int) bool { return list[i].Timestamp.Before(list[j].Timestamp) })
+	}
+
+	samples := make(map[int][]sample)
+	for key, atA := range byStation {
+		for _, hop := range topo.Next(key.lineID, key.stationID, key.direction) {
+			atB := byStation[stationKey{key.lineID, key.direction, hop.To}]
+			if len(atB) == 0 {
+				continue
+			}
+			expected := float64(hop.TravelTimeSeconds + hop.StopTimeSeconds)
+			if expected <= 0 {
+				continue
+			}
+			minGap := time.Duration(expected * opts.MinFactor * float64(time.Second))
+			maxGap := time.Duration(expected * opts.MaxFactor * float64(time.Second))
+			// Reports at A closer together than this are taken to be the same train
+			dedupe := time.Duration(math.Max(60, 2*float64(hop.StopTimeSeconds))) * time.Second
+
+			loc := lineLocation[key.lineID]
+			if loc == nil {
+				loc = time.UTC
+			}
+
+			for i, a := range atA {
+				b, sameUser, ok := matchSighting(a, atB, minGap, maxGap)
+				if !ok {
+					continue
+				}
+				if !sameUser && laterTrainBetween(atA[i+1:], a.Timestamp.Add(dedupe), b.Timestamp) {
+					continue
+				}
+				samples[hop.ConnectionID] = append(samples[hop.ConnectionID], sample{
+					seconds:  b.Timestamp.Sub(a.Timestamp).Seconds(),
+					hour:     a.Timestamp.In(loc).Hour(),
+					sameUser: sameUser,
+				})
+			}
+		}
+	}
+
+	for _, c := range connections {
+		dir := topo.Direction(c.LineID, c.FromStationID, c.ToStationID)
+		segment := SegmentCalibration{
+			ConnectionID:      c.ID,
+			LineID:            c.LineID,
+			FromStationID:     c.FromStationID,
+			ToStationID:       c.ToStationID,
+			Direction:         dir,
+			TravelTimeSeconds: c.TravelTimeSeconds,
+			StopTimeSeconds:   c.StopTimeSeconds,
+		}
+
+		list := samples[c.ID]
+		if len(list) == 0 {
+			cal.Unobserved++
+			continue
+		}
+		cal.Samples += len(list)
+
+		all := make([]float64, 0, len(list))
+		for _, s := range list {
+			all = append(all, s.seconds)
+			if s.sameUser {
+				segment.SameUserSamples++
+			}
+		}
+		segment.Observed = Summarize(all)
+		for _, band := range TimeBands {
+			var inBand []float64
+			for _, s := range list {
+				if band.Contains(s.hour) {
+					inBand = append(inBand, s.seconds)
+				}
+			}
+			if len(inBand) > 0 {
+				segment.Bands = append(segment.Bands, BandStats{Band: band.Name, Stats: Summarize(inBand)})
+			}
+		}
+
+		estimate := int(math.Round(segment.Observed.Median)) - c.StopTimeSeconds
+		if estimate < 1 {
+			estimate = 1
+		}
+		segment.EstimatedTravelSeconds = estimate
+		segment.DeltaSeconds = estimate - c.TravelTimeSeconds
+
+		delta := math.Abs(float64(segment.DeltaSeconds))
+		segment.Recommended = segment.Observed.N >= opts.MinSamples &&
+			segment.Observed.IQR <= opts.MaxSpread*segment.Observed.Median &&
+			delta >= float64(opts.MinDeltaSeconds) &&
+			delta >= opts.Tolerance*float64(c.TravelTimeSeconds)
+		if segment.Recommended {
+			cal.Recommended++
+		}
+
+		cal.Segments = append(cal.Segments, segment)
+	}
+
+	sort.Slice(cal.Segments, func(i, j int) bool {
+		a, b := cal.Segments[i], cal.Segments[j]
+		if a.LineID != b.LineID {
+			return a.LineID < b.LineID
+		}
+		if a.Direction != b.Direction {
+			return a.Direction > b.Direction // forward first
+		}
+		seqA, _ := topo.Sequence(a.LineID, a.FromStationID)
+		seqB, _ := topo.Sequence(b.LineID, b.FromStationID)
+		if a.Direction == topology.Backward {
+			return seqA > seqB
+		}
+		return seqA < seqB
+	})
+
+	return cal
+}
+
+// matchSighting finds the report at the next station that belongs to the same
+// train as a, preferring one from the same reporter
+func matchSighting(a database.TrainSighting, atB []database.TrainSighting, minGap, maxGap time.Duration) (database.TrainSighting, bool, bool) {
+	from := a.Timestamp.Add(minGap)
+	to := a.Timestamp.Add(maxGap)
+	start := sort.Search(len(atB), func(i int) bool { return !atB[i].Timestamp.Before(from) })
+
+	var first *database.TrainSighting
+	for i := start; i < len(atB) && !atB[i].Timestamp.After(to); i++ {
+		if a.UserID != "" && atB[i].UserID == a.UserID {
+			return atB[i], true, true
+		}
+		if first == nil {
+			first = &atB[i]
+		}
+	}
+	if first == nil {
+		return database.TrainSighting{}, false, false
+	}
+	return *first, false, true
+}
+
+// laterTrainBetween reports whether a sighting in the time-ordered list falls
+// strictly between after and before
+func laterTrainBetween(list []database.TrainSighting, after, before time.Time) bool {
+	for _, s := range list {
+		if !s.Timestamp.After(after) {
+			continue
+		}
+		return s.Timestamp.Before(before)
+	}
+	return false
+}
+
+// SQLPatch renders UPDATE statements for the recommended segments
+func (c *Calibration) SQLPatch() string {
+	var b strings.Builder
+	b.WriteString("-- Travel time calibration from train_sightings\n")
+	b.WriteString(fmt.Sprintf("-- Generated %s; review before applying\n", time.Now().UTC().Format(time.RFC3339)))
+	b.WriteString("BEGIN TRANSACTION;\n")
+	for _, s := range c.Segments {
+		if !s.Recommended {
+			continue
+		}
+		b.WriteString(fmt.Sprintf("-- %s %s -> %s: median %.0fs (IQR %.0fs, n=%d), was %ds\n",
+			s.LineID, s.FromStationID, s.ToStationID, s.Observed.Median, s.Observed.IQR, s.Observed.N, s.TravelTimeSeconds))
+		b.WriteString(fmt.Sprintf("UPDATE station_connections SET travel_time_seconds = %d WHERE id = %d;\n",
+			s.EstimatedTravelSeconds, s.ConnectionID))
+	}
+	b.WriteString("COMMIT;\n")
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package spatial
+
+import (
+	"math"
+	"sort"
+
+	"metro-tools/internal/validators"
+)
+
+const (
+	// CellDegrees is the size of a grid cell, about 1.1km north-south
+	CellDegrees = 0.01
+	// WalkSpeed is an average walking pace in metres per second (4.5 km/h)
+	WalkSpeed = 1.25
+	// DetourFactor is how much longer walking along streets is than the straight line
+	DetourFactor = 1.3
+
+	// metersPerDegree matches the earth radius of validators.HaversineDistance
+	metersPerDegree = 6371000 * math.Pi / 180
+)
+
+// Point is one indexed location
+type Point struct {
+	ID  string
+	Lat float64
+	Lng float64
+}
+
+// Neighbor is a point found near a location
+type Neighbor struct {
+	Point
+	Meters  float64
+	Bearing float64 // degrees clockwise from north, from the query location to the point
+}
+
+type cell struct{ lat, lng int }
+
+// Grid is a uniform latitude/longitude grid for radius queries
+type Grid struct {
+	cells map[cell][]Point
+}
+
+// NewGrid buckets points by grid cell
+func NewGrid(points []Point) *Grid {
+	g := &Grid{cells: make(map[cell][]Point)}
+	for _, p := range points {
+		c := cellOf(p.Lat, p.Lng)
+		g.cells[c] = append(g.cells[c], p)
+	}
+	return g
+}
+
+func cellOf(lat, lng float64) cell {
+	return cell{int(math.Floor(lat / CellDegrees)), int(math.Floor(lng / CellDegrees))}
+}
+
+// Within returns the points within radius metres of a location, nearest
+// first, keeping at most limit (0 keeps all). Points keep rejects are skipped.
+func (g *Grid) Within(lat, lng, radius float64, limit int, keep func(Point) bool) []Neighbor {
+	// Cells overlapping the bounding box of the circle. Degrees of longitude
+	// are shortest at the edge of the box nearest the pole.
+	dLat := radius / metersPerDegree
+	dLng := 360.0
+	if cos := math.Cos(math.Min(math.Abs(lat)+dLat, 90) * math.Pi / 180); cos > 1e-6 {
+		dLng = math.Min(dLng, radius/(metersPerDegree*cos))
+	}
+	lo, hi := cellOf(lat-dLat, lng-dLng), cellOf(lat+dLat, lng+dLng)
+
+	var found []Neighbor
+	for cl := lo.lat; cl <= hi.lat; cl++ {
+		for cg := lo.lng; cg <= hi.lng; cg++ {
+			for _, p := range g.cells[cell{cl, cg}] {
+				if keep != nil && !keep(p) {
+					continue
+				}
+				meters := validators.HaversineDistance(lat, lng, p.Lat, p.Lng) * 1000
+				if meters <= radius {
+					found = append(found, Neighbor{Point: p, Meters: meters, Bearing: Bearing(lat, lng, p.Lat, p.Lng)})
+				}
+			}
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Meters != found[j].Meters {
+			return found[i].Meters < found[j].Meters
+		}
+		return found[i].ID < found[j].ID
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// Bearing returns the initial great-circle bearing from one coordinate to
+// another, in degrees clockwise from north
+func Bearing(lat1, lng1, lat2, lng2 float64) float64 {
+	lat1Rad := lat1 * math.Pi / 180
+	lat2Rad := lat2 * math.Pi / 180
+	deltaLng := (lng2 - lng1) * math.Pi / 180
+
+	y := math.Sin(deltaLng) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(deltaLng)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+var compassPoints = []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+
+// Compass names the eight-point compass direction of a bearing
+func Compass(bearing float64) string {
+	return compassPoints[int(math.Round(bearing/45))%8]
+}
+
+// WalkSeconds estimates the time to walk a straight-line distance in metres
+func WalkSeconds(meters float64) int {
+	return int(math.Round(meters * DetourFactor / WalkSpeed))
+}
@@ -0,0 +1,143 @@
+package spatial
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"metro-tools/internal/validators"
+)
+
+// bruteForce is Within without the grid
+func bruteForce(points []Point, lat, lng, radius float64, limit int, keep func(Point) bool) []Neighbor {
+	var found []Neighbor
+	for _, p := range points {
+		if keep != nil && !keep(p) {
+			continue
+		}
+		meters := validators.HaversineDistance(lat, lng, p.Lat, p.Lng) * 1000
+		if meters <= radius {
+			found = append(found, Neighbor{Point: p, Meters: meters, Bearing: Bearing(lat, lng, p.Lat, p.Lng)})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Meters != found[j].Meters {
+			return found[i].Meters < found[j].Meters
+		}
+		return found[i].ID < found[j].ID
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+func TestWithinMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	centres := []struct {
+		name     string
+		lat, lng float64
+	}{
+		{"delhi", 28.6328, 77.2197},
+		{"equator", 0, 0},
+		{"sydney", -33.87, 151.21},
+		{"far north", 69.65, 18.96},
+	}
+	for _, c := range centres {
+		var points []Point
+		for i := 0; i < 2000; i++ {
+			points = append(points, Point{
+				ID:  fmt.Sprintf("p%d", i),
+				Lat: c.lat + (rng.Float64()-0.5)*0.2,
+				Lng: c.lng + (rng.Float64()-0.5)*0.2,
+			})
+		}
+		g := NewGrid(points)
+
+		tests := []struct {
+			radius float64
+			limit  int
+			keep   func(Point) bool
+		}{
+			{50, 0, nil},
+			{800, 0, nil},
+			{2500, 0, nil},
+			{2500, 5, nil},
+			{5000, 0, func(p Point) bool { return len(p.ID)%2 == 0 }},
+		}
+		for _, tt := range tests {
+			name := fmt.Sprintf("%s/%.0fm/limit%d/keep%v", c.name, tt.radius, tt.limit, tt.keep != nil)
+			t.Run(name, func(t *testing.T) {
+				// Query from each of a few random spots around the centre
+				for q := 0; q < 20; q++ {
+					lat := c.lat + (rng.Float64()-0.5)*0.1
+					lng := c.lng + (rng.Float64()-0.5)*0.1
+					got := g.Within(lat, lng, tt.radius, tt.limit, tt.keep)
+					want := bruteForce(points, lat, lng, tt.radius, tt.limit, tt.keep)
+					if len(got) == 0 && len(want) == 0 {
+						continue
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Fatalf("Within(%v, %v) found %d points, brute force %d", lat, lng, len(got), len(want))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWithinEdge(t *testing.T) {
+	// Points just inside the radius due north, east, south and west, with
+	// the north point just over a cell boundary
+	const radius = 1000.0
+	degree := validators.HaversineDistance(0, 0, 1, 0) * 1000
+	dLat := (radius - 0.5) / degree
+	lat, lng := 28.64-dLat+1e-7, 77.2197
+	dLng := (radius - 0.5) / (degree * math.Cos(lat*math.Pi/180))
+	points := []Point{
+		{ID: "n", Lat: lat + dLat, Lng: lng},
+		{ID: "e", Lat: lat, Lng: lng + dLng},
+		{ID: "s", Lat: lat - dLat, Lng: lng},
+		{ID: "w", Lat: lat, Lng: lng - dLng},
+	}
+	got := NewGrid(points).Within(lat, lng, radius, 0, nil)
+	if len(got) != len(points) {
+		t.Fatalf("Within found %+v, want all four edge points", got)
+	}
+	for _, n := range got {
+		if want := map[string]string{"n": "N", "e": "E", "s": "S", "w": "W"}[n.ID]; Compass(n.Bearing) != want {
+			t.Errorf("%s is at bearing %.1f (%s), want %s", n.ID, n.Bearing, Compass(n.Bearing), want)
+		}
+	}
+}
+
+func TestCompass(t *testing.T) {
+	tests := []struct {
+		bearing float64
+		want    string
+	}{
+		{0, "N"}, {22, "N"}, {23, "NE"}, {90, "E"}, {180, "S"}, {225, "SW"}, {292, "W"}, {293, "NW"}, {359, "N"},
+	}
+	for _, tt := range tests {
+		if got := Compass(tt.bearing); got != tt.want {
+			t.Errorf("Compass(%v) = %s, want %s", tt.bearing, got, tt.want)
+		}
+	}
+}
+
+func TestWalkSeconds(t *testing.T) {
+	tests := []struct {
+		meters float64
+		want   int
+	}{
+		{0, 0}, {100, 104}, {500, 520}, {1000, 1040},
+	}
+	for _, tt := range tests {
+		if got := WalkSeconds(tt.meters); got != tt.want {
+			t.Errorf("WalkSeconds(%v) = %d, want %d", tt.meters, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package timetable
+
+import (
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+)
+
+// ServiceDefaults describe the service assumed on lines without a schedule
+type ServiceDefaults struct {
+	FirstTrainTime          string
+	LastTrainTime           string
+	PeakFrequencyMinutes    int
+	OffPeakFrequencyMinutes int
+	PeakHours               [][2]string
+}
+
+// DefaultService is a typical metro service day, used until a line has its
+// own train_schedules row
+var DefaultService = ServiceDefaults{
+	FirstTrainTime:          "06:00:00",
+	LastTrainTime:           "23:00:00",
+	PeakFrequencyMinutes:    4,
+	OffPeakFrequencyMinutes: 8,
+	PeakHours:               [][2]string{{"08:00:00", "11:00:00"}, {"17:00:00", "21:00:00"}},
+}
+
+// AssumedSchedules returns terminus-to-terminus schedules for every line
+// direction that no train_schedules row covers. They get negative IDs so
+// trips generated from them can be told apart from published ones.
+func AssumedSchedules(
+	schedules []database.TrainSchedule,
+	lineStations []database.LineStation,
+	connections []database.StationConnection,
+	defaults ServiceDefaults,
+) ([]database.TrainSchedule, []database.PeakHour) {
+	topo := topology.New(lineStations, connections)
+
+	// Coverage follows the way the trains actually run, not the direction label
+	covered := make(map[string]bool)
+	for _, s := range schedules {
+		covered[s.LineID+"|"+topo.Direction(s.LineID, s.StartStationID, s.EndStationID)] = true
+	}
+
+	var assumed []database.TrainSchedule
+	var peaks []database.PeakHour
+	for _, lineID := range topo.Lines() {
+		for _, dir := range []string{topology.Forward, topology.Backward} {
+			if covered[lineID+"|"+dir] {
+				continue
+			}
+			starts, ends := topo.Termini(lineID, dir)
+			for _, start := range starts {
+				for _, end := range ends {
+					if _, ok := topo.Path(lineID, start, end); !ok {
+						continue
+					}
+					id := -(len(assumed) + 1)
+					assumed = append(assumed, database.TrainSchedule{
+						ID:                      id,
+						LineID:                  lineID,
+						Direction:               dir,
+						StartStationID:          start,
+						EndStationID:            end,
+						FirstTrainTime:          defaults.FirstTrainTime,
+						LastTrainTime:           defaults.LastTrainTime,
+						PeakFrequencyMinutes:    defaults.PeakFrequencyMinutes,
+						OffPeakFrequencyMinutes: defaults.OffPeakFrequencyMinutes,
+					})
+					for _, p := range defaults.PeakHours {
+						peaks = append(peaks, database.PeakHour{ScheduleID: id, StartTime: p[0], EndTime: p[1]})
+					}
+				}
+			}
+		}
+	}
+	return assumed, peaks
+}
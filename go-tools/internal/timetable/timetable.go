@@ -0,0 +1,336 @@
+package timetable
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+)
+
+// StopTime is a trip's call at one station, in seconds since the start of
+// the service day. Trips running past midnight have times of 24:00:00 or later.
+type StopTime struct {
+	StationID string `json:"stationId"`
+	Arrival   int    `json:"arrival"`
+	Departure int    `json:"departure"`
+}
+
+// Trip is one run of a train from a schedule's start station to its end station
+type Trip struct {
+	ID         string     `json:"id"`
+	ScheduleID int        `json:"scheduleId"`
+	LineID     string     `json:"lineId"`
+	Direction  string     `json:"direction"`
+	Peak       bool       `json:"peak"`
+	Assumed    bool       `json:"assumed"` // generated from an assumed schedule
+	Stops      []StopTime `json:"stops"`
+}
+
+// Origin returns the scheduled time the train is at its first station
+func (t Trip) Origin() int {
+	return t.Stops[0].Arrival
+}
+
+// Destination returns the last station of the trip
+func (t Trip) Destination() string {
+	return t.Stops[len(t.Stops)-1].StationID
+}
+
+// ScheduleSummary describes the trips generated for one train_schedules row
+type ScheduleSummary struct {
+	ScheduleID      int    `json:"scheduleId"`
+	LineID          string `json:"lineId"`
+	Direction       string `json:"direction"`
+	Trips           int    `json:"trips"`
+	PeakTrips       int    `json:"peakTrips"`
+	Stations        int    `json:"stations"`
+	FirstDeparture  string `json:"firstDeparture"`
+	LastDeparture   string `json:"lastDeparture"`
+	DurationSeconds int    `json:"durationSeconds"`
+}
+
+// Timetable is the synthetic timetable for a service day
+type Timetable struct {
+	Trips     []Trip            `json:"trips"`
+	Schedules []ScheduleSummary `json:"schedules"`
+	Warnings  []string          `json:"warnings,omitempty"`
+}
+
+// window is a peak period in seconds since midnight, [start, end)
+type window struct{ start, end int }
+
+// Generate builds every trip for a service day. Trains leave the start
+// station every peak_frequency_minutes inside a peak_hours window and every
+// off_peak_frequency_minutes outside, from first_train_time up to and
+// including last_train_time. Like trainPositionCalculator.ts, a train dwells
+// for the stop_time_seconds of the connection it is about to take, including
+// at the start station, and then travels for its travel_time_seconds.
+func Generate(
+	schedules []database.TrainSchedule,
+	peakHours []database.PeakHour,
+	lineStations []database.LineStation,
+	connections []database.StationConnection,
+) *Timetable {
+	tt := &Timetable{}
+	topo := topology.New(lineStations, connections)
+
+	peaks := make(map[int][]window)
+	for _, ph := range peakHours {
+		start, err := ParseClock(ph.StartTime)
+		if err != nil {
+			tt.warn("peak_hours#%d: start_time: %v", ph.ID, err)
+			continue
+		}
+		end, err := ParseClock(ph.EndTime)
+		if err != nil {
+			tt.warn("peak_hours#%d: end_time: %v", ph.ID, err)
+			continue
+		}
+		if end <= start {
+			tt.warn("peak_hours#%d: end_time %s is not after start_time %s", ph.ID, ph.EndTime, ph.StartTime)
+			continue
+		}
+		peaks[ph.ScheduleID] = append(peaks[ph.ScheduleID], window{start, end})
+	}
+
+	warned := make(map[string]bool)
+	for _, s := range schedules {
+		if hops := topo.Unordered(s.LineID); len(hops) > 0 && !warned[s.LineID] {
+			warned[s.LineID] = true
+			tt.warn("%s: %d station_connections do not follow the line_stations sequence and are not served (e.g. %s -> %s)",
+				s.LineID, len(hops), hops[0].From, hops[0].To)
+		}
+
+		first, err := ParseClock(s.FirstTrainTime)
+		if err != nil {
+			tt.warn("train_schedules#%d: first_train_time: %v", s.ID, err)
+			continue
+		}
+		last, err := ParseClock(s.LastTrainTime)
+		if err != nil {
+			tt.warn("train_schedules#%d: last_train_time: %v", s.ID, err)
+			continue
+		}
+		if last < first {
+			// Service runs past midnight
+			last += 24 * 3600
+		}
+		if s.PeakFrequencyMinutes <= 0 || s.OffPeakFrequencyMinutes <= 0 {
+			tt.warn("train_schedules#%d: frequencies must be positive (peak %d, off-peak %d)", s.ID, s.PeakFrequencyMinutes, s.OffPeakFrequencyMinutes)
+			continue
+		}
+
+		hops, ok := topo.Path(s.LineID, s.StartStationID, s.EndStationID)
+		if !ok {
+			tt.warn("train_schedules#%d: no route on %s from %s to %s", s.ID, s.LineID, s.StartStationID, s.EndStationID)
+			continue
+		}
+		// Trips are labelled with the way they run, as AssumedSchedules
+		// decides coverage, whatever the row says
+		dir := topo.Direction(s.LineID, s.StartStationID, s.EndStationID)
+		if dir != s.Direction {
+			tt.warn("train_schedules#%d: direction is '%s' but %s -> %s runs %s on %s; its trips are labelled %s",
+				s.ID, s.Direction, s.StartStationID, s.EndStationID, dir, s.LineID, dir)
+		}
+
+		summary := ScheduleSummary{
+			ScheduleID: s.ID,
+			LineID:     s.LineID,
+			Direction:  dir,
+			Stations:   len(hops) + 1,
+		}
+
+		prefix := fmt.Sprintf("%s-%s-%d", s.LineID, dir, s.ID)
+		if s.ID < 0 {
+			prefix = fmt.Sprintf("%s-%s-a%d", s.LineID, dir, -s.ID)
+		}
+
+		for departure, n := first, 1; departure <= last; n++ {
+			peak := inWindows(peaks[s.ID], departure%(24*3600))
+			trip := Trip{
+				ID:         fmt.Sprintf("%s-%03d", prefix, n),
+				ScheduleID: s.ID,
+				LineID:     s.LineID,
+				Direction:  dir,
+				Peak:       peak,
+				Assumed:    s.ID < 0,
+				Stops:      stopTimes(departure, hops),
+			}
+			tt.Trips = append(tt.Trips, trip)
+
+			summary.Trips++
+			if peak {
+				summary.PeakTrips++
+			}
+
+			headway := s.OffPeakFrequencyMinutes
+			if peak {
+				headway = s.PeakFrequencyMinutes
+			}
+			departure += headway * 60
+		}
+
+		if summary.Trips > 0 {
+			firstTrip := tt.Trips[len(tt.Trips)-summary.Trips]
+			lastTrip := tt.Trips[len(tt.Trips)-1]
+			summary.FirstDeparture = FormatClock(firstTrip.Origin())
+			summary.LastDeparture = FormatClock(lastTrip.Origin())
+			summary.DurationSeconds = lastTrip.Stops[len(lastTrip.Stops)-1].Arrival - lastTrip.Origin()
+		}
+		tt.Schedules = append(tt.Schedules, summary)
+	}
+
+	return tt
+}
+
+// stopTimes lays out one trip starting at the origin at the given time
+func stopTimes(start int, hops []topology.Hop) []StopTime {
+	stops := make([]StopTime, 0, len(hops)+1)
+	at := start
+	for _, hop := range hops {
+		stops = append(stops, StopTime{StationID: hop.From, Arrival: at, Departure: at + hop.StopTimeSeconds})
+		at += hop.StopTimeSeconds + hop.TravelTimeSeconds
+	}
+	if len(hops) > 0 {
+		end := hops[len(hops)-1].To
+		stops = append(stops, StopTime{StationID: end, Arrival: at, Departure: at})
+	}
+	return stops
+}
+
+// inWindows reports whether a time of day falls in any peak window
+func inWindows(windows []window, t int) bool {
+	for _, w := range windows {
+		if t >= w.start && t < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+func (tt *Timetable) warn(format string, args ...interface{}) {
+	tt.Warnings = append(tt.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Departure is a train leaving a station
+type Departure struct {
+	StationID   string `json:"stationId"`
+	Time        int    `json:"time"`
+	TripID      string `json:"tripId"`
+	LineID      string `json:"lineId"`
+	Direction   string `json:"direction"`
+	Destination string `json:"destination"`
+	Assumed     bool   `json:"assumed"`
+}
+
+// Departures returns every departure from a station in time order. Arrivals
+// at a trip's final station are not departures.
+func (tt *Timetable) Departures(stationID string) []Departure {
+	var deps []Departure
+	for _, trip := range tt.Trips {
+		for i, stop := range trip.Stops[:len(trip.Stops)-1] {
+			if stop.StationID == stationID {
+				deps = append(deps, departureOf(trip, i))
+			}
+		}
+	}
+	sortDepartures(deps)
+	return deps
+}
+
+// AllDepartures returns the departures from every station, ordered by station and time
+func (tt *Timetable) AllDepartures() []Departure {
+	var deps []Departure
+	for _, trip := range tt.Trips {
+		for i := range trip.Stops[:len(trip.Stops)-1] {
+			deps = append(deps, departureOf(trip, i))
+		}
+	}
+	sortDepartures(deps)
+	return deps
+}
+
+func departureOf(trip Trip, i int) Departure {
+	return Departure{
+		StationID:   trip.Stops[i].StationID,
+		Time:        trip.Stops[i].Departure,
+		TripID:      trip.ID,
+		LineID:      trip.LineID,
+		Direction:   trip.Direction,
+		Destination: trip.Destination(),
+		Assumed:     trip.Assumed,
+	}
+}
+
+func sortDepartures(deps []Departure) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].StationID != deps[j].StationID {
+			return deps[i].StationID < deps[j].StationID
+		}
+		return deps[i].Time < deps[j].Time
+	})
+}
+
+// ParseClock parses "HH:MM" or "HH:MM:SS" into seconds since midnight.
+// Hours of 24 and above are allowed for service past midnight.
+func ParseClock(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM:SS", s)
+	}
+	var values [3]int
+	for i, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || (i > 0 && v > 59) {
+			return 0, fmt.Errorf("invalid time '%s', expected HH:MM:SS", s)
+		}
+		values[i] = v
+	}
+	return values[0]*3600 + values[1]*60 + values[2], nil
+}
+
+// FormatClock formats seconds since midnight as HH:MM:SS
+func FormatClock(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// Occurrence is a departure on a specific date
+type Occurrence struct {
+	Departure
+	At time.Time `json:"at"`
+}
+
+// ServiceDay returns local midnight of the day containing t
+func ServiceDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// At converts seconds since the start of a service day to a time
+func At(day time.Time, seconds int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, seconds, 0, day.Location())
+}
+
+// Upcoming returns the departures leaving at or after a time and before the
+// horizon, in time order. Trips of the previous service day that run past
+// midnight and the next day's first trains are included.
+func Upcoming(deps []Departure, at time.Time, loc *time.Location, horizon time.Duration) []Occurrence {
+	today := ServiceDay(at, loc)
+	until := at.Add(horizon)
+
+	var upcoming []Occurrence
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today, today.AddDate(0, 0, 1)} {
+		for _, d := range deps {
+			when := At(day, d.Time)
+			if !when.Before(at) && when.Before(until) {
+				upcoming = append(upcoming, Occurrence{Departure: d, At: when})
+			}
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool { return upcoming[i].At.Before(upcoming[j].At) })
+	return upcoming
+}
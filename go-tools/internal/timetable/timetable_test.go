@@ -0,0 +1,233 @@
+package timetable
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+)
+
+// testLine is line L through a, b and c, two minutes a hop with 30s dwells
+func testLine() ([]database.LineStation, []database.StationConnection) {
+	lineStations := []database.LineStation{
+		{LineID: "L", StationID: "a", SequenceNumber: 1, Direction: topology.Forward},
+		{LineID: "L", StationID: "b", SequenceNumber: 2, Direction: topology.Forward},
+		{LineID: "L", StationID: "c", SequenceNumber: 3, Direction: topology.Forward},
+	}
+	var connections []database.StationConnection
+	for _, hop := range [][2]string{{"a", "b"}, {"b", "c"}, {"c", "b"}, {"b", "a"}} {
+		connections = append(connections, database.StationConnection{
+			FromStationID: hop[0], ToStationID: hop[1], LineID: "L", TravelTimeSeconds: 120, StopTimeSeconds: 30,
+		})
+	}
+	return lineStations, connections
+}
+
+func schedule(id int, direction, start, end, first, last string, peak, offPeak int) database.TrainSchedule {
+	return database.TrainSchedule{
+		ID: id, LineID: "L", Direction: direction, StartStationID: start, EndStationID: end,
+		FirstTrainTime: first, LastTrainTime: last, PeakFrequencyMinutes: peak, OffPeakFrequencyMinutes: offPeak,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedule  database.TrainSchedule
+		peaks     []database.PeakHour
+		origins   []string // departure from the start station of each trip
+		peak      []bool
+		direction string
+		warning   string // a substring of the only warning, if any
+	}{
+		{
+			name:     "off-peak headway",
+			schedule: schedule(1, topology.Forward, "a", "c", "06:00:00", "07:00:00", 4, 20),
+			origins:  []string{"06:00:00", "06:20:00", "06:40:00", "07:00:00"},
+			peak:     []bool{false, false, false, false}, direction: topology.Forward,
+		},
+		{
+			name:     "last train not on a headway",
+			schedule: schedule(1, topology.Forward, "a", "c", "06:00:00", "06:50:00", 4, 20),
+			origins:  []string{"06:00:00", "06:20:00", "06:40:00"},
+			peak:     []bool{false, false, false}, direction: topology.Forward,
+		},
+		{
+			name:     "peak window switches headway both ways",
+			schedule: schedule(1, topology.Forward, "a", "c", "08:00:00", "09:20:00", 10, 30),
+			peaks:    []database.PeakHour{{ID: 1, ScheduleID: 1, StartTime: "08:30:00", EndTime: "09:00:00"}},
+			origins:  []string{"08:00:00", "08:30:00", "08:40:00", "08:50:00", "09:00:00"},
+			peak:     []bool{false, true, true, true, false}, direction: topology.Forward,
+		},
+		{
+			name:     "peak hours of another schedule do not apply",
+			schedule: schedule(1, topology.Forward, "a", "c", "08:00:00", "09:00:00", 10, 30),
+			peaks:    []database.PeakHour{{ID: 1, ScheduleID: 2, StartTime: "08:00:00", EndTime: "09:00:00"}},
+			origins:  []string{"08:00:00", "08:30:00", "09:00:00"},
+			peak:     []bool{false, false, false}, direction: topology.Forward,
+		},
+		{
+			name:     "service past midnight",
+			schedule: schedule(1, topology.Backward, "c", "a", "23:30:00", "00:30:00", 10, 30),
+			origins:  []string{"23:30:00", "24:00:00", "24:30:00"},
+			peak:     []bool{false, false, false}, direction: topology.Backward,
+		},
+		{
+			name:     "mislabelled schedule runs the way its stations go",
+			schedule: schedule(1, topology.Forward, "c", "a", "06:00:00", "06:20:00", 4, 20),
+			origins:  []string{"06:00:00", "06:20:00"},
+			peak:     []bool{false, false}, direction: topology.Backward,
+			warning: "direction is 'forward' but c -> a runs backward",
+		},
+		{
+			name:     "bad frequency",
+			schedule: schedule(1, topology.Forward, "a", "c", "06:00:00", "07:00:00", 0, 20),
+			warning:  "frequencies must be positive",
+		},
+		{
+			name:     "bad clock",
+			schedule: schedule(1, topology.Forward, "a", "c", "6am", "07:00:00", 4, 20),
+			warning:  "first_train_time",
+		},
+		{
+			name:     "stations not on the line",
+			schedule: schedule(1, topology.Forward, "a", "z", "06:00:00", "07:00:00", 4, 20),
+			warning:  "no route on L from a to z",
+		},
+	}
+	lineStations, connections := testLine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timetable := Generate([]database.TrainSchedule{tt.schedule}, tt.peaks, lineStations, connections)
+
+			var origins []string
+			var peak []bool
+			for _, trip := range timetable.Trips {
+				origins = append(origins, FormatClock(trip.Origin()))
+				peak = append(peak, trip.Peak)
+				if trip.Direction != tt.direction {
+					t.Errorf("trip %s runs %s, want %s", trip.ID, trip.Direction, tt.direction)
+				}
+				if !strings.HasPrefix(trip.ID, "L-"+tt.direction+"-1-") {
+					t.Errorf("trip ID %s does not carry direction %s", trip.ID, tt.direction)
+				}
+			}
+			if !reflect.DeepEqual(origins, tt.origins) || !reflect.DeepEqual(peak, tt.peak) {
+				t.Errorf("trips leave at %v (peak %v), want %v (peak %v)", origins, peak, tt.origins, tt.peak)
+			}
+
+			if tt.warning == "" && len(timetable.Warnings) > 0 {
+				t.Errorf("unexpected warnings %q", timetable.Warnings)
+			}
+			if tt.warning != "" && (len(timetable.Warnings) != 1 || !strings.Contains(timetable.Warnings[0], tt.warning)) {
+				t.Errorf("warnings = %q, want one containing %q", timetable.Warnings, tt.warning)
+			}
+			if len(tt.origins) > 0 {
+				summary := timetable.Schedules[0]
+				if summary.Trips != len(tt.origins) || summary.Direction != tt.direction {
+					t.Errorf("summary = %+v, want %d trips running %s", summary, len(tt.origins), tt.direction)
+				}
+			}
+		})
+	}
+}
+
+func TestStopTimes(t *testing.T) {
+	lineStations, connections := testLine()
+	s := schedule(1, topology.Forward, "a", "c", "23:55:00", "23:55:00", 4, 20)
+	timetable := Generate([]database.TrainSchedule{s}, nil, lineStations, connections)
+	if len(timetable.Trips) != 1 {
+		t.Fatalf("got %d trips, want 1", len(timetable.Trips))
+	}
+	// The train dwells before each hop, and arrives at c past midnight
+	want := []StopTime{
+		{StationID: "a", Arrival: 86100, Departure: 86130},
+		{StationID: "b", Arrival: 86250, Departure: 86280},
+		{StationID: "c", Arrival: 86400, Departure: 86400},
+	}
+	if got := timetable.Trips[0].Stops; !reflect.DeepEqual(got, want) {
+		t.Errorf("stops = %+v, want %+v", got, want)
+	}
+}
+
+func TestAssumedSchedulesFollowTopology(t *testing.T) {
+	lineStations, connections := testLine()
+	// Published c -> a, labelled forward: only the real forward service is assumed
+	published := []database.TrainSchedule{schedule(1, topology.Forward, "c", "a", "06:00:00", "23:00:00", 4, 8)}
+	assumed, peaks := AssumedSchedules(published, lineStations, connections, DefaultService)
+	if len(assumed) != 1 || assumed[0].Direction != topology.Forward || assumed[0].StartStationID != "a" || assumed[0].EndStationID != "c" {
+		t.Fatalf("assumed = %+v, want one forward a -> c schedule", assumed)
+	}
+	if len(peaks) != len(DefaultService.PeakHours) {
+		t.Errorf("got %d assumed peak hours, want %d", len(peaks), len(DefaultService.PeakHours))
+	}
+
+	timetable := Generate(append(published, assumed...), peaks, lineStations, connections)
+	directions := make(map[string]map[bool]bool)
+	for _, trip := range timetable.Trips {
+		if directions[trip.Direction] == nil {
+			directions[trip.Direction] = make(map[bool]bool)
+		}
+		directions[trip.Direction][trip.Assumed] = true
+	}
+	want := map[string]map[bool]bool{topology.Backward: {false: true}, topology.Forward: {true: true}}
+	if !reflect.DeepEqual(directions, want) {
+		t.Errorf("trip directions by assumed = %v, want published backward and assumed forward", directions)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	loc := time.FixedZone("IST", 19800)
+	deps := []Departure{
+		{TripID: "late", Time: 24*3600 + 15*60}, // 00:15 on the next calendar day
+		{TripID: "first", Time: 6 * 3600},
+		{TripID: "evening", Time: 23 * 3600},
+	}
+	tests := []struct {
+		name    string
+		at      time.Time
+		horizon time.Duration
+		want    []string
+	}{
+		{"evening", time.Date(2026, 3, 2, 22, 0, 0, 0, loc), 3 * time.Hour, []string{"evening", "late"}},
+		{"after midnight", time.Date(2026, 3, 3, 0, 5, 0, 0, loc), 6 * time.Hour, []string{"late", "first"}},
+		{"at the departure", time.Date(2026, 3, 2, 6, 0, 0, 0, loc), time.Minute, []string{"first"}},
+		{"horizon excludes", time.Date(2026, 3, 2, 6, 1, 0, 0, loc), time.Hour, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, o := range Upcoming(deps, tt.at, loc, tt.horizon) {
+				got = append(got, o.TripID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Upcoming = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"06:00", 21600, true},
+		{"06:00:30", 21630, true},
+		{"25:10:00", 90600, true},
+		{"06:60:00", 0, false},
+		{"6", 0, false},
+		{"06:00:00:00", 0, false},
+		{"-1:00", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseClock(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseClock(%q) = %d, %v, want %d (ok %v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package topology
+
+import (
+	"sort"
+
+	"metro-tools/internal/database"
+)
+
+// Directions as stored in line_stations and train_sightings
+const (
+	Forward  = "forward"
+	Backward = "backward"
+)
+
+// Hop is a move from one station to the adjacent one on a line
+type Hop struct {
+	ConnectionID      int
+	LineID            string
+	From              string
+	To                string
+	TravelTimeSeconds int
+	StopTimeSeconds   int
+}
+
+type hopKey struct {
+	lineID, stationID, direction string
+}
+
+// Topology answers ordering questions about each line: which way a hop
+// goes and which station comes next in a direction
+type Topology struct {
+	// line -> station -> forward sequence numbers; a station listed on both
+	// a trunk and a branch that restarts its numbering has several
+	sequence map[string]map[string][]int
+	next     map[hopKey][]Hop
+	segment  map[string]Hop // "line|from|to" -> hop
+	// connections whose stations have no order on the line
+	unordered map[string][]Hop
+}
+
+// New builds the line topology from line_stations and station_connections.
+// Stations are ordered by their forward sequence; lines seeded with only
+// backward rows fall back to the reversed backward sequence. A hop's
+// direction comes from the closest pair of sequence numbers of its two
+// stations, which keeps branches numbered from 1 consistent with the trunk.
+func New(lineStations []database.LineStation, connections []database.StationConnection) *Topology {
+	t := &Topology{
+		sequence:  make(map[string]map[string][]int),
+		next:      make(map[hopKey][]Hop),
+		segment:   make(map[string]Hop),
+		unordered: make(map[string][]Hop),
+	}
+
+	hasForward := make(map[string]bool)
+	for _, ls := range lineStations {
+		if ls.Direction == Forward {
+			hasForward[ls.LineID] = true
+		}
+	}
+	for _, ls := range lineStations {
+		seq := ls.SequenceNumber
+		if ls.Direction != Forward {
+			if hasForward[ls.LineID] {
+				continue
+			}
+			seq = -seq
+		}
+		if t.sequence[ls.LineID] == nil {
+			t.sequence[ls.LineID] = make(map[string][]int)
+		}
+		t.sequence[ls.LineID][ls.StationID] = append(t.sequence[ls.LineID][ls.StationID], seq)
+	}
+
+	for _, c := range connections {
+		hop := Hop{
+			ConnectionID:      c.ID,
+			LineID:            c.LineID,
+			From:              c.FromStationID,
+			To:                c.ToStationID,
+			TravelTimeSeconds: c.TravelTimeSeconds,
+			StopTimeSeconds:   c.StopTimeSeconds,
+		}
+		dir := t.Direction(c.LineID, c.FromStationID, c.ToStationID)
+		if dir == "" {
+			t.unordered[c.LineID] = append(t.unordered[c.LineID], hop)
+			continue
+		}
+		key := hopKey{c.LineID, c.FromStationID, dir}
+		t.next[key] = append(t.next[key], hop)
+		t.segment[c.LineID+"|"+c.FromStationID+"|"+c.ToStationID] = hop
+	}
+
+	return t
+}
+
+// Direction returns forward or backward for a hop between two stations on a
+// line, or "" if either station is not on the line
+func (t *Topology) Direction(lineID, from, to string) string {
+	seq := t.sequence[lineID]
+	best := 0
+	for _, a := range seq[from] {
+		for _, b := range seq[to] {
+			if d := b - a; best == 0 || abs(d) < abs(best) {
+				best = d
+			}
+		}
+	}
+	switch {
+	case best > 0:
+		return Forward
+	case best < 0:
+		return Backward
+	}
+	return ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Next returns the hops leaving a station in a direction. Most stations have
+// one; branch points have several.
+func (t *Topology) Next(lineID, stationID, direction string) []Hop {
+	return t.next[hopKey{lineID, stationID, direction}]
+}
+
+// Unordered returns the connections on a line that line_stations gives no
+// direction for, such as between two stations with the same sequence number.
+// They are left out of Next, Segment and Path.
+func (t *Topology) Unordered(lineID string) []Hop {
+	return t.unordered[lineID]
+}
+
+// Segment returns the hop between two adjacent stations on a line
+func (t *Topology) Segment(lineID, from, to string) (Hop, bool) {
+	hop, ok := t.segment[lineID+"|"+from+"|"+to]
+	return hop, ok
+}
+
+// Sequence returns a station's forward position on a line, the lowest if
+// it is listed more than once
+func (t *Topology) Sequence(lineID, stationID string) (int, bool) {
+	seqs, ok := t.sequence[lineID][stationID]
+	if !ok {
+		return 0, false
+	}
+	lowest := seqs[0]
+	for _, s := range seqs[1:] {
+		if s < lowest {
+			lowest = s
+		}
+	}
+	return lowest, true
+}
+
+// Stations returns the stations of a line in travel order for a direction
+func (t *Topology) Stations(lineID, direction string) []string {
+	stations := make([]string, 0, len(t.sequence[lineID]))
+	seq := make(map[string]int)
+	for id := range t.sequence[lineID] {
+		stations = append(stations, id)
+		seq[id], _ = t.Sequence(lineID, id)
+	}
+	sort.Slice(stations, func(i, j int) bool {
+		a, b := seq[stations[i]], seq[stations[j]]
+		if a == b {
+			return stations[i] < stations[j]
+		}
+		if direction == Backward {
+			return a > b
+		}
+		return a < b
+	})
+	return stations
+}
+
+// Lines returns the IDs of all lines with stations
+func (t *Topology) Lines() []string {
+	lines := make([]string, 0, len(t.sequence))
+	for id := range t.sequence {
+		lines = append(lines, id)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// Path returns the hops from one station to another along a line, following
+// the direction between them and the right branch at branch points
+func (t *Topology) Path(lineID, from, to string) ([]Hop, bool) {
+	dir := t.Direction(lineID, from, to)
+	if dir == "" {
+		return nil, false
+	}
+
+	visited := make(map[string]bool)
+	var walk func(at string) ([]Hop, bool)
+	walk = func(at string) ([]Hop, bool) {
+		if at == to {
+			return nil, true
+		}
+		if visited[at] {
+			return nil, false
+		}
+		visited[at] = true
+		for _, hop := range t.Next(lineID, at, dir) {
+			if rest, ok := walk(hop.To); ok {
+				return append([]Hop{hop}, rest...), true
+			}
+		}
+		return nil, false
+	}
+	return walk(from)
+}
+
+// Termini returns the stations where trains in a direction start (no hop
+// arrives there) and end (no hop leaves). Branched lines have several of each.
+func (t *Topology) Termini(lineID, direction string) (starts, ends []string) {
+	arriving := make(map[string]bool)
+	for _, id := range t.Stations(lineID, direction) {
+		for _, hop := range t.Next(lineID, id, direction) {
+			arriving[hop.To] = true
+		}
+	}
+	for _, id := range t.Stations(lineID, direction) {
+		leaving := len(t.Next(lineID, id, direction)) > 0
+		switch {
+		case leaving && !arriving[id]:
+			starts = append(starts, id)
+		case !leaving && arriving[id]:
+			ends = append(ends, id)
+		}
+	}
+	return starts, ends
+}
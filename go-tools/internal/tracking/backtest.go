@@ -0,0 +1,133 @@
+package tracking
+
+import (
+	"time"
+
+	"metro-tools/internal/topology"
+)
+
+// LevelScore is the backtest outcome for one confidence level
+type LevelScore struct {
+	Level       string  `json:"level"`
+	Predictions int     `json:"predictions"`
+	Hits        int     `json:"hits"`
+	HitRate     float64 `json:"hitRate"`
+	MeanScore   float64 `json:"meanScore"`
+}
+
+// AgeScore is the backtest outcome for a range of sighting ages
+type AgeScore struct {
+	MaxAgeSeconds int     `json:"maxAgeSeconds"`
+	Predictions   int     `json:"predictions"`
+	Hits          int     `json:"hits"`
+	HitRate       float64 `json:"hitRate"`
+}
+
+// Backtest measures how often the estimator places a train where it is next
+// reported, grouped by the confidence the estimator claimed
+type Backtest struct {
+	LineID      string          `json:"lineId"`
+	Mode        NextStationMode `json:"mode"`
+	Sightings   int             `json:"sightings"`
+	Predictions int             `json:"predictions"`
+	NoEstimate  int             `json:"noEstimate"`
+	Hits        int             `json:"hits"`
+	HitRate     float64         `json:"hitRate"`
+	// Brier is the mean squared error of confidenceScore as a probability of a hit
+	Brier  float64      `json:"brier"`
+	Levels []LevelScore `json:"levels"`
+	Ages   []AgeScore   `json:"ages"`
+}
+
+// backtestAges are the upper bounds of the age buckets, matching the
+// confidence decay steps
+var backtestAges = []int{60, 180, 300, 600}
+
+// Hit reports whether a train reported at stationID agrees with a position:
+// it is the current station, or the next one while in transit
+func (p *Position) Hit(stationID string) bool {
+	if p.CurrentStationID == stationID {
+		return true
+	}
+	return p.Status == StatusInTransit && p.NextStationID != nil && *p.NextStationID == stationID
+}
+
+// RunBacktest replays every sighting on a line in [from, to) and scores the
+// estimate made just before it from earlier sightings only
+func (e *Estimator) RunBacktest(lineID string, from, to time.Time) *Backtest {
+	bt := &Backtest{LineID: lineID, Mode: e.Mode}
+
+	levels := map[string]*LevelScore{
+		ConfidenceHigh:   {Level: ConfidenceHigh},
+		ConfidenceMedium: {Level: ConfidenceMedium},
+		ConfidenceLow:    {Level: ConfidenceLow},
+	}
+	ages := make([]AgeScore, len(backtestAges))
+	for i, a := range backtestAges {
+		ages[i].MaxAgeSeconds = a
+	}
+
+	for _, dir := range []string{topology.Forward, topology.Backward} {
+		for _, truth := range e.Sightings(lineID, dir) {
+			if (!from.IsZero() && truth.Timestamp.Before(from)) || (!to.IsZero() && !truth.Timestamp.Before(to)) {
+				continue
+			}
+			bt.Sightings++
+
+			// Sightings have one-second resolution, so this excludes the
+			// truth itself and anything reported in the same second
+			p := e.Estimate(lineID, dir, truth.Timestamp.Add(-time.Second))
+			if p == nil {
+				bt.NoEstimate++
+				continue
+			}
+			bt.Predictions++
+
+			hit := p.Hit(truth.StationID)
+			outcome := 0.0
+			if hit {
+				bt.Hits++
+				outcome = 1
+			}
+			bt.Brier += (p.ConfidenceScore - outcome) * (p.ConfidenceScore - outcome)
+
+			level := levels[p.Confidence]
+			level.Predictions++
+			level.MeanScore += p.ConfidenceScore
+			if hit {
+				level.Hits++
+			}
+
+			for i := range ages {
+				if p.LastSightingAge < ages[i].MaxAgeSeconds || i == len(ages)-1 {
+					ages[i].Predictions++
+					if hit {
+						ages[i].Hits++
+					}
+					break
+				}
+			}
+		}
+	}
+
+	if bt.Predictions > 0 {
+		bt.HitRate = float64(bt.Hits) / float64(bt.Predictions)
+		bt.Brier /= float64(bt.Predictions)
+	}
+	for _, name := range []string{ConfidenceHigh, ConfidenceMedium, ConfidenceLow} {
+		level := levels[name]
+		if level.Predictions > 0 {
+			level.HitRate = float64(level.Hits) / float64(level.Predictions)
+			level.MeanScore /= float64(level.Predictions)
+		}
+		bt.Levels = append(bt.Levels, *level)
+	}
+	for i := range ages {
+		if ages[i].Predictions > 0 {
+			ages[i].HitRate = float64(ages[i].Hits) / float64(ages[i].Predictions)
+		}
+	}
+	bt.Ages = ages
+
+	return bt
+}
@@ -0,0 +1,303 @@
+package tracking
+
+import (
+	"sort"
+	"time"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/topology"
+)
+
+// Constants from trainPositionEstimator.service.ts
+const (
+	DwellTimeSeconds         = 30  // average station dwell time
+	AverageTravelTimeSeconds = 120 // used when the next hop is unknown
+	DefaultMaxAge            = 600 * time.Second
+)
+
+// Train status values
+const (
+	StatusAtStation = "at_station"
+	StatusInTransit = "in_transit"
+	StatusUnknown   = "unknown"
+)
+
+// Confidence levels
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Position mirrors EstimatedTrainPosition from the backend
+type Position struct {
+	LineID                      string  `json:"lineId"`
+	Direction                   string  `json:"direction"`
+	Status                      string  `json:"status"`
+	CurrentStationID            string  `json:"currentStationId"`
+	CurrentStationName          string  `json:"currentStationName"`
+	NextStationID               *string `json:"nextStationId"`
+	NextStationName             *string `json:"nextStationName"`
+	ProgressPercent             float64 `json:"progressPercent"`
+	Confidence                  string  `json:"confidence"`
+	ConfidenceScore             float64 `json:"confidenceScore"`
+	LastSightingAge             int     `json:"lastSightingAge"`
+	EstimatedArrivalNextStation *int    `json:"estimatedArrivalNextStation"`
+	SightingID                  int     `json:"sightingId"`
+}
+
+// NextStation is the station a train heads to after the one it was seen at
+type NextStation struct {
+	ID         string
+	Name       string
+	TravelTime int // seconds
+}
+
+// Movement is the part of a Position derived from the sighting's age
+type Movement struct {
+	Status             string
+	CurrentStationID   string
+	CurrentStationName string
+	NextStationID      *string
+	NextStationName    *string
+	ProgressPercent    float64
+	EstimatedArrival   *int
+}
+
+// CalculatePositionFromAge places a train relative to the station it was last
+// seen at: still dwelling, in transit to the next station, or arrived there
+func CalculatePositionFromAge(ageSeconds int, stationID, stationName string, next *NextStation) Movement {
+	// If less than dwell time, train is still at the station
+	if ageSeconds < DwellTimeSeconds {
+		m := Movement{
+			Status:             StatusAtStation,
+			CurrentStationID:   stationID,
+			CurrentStationName: stationName,
+		}
+		if next != nil {
+			m.NextStationID = &next.ID
+			m.NextStationName = &next.Name
+			arrival := next.TravelTime
+			m.EstimatedArrival = &arrival
+		}
+		return m
+	}
+
+	// If between dwell time and travel time, train is in transit
+	travelTime := AverageTravelTimeSeconds
+	if next != nil && next.TravelTime != 0 {
+		travelTime = next.TravelTime
+	}
+	transitTime := ageSeconds - DwellTimeSeconds
+
+	if transitTime < travelTime && next != nil {
+		progress := float64(transitTime) / float64(travelTime) * 100
+		if progress > 100 {
+			progress = 100
+		}
+		remaining := travelTime - transitTime
+		return Movement{
+			Status:             StatusInTransit,
+			CurrentStationID:   stationID,
+			CurrentStationName: stationName,
+			NextStationID:      &next.ID,
+			NextStationName:    &next.Name,
+			ProgressPercent:    progress,
+			EstimatedArrival:   &remaining,
+		}
+	}
+
+	// If more time has passed, train has likely reached next station
+	if next != nil {
+		return Movement{
+			Status:             StatusAtStation,
+			CurrentStationID:   next.ID,
+			CurrentStationName: next.Name,
+		}
+	}
+
+	// Unknown status - data too old or incomplete
+	return Movement{
+		Status:             StatusUnknown,
+		CurrentStationID:   stationID,
+		CurrentStationName: stationName,
+	}
+}
+
+// CalculateConfidence combines the sighting's own confidence with a decay
+// by age and maps the result to a level
+func CalculateConfidence(ageSeconds int, sightingConfidence float64) (string, float64) {
+	var ageConfidence float64
+	switch {
+	case ageSeconds < 60:
+		ageConfidence = 1.0
+	case ageSeconds < 180:
+		ageConfidence = 0.8
+	case ageSeconds < 300:
+		ageConfidence = 0.6
+	default:
+		ageConfidence = 0.4
+	}
+
+	score := sightingConfidence * ageConfidence
+	switch {
+	case score >= 0.7:
+		return ConfidenceHigh, score
+	case score >= 0.4:
+		return ConfidenceMedium, score
+	default:
+		return ConfidenceLow, score
+	}
+}
+
+// NextStationMode selects how the next station is looked up
+type NextStationMode string
+
+const (
+	// NextStationFirst takes the first connection leaving the station on the
+	// line regardless of direction, as the backend service does
+	NextStationFirst NextStationMode = "first"
+	// NextStationDirectional follows the line in the train's direction
+	NextStationDirectional NextStationMode = "directional"
+)
+
+// Estimator estimates train positions from a history of sightings
+type Estimator struct {
+	Mode   NextStationMode
+	MaxAge time.Duration
+
+	stations map[string]database.MetroStation
+	first    map[string]database.StationConnection // "line|from" -> first connection by id
+	topo     *topology.Topology
+	history  map[string][]database.TrainSighting // "line|direction" -> sightings by time
+}
+
+// NewEstimator indexes the network and the sightings to replay
+func NewEstimator(
+	stations []database.MetroStation,
+	lineStations []database.LineStation,
+	connections []database.StationConnection,
+	sightings []database.TrainSighting,
+) *Estimator {
+	e := &Estimator{
+		Mode:     NextStationFirst,
+		MaxAge:   DefaultMaxAge,
+		stations: make(map[string]database.MetroStation),
+		first:    make(map[string]database.StationConnection),
+		topo:     topology.New(lineStations, connections),
+		history:  make(map[string][]database.TrainSighting),
+	}
+	for _, s := range stations {
+		e.stations[s.ID] = s
+	}
+
+	// SQLite returns rows in rowid order for the backend's LIMIT 1 query
+	byID := append([]database.StationConnection(nil), connections...)
+	sort.Slice(byID, func(i, j int) bool { return byID[i].ID < byID[j].ID })
+	for _, c := range byID {
+		key := c.LineID + "|" + c.FromStationID
+		if _, ok := e.first[key]; !ok {
+			e.first[key] = c
+		}
+	}
+
+	for _, s := range sightings {
+		key := s.LineID + "|" + s.Direction
+		e.history[key] = append(e.history[key], s)
+	}
+	for _, list := range e.history {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Timestamp.Before(list[j].Timestamp) })
+	}
+	return e
+}
+
+// Sightings returns the replayed sightings for a line and direction in time order
+func (e *Estimator) Sightings(lineID, direction string) []database.TrainSighting {
+	return e.history[lineID+"|"+direction]
+}
+
+// Latest returns the newest sighting at or before a time, no older than MaxAge
+func (e *Estimator) Latest(lineID, direction string, at time.Time) (database.TrainSighting, bool) {
+	list := e.history[lineID+"|"+direction]
+	i := sort.Search(len(list), func(i int) bool { return list[i].Timestamp.After(at) }) - 1
+	if i < 0 || list[i].Timestamp.Before(at.Add(-e.MaxAge)) {
+		return database.TrainSighting{}, false
+	}
+	return list[i], true
+}
+
+// NextStation looks up the station after stationID using the estimator's mode
+func (e *Estimator) NextStation(stationID, lineID, direction string) *NextStation {
+	var to string
+	var travel int
+	switch e.Mode {
+	case NextStationDirectional:
+		hops := e.topo.Next(lineID, stationID, direction)
+		if len(hops) == 0 {
+			return nil
+		}
+		to, travel = hops[0].To, hops[0].TravelTimeSeconds
+	default:
+		c, ok := e.first[lineID+"|"+stationID]
+		if !ok {
+			return nil
+		}
+		to, travel = c.ToStationID, c.TravelTimeSeconds
+	}
+
+	station, ok := e.stations[to]
+	if !ok {
+		return nil
+	}
+	return &NextStation{ID: station.ID, Name: station.Name, TravelTime: travel}
+}
+
+// Estimate returns the estimated position of the latest train on a line and
+// direction at a point in time, or nil if there is no recent sighting
+func (e *Estimator) Estimate(lineID, direction string, at time.Time) *Position {
+	sighting, ok := e.Latest(lineID, direction, at)
+	if !ok {
+		return nil
+	}
+	station, ok := e.stations[sighting.StationID]
+	if !ok {
+		return nil
+	}
+
+	age := int(at.Sub(sighting.Timestamp) / time.Second)
+	next := e.NextStation(sighting.StationID, lineID, direction)
+	m := CalculatePositionFromAge(age, station.ID, station.Name, next)
+
+	sightingConfidence := sighting.ConfidenceScore
+	if sightingConfidence == 0 {
+		sightingConfidence = 1.0
+	}
+	level, score := CalculateConfidence(age, sightingConfidence)
+
+	return &Position{
+		LineID:                      lineID,
+		Direction:                   direction,
+		Status:                      m.Status,
+		CurrentStationID:            m.CurrentStationID,
+		CurrentStationName:          m.CurrentStationName,
+		NextStationID:               m.NextStationID,
+		NextStationName:             m.NextStationName,
+		ProgressPercent:             m.ProgressPercent,
+		Confidence:                  level,
+		ConfidenceScore:             score,
+		LastSightingAge:             age,
+		EstimatedArrivalNextStation: m.EstimatedArrival,
+		SightingID:                  sighting.ID,
+	}
+}
+
+// EstimateAll returns positions for both directions of a line
+func (e *Estimator) EstimateAll(lineID string, at time.Time) []Position {
+	var positions []Position
+	for _, dir := range []string{topology.Forward, topology.Backward} {
+		if p := e.Estimate(lineID, dir, at); p != nil {
+			positions = append(positions, *p)
+		}
+	}
+	return positions
+}
@@ -0,0 +1,115 @@
+package validators
+
+import (
+	"fmt"
+	"metro-tools/internal/colors"
+	"metro-tools/internal/database"
+)
+
+const (
+	// MinLineColorDeltaE is the minimum CIEDE2000 difference between two lines in a city
+	MinLineColorDeltaE = 20.0
+	// MinLineColorDeltaECVD is the minimum difference under simulated colour-vision deficiency
+	MinLineColorDeltaECVD = 10.0
+	// MinMapContrast is the WCAG 1.4.11 non-text contrast required against map backgrounds
+	MinMapContrast = 3.0
+	// MinLabelContrast is the WCAG 1.4.3 text contrast required for label text on a line colour
+	MinLabelContrast = 4.5
+)
+
+// Map backgrounds and label colour that line colours are drawn against
+var (
+	MapBackgroundLight = "#F2EFE9" // OpenStreetMap land
+	MapBackgroundDark  = "#242424" // Dark basemap land
+	LabelTextColor     = "#FFFFFF"
+)
+
+// cvdChecks are the colour-vision deficiencies lines must remain distinct under
+var cvdChecks = []colors.Vision{colors.Protanopia, colors.Deuteranopia, colors.Tritanopia}
+
+// contrastCheck is a single WCAG contrast requirement for a line colour
+type contrastCheck struct {
+	against string
+	label   string
+	min     float64
+}
+
+var contrastChecks = []contrastCheck{
+	{MapBackgroundLight, "light map background", MinMapContrast},
+	{MapBackgroundDark, "dark map background", MinMapContrast},
+	{LabelTextColor, "white label text", MinLabelContrast},
+}
+
+// meetsContrast reports whether a colour passes every contrast check
+func meetsContrast(c colors.RGB) bool {
+	for _, check := range contrastChecks {
+		against, _ := colors.ParseHex(check.against)
+		if colors.ContrastRatio(c, against) < check.min {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateLineColors checks that line colours are distinct within a city and accessible on the map
+func ValidateLineColors(lines []database.MetroLine) *Result {
+	result := NewResult("color")
+
+	parsed := make(map[string]colors.RGB)
+	for _, line := range lines {
+		if c, err := colors.ParseHex(line.Color); err == nil {
+			parsed[line.ID] = c
+		}
+	}
+
+	for i, line := range lines {
+		c, ok := parsed[line.ID]
+		if !ok {
+			// Malformed colours are reported by ValidateLines
+			continue
+		}
+
+		// Distinctness against the later lines of the same city, so each pair is checked once
+		for _, other := range lines[i+1:] {
+			oc, ok := parsed[other.ID]
+			if !ok || other.CityID != line.CityID {
+				continue
+			}
+
+			if d := colors.CIEDE2000(c.Lab(), oc.Lab()); d < MinLineColorDeltaE {
+				result.AddWarning(line.ID, fmt.Sprintf("Color %s is too similar to '%s' (%s): ΔE2000 %.1f < %.0f", line.Color, other.ID, other.Color, d, MinLineColorDeltaE))
+				continue
+			}
+
+			for _, v := range cvdChecks {
+				d := colors.CIEDE2000(c.Simulate(v).Lab(), oc.Simulate(v).Lab())
+				if d < MinLineColorDeltaECVD {
+					result.AddWarning(line.ID, fmt.Sprintf("Color %s is hard to tell from '%s' (%s) with %s: ΔE2000 %.1f < %.0f", line.Color, other.ID, other.Color, v, d, MinLineColorDeltaECVD))
+				}
+			}
+		}
+
+		// WCAG contrast against backgrounds and label text
+		var failures []string
+		for _, check := range contrastChecks {
+			against, _ := colors.ParseHex(check.against)
+			if ratio := colors.ContrastRatio(c, against); ratio < check.min {
+				failures = append(failures, fmt.Sprintf("Contrast %.2f:1 against %s (%s) is below %.1f:1", ratio, check.label, check.against, check.min))
+			}
+		}
+		if len(failures) > 0 {
+			// One suggestion that passes every check, not just the failing one
+			suggestion, found := colors.NearestCompliant(c, meetsContrast)
+			for _, message := range failures {
+				if found {
+					message += fmt.Sprintf("; nearest compliant color is %s", suggestion.Hex())
+				}
+				result.AddWarning(line.ID, message)
+			}
+		}
+
+		result.AddPass()
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"fmt"
+	"metro-tools/internal/database"
+)
+
+// MaxExitMeters is the furthest an exit may be from its station's coordinates
+const MaxExitMeters = 300
+
+// ValidateFacilities checks station accessibility data: counts are sane,
+// step-free stations have lifts and exits are close to their station
+func ValidateFacilities(facilities []database.StationFacilities, stations []database.MetroStation) *Result {
+	result := NewResult("facility")
+
+	stationMap := make(map[string]database.MetroStation)
+	for _, s := range stations {
+		stationMap[s.ID] = s
+	}
+
+	for _, f := range facilities {
+		valid := true
+
+		if f.Lifts < 0 || f.Escalators < 0 {
+			result.AddError(f.StationID, fmt.Sprintf("Negative facility count (lifts %d, escalators %d)", f.Lifts, f.Escalators))
+			valid = false
+		}
+		if f.StepFree && f.Lifts <= 0 {
+			result.AddError(f.StationID, "Marked step-free but has no lifts")
+			valid = false
+		}
+
+		station, ok := stationMap[f.StationID]
+		if !ok {
+			// Reported by the reference validator
+			continue
+		}
+		for _, e := range f.Exits {
+			if e.Latitude < -90 || e.Latitude > 90 || e.Longitude < -180 || e.Longitude > 180 {
+				result.AddError(f.StationID, fmt.Sprintf("Exit '%s' has invalid coordinates (%f, %f)", e.Name, e.Latitude, e.Longitude))
+				valid = false
+				continue
+			}
+			meters := HaversineDistance(station.Latitude, station.Longitude, e.Latitude, e.Longitude) * 1000
+			if meters > MaxExitMeters {
+				result.AddError(f.StationID, fmt.Sprintf("Exit '%s' is %.0fm from the station (max %dm)", e.Name, meters, MaxExitMeters))
+				valid = false
+			}
+		}
+
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,92 @@
+package validators
+
+import (
+	"fmt"
+	"metro-tools/internal/database"
+)
+
+// MaxStationGroupMeters is the furthest apart two stations of one complex may be
+const MaxStationGroupMeters = 500
+
+// ValidateStationGroups checks that each station complex is a plausible walkway
+// interchange: at least two members, all in the group's city and close together
+func ValidateStationGroups(groups []database.StationGroup, stations []database.MetroStation) *Result {
+	result := NewResult("group")
+
+	stationMap := make(map[string]database.MetroStation)
+	for _, s := range stations {
+		stationMap[s.ID] = s
+	}
+	memberOf := make(map[string]string)
+
+	for _, g := range groups {
+		valid := true
+
+		if len(g.StationIDs) < 2 {
+			result.AddError(g.ID, fmt.Sprintf("Group has %d member(s), needs at least 2", len(g.StationIDs)))
+			valid = false
+		}
+		if g.WalkSeconds <= 0 {
+			result.AddError(g.ID, fmt.Sprintf("walk_seconds must be positive (got %d)", g.WalkSeconds))
+			valid = false
+		}
+
+		var members []database.MetroStation
+		for _, id := range g.StationIDs {
+			if other, ok := memberOf[id]; ok && other != g.ID {
+				result.AddError(g.ID, fmt.Sprintf("Station %s is also in group %s", id, other))
+				valid = false
+			}
+			memberOf[id] = g.ID
+
+			st, ok := stationMap[id]
+			if !ok {
+				// Reported by the reference validator
+				valid = false
+				continue
+			}
+			if st.CityID != g.CityID {
+				result.AddError(g.ID, fmt.Sprintf("Station %s is in %s, group is in %s", id, st.CityID, g.CityID))
+				valid = false
+			}
+			members = append(members, st)
+		}
+
+		for i := 0; i < len(members); i++ {
+			for j := i + 1; j < len(members); j++ {
+				a, b := members[i], members[j]
+				meters := HaversineDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) * 1000
+				if meters > MaxStationGroupMeters {
+					result.AddError(g.ID, fmt.Sprintf("%s and %s are %.0fm apart (max %dm)", a.ID, b.ID, meters, MaxStationGroupMeters))
+					valid = false
+				}
+			}
+		}
+
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	return result
+}
+
+// GroupLines returns the lines serving each station together with the other
+// members of its complex, for stations that are in a group
+func GroupLines(groups []database.StationGroup, linesPerStation map[string][]string) map[string][]string {
+	combined := make(map[string][]string)
+	for _, g := range groups {
+		var lines []string
+		for _, id := range g.StationIDs {
+			for _, line := range linesPerStation[id] {
+				if !containsLine(lines, line) {
+					lines = append(lines, line)
+				}
+			}
+		}
+		for _, id := range g.StationIDs {
+			combined[id] = lines
+		}
+	}
+	return combined
+}
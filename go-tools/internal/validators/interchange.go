@@ -5,16 +5,19 @@ import (
 	"metro-tools/internal/database"
 )
 
-// ValidateInterchanges validates interchange station consistency
-func ValidateInterchanges(stations []database.MetroStation, linesPerStation map[string][]string) *Result {
+// ValidateInterchanges validates interchange station consistency. A station
+// in a complex counts the lines of the whole complex.
+func ValidateInterchanges(stations []database.MetroStation, linesPerStation map[string][]string, groups []database.StationGroup) *Result {
 	result := NewResult("interchange")
+	groupLines := GroupLines(groups, linesPerStation)
 
 	for _, station := range stations {
 		valid := true
 		lineCount := len(linesPerStation[station.ID])
 
-		// Case 1: Station is marked as interchange but only has 1 line
-		if station.IsInterchange && lineCount < 2 {
+		// Case 1: Station is marked as interchange but only has 1 line,
+		// and is not joined by a walkway to a station on another line
+		if station.IsInterchange && lineCount < 2 && len(groupLines[station.ID]) < 2 {
 			result.AddError(station.ID, fmt.Sprintf("Marked as interchange but only on %d line(s)", lineCount))
 			valid = false
 		}
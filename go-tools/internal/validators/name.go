@@ -0,0 +1,78 @@
+package validators
+
+import (
+	"fmt"
+	"strings"
+
+	"metro-tools/internal/database"
+	"metro-tools/internal/names"
+)
+
+// ValidateStationNames checks that every station is named in its city's
+// languages, in the right script, with Latin transliterations. migrated and
+// languagesMigrated are false when station_names or city_languages do not
+// exist yet.
+func ValidateStationNames(stationNames []database.StationName, cityLanguages []database.CityLanguage, stations []database.MetroStation, migrated, languagesMigrated bool) *Result {
+	result := NewResult("name")
+	if !migrated {
+		result.AddWarning("station_names",
+			"Table not found; station names are shown in English only (run add-station-names.ts)")
+		return result
+	}
+	if !languagesMigrated {
+		result.AddWarning("city_languages",
+			"Table not found; no station name languages are required (run add-city-languages.ts)")
+	}
+
+	for _, l := range cityLanguages {
+		if _, known := names.Scripts[l.Lang]; !known {
+			result.AddWarning(fmt.Sprintf("city_languages#%d", l.ID), fmt.Sprintf("Unknown language '%s' for city %s", l.Lang, l.CityID))
+		}
+	}
+
+	byStation := make(map[string]map[string]bool)
+	for _, n := range stationNames {
+		id := fmt.Sprintf("station_names#%d", n.ID)
+		valid := true
+
+		if byStation[n.StationID] == nil {
+			byStation[n.StationID] = make(map[string]bool)
+		}
+		if byStation[n.StationID][n.Lang] {
+			result.AddError(id, fmt.Sprintf("Duplicate '%s' name for station %s", n.Lang, n.StationID))
+			valid = false
+		}
+		byStation[n.StationID][n.Lang] = true
+
+		if strings.TrimSpace(n.Name) == "" {
+			result.AddError(id, fmt.Sprintf("Empty '%s' name for station %s", n.Lang, n.StationID))
+			valid = false
+		} else if ok, known := names.MatchesScript(n.Name, n.Lang); !known {
+			result.AddWarning(id, fmt.Sprintf("Unknown language '%s' for station %s", n.Lang, n.StationID))
+		} else if !ok {
+			result.AddError(id, fmt.Sprintf("'%s' is not written in the script of '%s'", n.Name, n.Lang))
+			valid = false
+		}
+		if n.Transliteration != "" {
+			if ok, _ := names.MatchesScript(n.Transliteration, names.Default); !ok {
+				result.AddError(id, fmt.Sprintf("Transliteration '%s' is not in Latin script", n.Transliteration))
+				valid = false
+			}
+		}
+
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	languages := names.NewLanguages(cityLanguages)
+	for _, st := range stations {
+		for _, lang := range languages[st.CityID] {
+			if !byStation[st.ID][lang] {
+				result.AddError(st.ID, fmt.Sprintf("Missing '%s' name", lang))
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,173 @@
+package validators
+
+import (
+	"fmt"
+	"metro-tools/internal/database"
+	"sort"
+	"strconv"
+)
+
+// ReferenceData is every table with a foreign key, plus SQLite's own check
+type ReferenceData struct {
+	Cities       []database.City
+	Lines        []database.MetroLine
+	Stations     []database.MetroStation
+	LineStations []database.LineStation
+	Connections  []database.StationConnection
+	Schedules    []database.TrainSchedule
+	PeakHours    []database.PeakHour
+	Sightings    []database.TrainSighting
+	Transfers    []database.InterchangeTransfer
+	Groups       []database.StationGroup
+	Facilities   []database.StationFacilities
+	Names        []database.StationName
+	Languages    []database.CityLanguage
+	Violations   []database.ForeignKeyViolation // from PRAGMA foreign_key_check
+}
+
+// reference is one foreign key value to resolve
+type reference struct {
+	column string
+	value  string
+	target map[string]bool
+	parent string
+}
+
+// ValidateReferences checks that every foreign key in the schema points at an existing row
+func ValidateReferences(data ReferenceData) *Result {
+	result := NewResult("reference")
+
+	cityIDs := make(map[string]bool)
+	for _, c := range data.Cities {
+		cityIDs[c.ID] = true
+	}
+	lineIDs := make(map[string]bool)
+	for _, l := range data.Lines {
+		lineIDs[l.ID] = true
+	}
+	stationIDs := make(map[string]bool)
+	for _, s := range data.Stations {
+		stationIDs[s.ID] = true
+	}
+	scheduleIDs := make(map[string]bool)
+	for _, ts := range data.Schedules {
+		scheduleIDs[strconv.Itoa(ts.ID)] = true
+	}
+
+	// covered records the table.column pairs checked here, so orphans found
+	// by foreign_key_check are not reported twice
+	covered := make(map[string]bool)
+	check := func(table, id string, refs ...reference) {
+		valid := true
+		for _, ref := range refs {
+			covered[table+"."+ref.column] = true
+			if !ref.target[ref.value] {
+				result.AddError(table+"#"+id, fmt.Sprintf("%s '%s' does not exist in %s", ref.column, ref.value, ref.parent))
+				valid = false
+			}
+		}
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	for _, l := range data.Lines {
+		check("metro_lines", l.ID,
+			reference{"city_id", l.CityID, cityIDs, "cities"})
+	}
+	for _, s := range data.Stations {
+		check("metro_stations", s.ID,
+			reference{"city_id", s.CityID, cityIDs, "cities"})
+	}
+	for _, ls := range data.LineStations {
+		check("line_stations", strconv.Itoa(ls.ID),
+			reference{"line_id", ls.LineID, lineIDs, "metro_lines"},
+			reference{"station_id", ls.StationID, stationIDs, "metro_stations"})
+	}
+	for _, c := range data.Connections {
+		check("station_connections", strconv.Itoa(c.ID),
+			reference{"from_station_id", c.FromStationID, stationIDs, "metro_stations"},
+			reference{"to_station_id", c.ToStationID, stationIDs, "metro_stations"},
+			reference{"line_id", c.LineID, lineIDs, "metro_lines"})
+	}
+	for _, ts := range data.Schedules {
+		check("train_schedules", strconv.Itoa(ts.ID),
+			reference{"line_id", ts.LineID, lineIDs, "metro_lines"},
+			reference{"start_station_id", ts.StartStationID, stationIDs, "metro_stations"},
+			reference{"end_station_id", ts.EndStationID, stationIDs, "metro_stations"})
+	}
+	for _, ph := range data.PeakHours {
+		check("peak_hours", strconv.Itoa(ph.ID),
+			reference{"schedule_id", strconv.Itoa(ph.ScheduleID), scheduleIDs, "train_schedules"})
+	}
+	for _, s := range data.Sightings {
+		check("train_sightings", strconv.Itoa(s.ID),
+			reference{"line_id", s.LineID, lineIDs, "metro_lines"},
+			reference{"station_id", s.StationID, stationIDs, "metro_stations"})
+	}
+	for _, t := range data.Transfers {
+		check("interchange_transfers", strconv.Itoa(t.ID),
+			reference{"station_id", t.StationID, stationIDs, "metro_stations"},
+			reference{"from_line_id", t.FromLineID, lineIDs, "metro_lines"},
+			reference{"to_line_id", t.ToLineID, lineIDs, "metro_lines"})
+	}
+	for _, g := range data.Groups {
+		check("station_groups", g.ID,
+			reference{"city_id", g.CityID, cityIDs, "cities"})
+		// Members without a group are not loaded, so their group_id is
+		// left to foreign_key_check
+		for _, id := range g.StationIDs {
+			check("station_group_members", g.ID+"/"+id,
+				reference{"station_id", id, stationIDs, "metro_stations"})
+		}
+	}
+	for _, f := range data.Facilities {
+		check("station_facilities", f.StationID,
+			reference{"station_id", f.StationID, stationIDs, "metro_stations"})
+	}
+	for _, n := range data.Names {
+		check("station_names", strconv.Itoa(n.ID),
+			reference{"station_id", n.StationID, stationIDs, "metro_stations"})
+	}
+
+	for _, l := range data.Languages {
+		check("city_languages", strconv.Itoa(l.ID),
+			reference{"city_id", l.CityID, cityIDs, "cities"})
+	}
+
+	// Summarise SQLite's own view of orphans per table and constraint for
+	// foreign keys declared in the database but not checked above
+	type orphanKey struct{ table, column, parent string }
+	orphans := make(map[orphanKey][]string)
+	for _, v := range data.Violations {
+		if covered[v.Table+"."+v.Column] {
+			continue
+		}
+		key := orphanKey{v.Table, v.Column, v.Parent}
+		row := v.RowKey
+		if row == "" {
+			row = fmt.Sprintf("rowid %d", v.RowID)
+		}
+		orphans[key] = append(orphans[key], row)
+	}
+	keys := make([]orphanKey, 0, len(orphans))
+	for k := range orphans {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].table != keys[j].table {
+			return keys[i].table < keys[j].table
+		}
+		return keys[i].column < keys[j].column
+	})
+	for _, k := range keys {
+		rows := orphans[k]
+		sample := rows
+		if len(sample) > 5 {
+			sample = sample[:5]
+		}
+		result.AddError(k.table, fmt.Sprintf("foreign_key_check: %d orphaned row(s) where %s references missing %s (e.g. %v)", len(rows), k.column, k.parent, sample))
+	}
+
+	return result
+}
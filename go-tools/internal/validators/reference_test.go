@@ -0,0 +1,77 @@
+package validators
+
+import (
+	"reflect"
+	"testing"
+
+	"metro-tools/internal/database"
+)
+
+func TestValidateReferences(t *testing.T) {
+	base := ReferenceData{
+		Cities:   []database.City{{ID: "delhi"}},
+		Lines:    []database.MetroLine{{ID: "yellow", CityID: "delhi"}},
+		Stations: []database.MetroStation{{ID: "a", CityID: "delhi"}, {ID: "b", CityID: "delhi"}},
+	}
+
+	tests := []struct {
+		name   string
+		edit   func(d *ReferenceData)
+		passed int
+		want   []Issue
+	}{
+		{
+			name:   "every key resolves",
+			edit:   func(d *ReferenceData) {},
+			passed: 3,
+		},
+		{
+			name: "orphan group member reported once",
+			edit: func(d *ReferenceData) {
+				d.Groups = []database.StationGroup{{ID: "ab", CityID: "delhi", StationIDs: []string{"a", "gone"}}}
+				d.Violations = []database.ForeignKeyViolation{
+					{Table: "station_group_members", RowID: 2, RowKey: "2", Parent: "metro_stations", Column: "station_id", ParentID: "gone"},
+				}
+			},
+			passed: 5,
+			want: []Issue{{SeverityError, "reference", "station_group_members#ab/gone",
+				"station_id 'gone' does not exist in metro_stations"}},
+		},
+		{
+			name: "orphan group city",
+			edit: func(d *ReferenceData) {
+				d.Groups = []database.StationGroup{{ID: "ab", CityID: "mumbai", StationIDs: []string{"a", "b"}}}
+				d.Violations = []database.ForeignKeyViolation{
+					{Table: "station_groups", RowID: 1, RowKey: "ab", Parent: "cities", Column: "city_id", ParentID: "mumbai"},
+				}
+			},
+			passed: 5,
+			want: []Issue{{SeverityError, "reference", "station_groups#ab",
+				"city_id 'mumbai' does not exist in cities"}},
+		},
+		{
+			name: "members of a missing group are left to foreign_key_check",
+			edit: func(d *ReferenceData) {
+				d.Violations = []database.ForeignKeyViolation{
+					{Table: "station_group_members", RowID: 3, RowKey: "3", Parent: "station_groups", Column: "group_id", ParentID: "gone"},
+				}
+			},
+			passed: 3,
+			want: []Issue{{SeverityError, "reference", "station_group_members",
+				"foreign_key_check: 1 orphaned row(s) where group_id references missing station_groups (e.g. [3])"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := base
+			tt.edit(&data)
+			result := ValidateReferences(data)
+			if result.Passed != tt.passed {
+				t.Errorf("passed = %d, want %d", result.Passed, tt.passed)
+			}
+			if len(result.Issues) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(result.Issues, tt.want)) {
+				t.Errorf("issues = %+v\nwant %+v", result.Issues, tt.want)
+			}
+		})
+	}
+}
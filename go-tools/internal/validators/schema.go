@@ -0,0 +1,27 @@
+package validators
+
+import (
+	"metro-tools/internal/database"
+)
+
+// ValidateSchema reports required tables and columns that are missing
+func ValidateSchema(tables []string, problems []database.SchemaProblem) *Result {
+	result := NewResult("schema")
+
+	byTable := make(map[string][]database.SchemaProblem)
+	for _, p := range problems {
+		byTable[p.Table] = append(byTable[p.Table], p)
+	}
+
+	for _, table := range tables {
+		if len(byTable[table]) == 0 {
+			result.AddPass()
+			continue
+		}
+		for _, p := range byTable[table] {
+			result.AddError(table, p.String())
+		}
+	}
+
+	return result
+}
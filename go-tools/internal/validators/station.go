@@ -7,8 +7,8 @@ import (
 	"strings"
 )
 
-// haversineDistance calculates the distance in km between two coordinates
-func haversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
+// HaversineDistance calculates the distance in km between two coordinates
+func HaversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	const earthRadius = 6371.0 // km
 
 	lat1Rad := lat1 * math.Pi / 180
@@ -87,7 +87,7 @@ func ValidateStations(stations []database.MetroStation, cities []database.City)
 
 		// Check distance from city center (should be within 50km)
 		if cityCenter, ok := cityMap[station.CityID]; ok {
-			distance := haversineDistance(station.Latitude, station.Longitude, cityCenter.Lat, cityCenter.Lng)
+			distance := HaversineDistance(station.Latitude, station.Longitude, cityCenter.Lat, cityCenter.Lng)
 			if distance > 50 {
 				result.AddError(station.ID, fmt.Sprintf("Station is %.1f km from %s city center (max 50km)", distance, cityNames[station.CityID]))
 				valid = false
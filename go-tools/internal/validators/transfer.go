@@ -0,0 +1,99 @@
+package validators
+
+import (
+	"fmt"
+	"metro-tools/internal/database"
+	"sort"
+)
+
+// MaxTransferWalkSeconds is the longest plausible walk between two platforms
+const MaxTransferWalkSeconds = 900
+
+// ValidateTransfers checks interchange_transfers against the lines serving
+// each station. migrated is false when the table does not exist yet.
+func ValidateTransfers(transfers []database.InterchangeTransfer, linesPerStation map[string][]string, migrated bool) *Result {
+	result := NewResult("transfer")
+
+	if !migrated {
+		result.AddWarning("interchange_transfers",
+			"Table not found; routing assumes 120s for every change of line (run add-interchange-transfers.ts)")
+		return result
+	}
+
+	type pair struct{ station, from, to string }
+	recorded := make(map[pair]bool)
+
+	for _, t := range transfers {
+		id := fmt.Sprintf("interchange_transfers#%d", t.ID)
+		valid := true
+
+		lines := linesPerStation[t.StationID]
+		if t.FromLineID == t.ToLineID {
+			result.AddError(id, fmt.Sprintf("Transfer at %s is from %s to itself", t.StationID, t.FromLineID))
+			valid = false
+		}
+		for _, line := range []string{t.FromLineID, t.ToLineID} {
+			if !containsLine(lines, line) {
+				result.AddError(id, fmt.Sprintf("Line %s does not serve %s", line, t.StationID))
+				valid = false
+			}
+		}
+
+		if t.WalkSeconds <= 0 {
+			result.AddError(id, fmt.Sprintf("walk_seconds must be positive (got %d)", t.WalkSeconds))
+			valid = false
+		} else if t.WalkSeconds > MaxTransferWalkSeconds {
+			result.AddWarning(id, fmt.Sprintf("walk_seconds %d is unusually long (over %ds)", t.WalkSeconds, MaxTransferWalkSeconds))
+		}
+
+		key := pair{t.StationID, t.FromLineID, t.ToLineID}
+		if recorded[key] {
+			result.AddError(id, fmt.Sprintf("Duplicate transfer %s -> %s at %s", t.FromLineID, t.ToLineID, t.StationID))
+			valid = false
+		}
+		recorded[key] = true
+
+		if valid {
+			result.AddPass()
+		}
+	}
+
+	// Every pair of lines at an interchange needs a time, in at least one
+	// direction (routing assumes the same walk both ways)
+	stations := make([]string, 0, len(linesPerStation))
+	for station := range linesPerStation {
+		stations = append(stations, station)
+	}
+	sort.Strings(stations)
+
+	for _, station := range stations {
+		lines := linesPerStation[station]
+		if len(lines) < 2 {
+			continue
+		}
+		var missing []string
+		for i := 0; i < len(lines); i++ {
+			for j := i + 1; j < len(lines); j++ {
+				if !recorded[pair{station, lines[i], lines[j]}] && !recorded[pair{station, lines[j], lines[i]}] {
+					missing = append(missing, lines[i]+" <-> "+lines[j])
+				}
+			}
+		}
+		if len(missing) > 0 {
+			result.AddError(station, fmt.Sprintf("No transfer time for %d line pair(s): %v", len(missing), missing))
+		} else {
+			result.AddPass()
+		}
+	}
+
+	return result
+}
+
+func containsLine(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
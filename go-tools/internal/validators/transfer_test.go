@@ -0,0 +1,101 @@
+package validators
+
+import (
+	"reflect"
+	"testing"
+
+	"metro-tools/internal/database"
+)
+
+func transfer(id int, station, from, to string, seconds int) database.InterchangeTransfer {
+	return database.InterchangeTransfer{ID: id, StationID: station, FromLineID: from, ToLineID: to, WalkSeconds: seconds}
+}
+
+func TestValidateTransfers(t *testing.T) {
+	lines := map[string][]string{
+		"a": {"yellow"},
+		"x": {"yellow", "blue"},
+		"y": {"yellow", "blue", "violet"},
+	}
+	complete := []database.InterchangeTransfer{
+		transfer(1, "x", "yellow", "blue", 120),
+		transfer(2, "y", "yellow", "blue", 90),
+		transfer(3, "y", "violet", "yellow", 150),
+		transfer(4, "y", "blue", "violet", 60),
+	}
+
+	tests := []struct {
+		name      string
+		transfers []database.InterchangeTransfer
+		migrated  bool
+		passed    int
+		want      []Issue
+	}{
+		{
+			name:      "every pair recorded in one direction",
+			transfers: complete,
+			migrated:  true,
+			passed:    6,
+		},
+		{
+			name:      "table missing only warns",
+			transfers: nil,
+			migrated:  false,
+			want: []Issue{{SeverityWarning, "transfer", "interchange_transfers",
+				"Table not found; routing assumes 120s for every change of line (run add-interchange-transfers.ts)"}},
+		},
+		{
+			name:      "missing line pairs",
+			transfers: complete[:2],
+			migrated:  true,
+			passed:    3,
+			want: []Issue{{SeverityError, "transfer", "y",
+				"No transfer time for 2 line pair(s): [yellow <-> violet blue <-> violet]"}},
+		},
+		{
+			name:      "no transfers at all",
+			transfers: []database.InterchangeTransfer{},
+			migrated:  true,
+			want: []Issue{
+				{SeverityError, "transfer", "x", "No transfer time for 1 line pair(s): [yellow <-> blue]"},
+				{SeverityError, "transfer", "y", "No transfer time for 3 line pair(s): [yellow <-> blue yellow <-> violet blue <-> violet]"},
+			},
+		},
+		{
+			name:      "duplicate rows",
+			transfers: append([]database.InterchangeTransfer{transfer(9, "x", "yellow", "blue", 100)}, complete...),
+			migrated:  true,
+			passed:    6,
+			want: []Issue{{SeverityError, "transfer", "interchange_transfers#1",
+				"Duplicate transfer yellow -> blue at x"}},
+		},
+		{
+			name: "bad rows",
+			transfers: append([]database.InterchangeTransfer{
+				transfer(10, "x", "blue", "blue", 60),
+				transfer(11, "a", "yellow", "blue", 60),
+				transfer(12, "x", "blue", "yellow", 0),
+				transfer(13, "y", "blue", "yellow", 1000),
+			}, complete...),
+			migrated: true,
+			passed:   7,
+			want: []Issue{
+				{SeverityError, "transfer", "interchange_transfers#10", "Transfer at x is from blue to itself"},
+				{SeverityError, "transfer", "interchange_transfers#11", "Line blue does not serve a"},
+				{SeverityError, "transfer", "interchange_transfers#12", "walk_seconds must be positive (got 0)"},
+				{SeverityWarning, "transfer", "interchange_transfers#13", "walk_seconds 1000 is unusually long (over 900s)"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ValidateTransfers(tt.transfers, lines, tt.migrated)
+			if result.Passed != tt.passed {
+				t.Errorf("passed = %d, want %d", result.Passed, tt.passed)
+			}
+			if len(result.Issues) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(result.Issues, tt.want)) {
+				t.Errorf("issues = %+v\nwant %+v", result.Issues, tt.want)
+			}
+		})
+	}
+}